@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/dashboards/dashboards"
+)
+
+var usage = `
+Usage: ./kibana_dashboards [options] -dashboard <id>[,<id>...]
+
+Exports dashboards from Kibana, together with the visualizations, searches and
+index patterns they use, into the directory layout of the Beats dashboards:
+
+	./kibana_dashboards -kibana http://localhost:5601 -dashboard f3e771c0-eb19-11e6-be20-559646f8b9ba -dir _meta/kibana
+
+Deletes dashboards from Kibana, including the visualizations and searches they
+use if -references is set:
+
+	./kibana_dashboards -kibana http://localhost:5601 -dashboard f3e771c0-eb19-11e6-be20-559646f8b9ba -delete -references
+
+`
+
+type options struct {
+	Kibana               string
+	User                 string
+	Pass                 string
+	Dashboards           string
+	Dir                  string
+	Delete               bool
+	References           bool
+	Certificate          string
+	CertificateKey       string
+	CertificateAuthority string
+	Insecure             bool
+	Quiet                bool
+}
+
+func parseCommandLine() (*options, error) {
+	var opt options
+
+	flagSet := flag.NewFlagSet("kibana_dashboards", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		os.Stderr.WriteString(usage)
+		flagSet.PrintDefaults()
+	}
+
+	flagSet.StringVar(&opt.Kibana, "kibana", "http://localhost:5601", "Kibana URL")
+	flagSet.StringVar(&opt.User, "user", "", "Username to connect to the Kibana API. By default no username is passed.")
+	flagSet.StringVar(&opt.Pass, "pass", "", "Password to connect to the Kibana API. By default no password is passed.")
+	flagSet.StringVar(&opt.Dashboards, "dashboard", "", "Comma separated list of the IDs of the dashboards.")
+	flagSet.StringVar(&opt.Dir, "dir", "_meta/kibana", "Directory the dashboards are exported to.")
+	flagSet.BoolVar(&opt.Delete, "delete", false, "Delete the dashboards instead of exporting them.")
+	flagSet.BoolVar(&opt.References, "references", false, "Delete the visualizations and searches used by the dashboards too. Index patterns are never deleted.")
+	flagSet.StringVar(&opt.CertificateAuthority, "cacert", "", "Certificate Authority for server verification")
+	flagSet.StringVar(&opt.Certificate, "cert", "", "Certificate for SSL client authentication in PEM format.")
+	flagSet.StringVar(&opt.CertificateKey, "key", "", "Client Certificate Key in PEM format.")
+	flagSet.BoolVar(&opt.Insecure, "insecure", false, `Allows "insecure" SSL connections`)
+	flagSet.BoolVar(&opt.Quiet, "quiet", false, "Suppresses all status messages. Error messages are still printed to stderr.")
+
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
+
+	if opt.Dashboards == "" {
+		return nil, errors.New("Missing dashboards. Please specify the IDs of the dashboards with -dashboard")
+	}
+	if opt.References && !opt.Delete {
+		return nil, errors.New("The -references option can only be used together with -delete")
+	}
+	if (opt.Certificate == "") != (opt.CertificateKey == "") {
+		return nil, errors.New("Both the -cert and the -key options are required for SSL client authentication")
+	}
+	return &opt, nil
+}
+
+func run() error {
+	opt, err := parseCommandLine()
+	if err != nil {
+		return err
+	}
+
+	tlsConfig := common.MapStr{}
+	if opt.Insecure {
+		tlsConfig["verification_mode"] = "none"
+	}
+	if opt.Certificate != "" {
+		tlsConfig["certificate"] = opt.Certificate
+		tlsConfig["key"] = opt.CertificateKey
+	}
+	if opt.CertificateAuthority != "" {
+		tlsConfig["certificate_authorities"] = []string{opt.CertificateAuthority}
+	}
+	if len(tlsConfig) > 0 {
+		tlsConfig["enabled"] = "true"
+	}
+
+	config, err := common.NewConfigFrom(common.MapStr{
+		"host":     opt.Kibana,
+		"username": opt.User,
+		"password": opt.Pass,
+		"ssl":      tlsConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("Fail to create a common.Config from the Kibana options: %v", err)
+	}
+
+	statusMsg := dashboards.MessageOutputter(func(msg string, a ...interface{}) {
+		if !opt.Quiet {
+			fmt.Println(fmt.Sprintf(msg, a...))
+		}
+	})
+
+	var ids []string
+	for _, id := range strings.Split(opt.Dashboards, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	if opt.Delete {
+		return dashboards.DeleteDashboardsViaKibana(config, ids, opt.References, statusMsg)
+	}
+	return dashboards.ExportDashboardsViaKibana(config, ids, opt.Dir, statusMsg)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Exiting")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,104 @@
+package hints
+
+import (
+	"strconv"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+func init() {
+	autodiscover.Registry.MustAddBuilder(autodiscover.HintsBuilder, NewLogHints)
+}
+
+const (
+	logs = "logs"
+
+	enabled         = "enabled"
+	multilinePrefix = "multiline."
+	includeLines    = "include_lines"
+	excludeLines    = "exclude_lines"
+)
+
+// logHints creates the prospector configs for the containers with logs
+// hints, e.g. co.elastic.logs/multiline.pattern.
+type logHints struct {
+	config *common.Config
+}
+
+type logHintsConfig struct {
+	Config *common.Config `config:"config"`
+}
+
+var defaultConfig = map[string]interface{}{
+	"config": map[string]interface{}{
+		"input_type":           "log",
+		"paths":                []string{"/var/lib/docker/containers/${data.container.id}/*.log"},
+		"json.message_key":     "log",
+		"json.keys_under_root": true,
+	},
+}
+
+// NewLogHints creates the hints builder of filebeat. The config setting
+// holds the prospector config the hints are applied to.
+func NewLogHints(cfg *common.Config) (autodiscover.Builder, error) {
+	config, err := common.NewConfigFrom(defaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Merge(cfg); err != nil {
+		return nil, err
+	}
+
+	settings := logHintsConfig{}
+	if err := config.Unpack(&settings); err != nil {
+		return nil, err
+	}
+	return &logHints{config: settings.Config}, nil
+}
+
+// CreateConfig returns the prospector config for the logs hints found in
+// data. No config is returned if there are no logs hints, or the logs
+// collection is disabled by the enabled hint.
+func (l *logHints) CreateConfig(data common.MapStr) []*common.Config {
+	if !autodiscover.HasHints(data, logs) {
+		return nil
+	}
+	if value := autodiscover.GetHintString(data, logs, enabled); value != "" {
+		if on, err := strconv.ParseBool(value); err != nil || !on {
+			return nil
+		}
+	}
+
+	settings := common.MapStr{}
+	for _, setting := range []string{"pattern", "match"} {
+		if value := autodiscover.GetHintString(data, logs, multilinePrefix+setting); value != "" {
+			settings.Put(multilinePrefix+setting, value)
+		}
+	}
+	if value := autodiscover.GetHintString(data, logs, multilinePrefix+"negate"); value != "" {
+		negate, err := strconv.ParseBool(value)
+		if err != nil {
+			logp.Err("Invalid multiline.negate hint %v: %v", value, err)
+			return nil
+		}
+		settings.Put(multilinePrefix+"negate", negate)
+	}
+	for _, setting := range []string{includeLines, excludeLines} {
+		if list := autodiscover.GetHintList(data, logs, setting); len(list) > 0 {
+			settings[setting] = list
+		}
+	}
+
+	config := common.NewConfig()
+	if err := config.Merge(l.config); err != nil {
+		logp.Err("Error creating config from hints: %v", err)
+		return nil
+	}
+	if err := config.Merge(settings); err != nil {
+		logp.Err("Error creating config from hints: %v", err)
+		return nil
+	}
+	return []*common.Config{config}
+}
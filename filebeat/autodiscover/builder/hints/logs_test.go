@@ -0,0 +1,103 @@
+// +build !integration
+
+package hints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type prospectorConfig struct {
+	InputType    string   `config:"input_type"`
+	Paths        []string `config:"paths"`
+	ExcludeLines []string `config:"exclude_lines"`
+	IncludeLines []string `config:"include_lines"`
+	JSON         struct {
+		MessageKey string `config:"message_key"`
+	} `config:"json"`
+	Multiline struct {
+		Pattern string `config:"pattern"`
+		Negate  bool   `config:"negate"`
+		Match   string `config:"match"`
+	} `config:"multiline"`
+}
+
+func newTestBuilder(t *testing.T, settings map[string]interface{}) autodiscover.Builder {
+	cfg, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+	builder, err := NewLogHints(cfg)
+	require.NoError(t, err)
+	return builder
+}
+
+func testData(labels map[string]string) common.MapStr {
+	data := common.MapStr{
+		"container": common.MapStr{"id": "abc", "name": "web"},
+	}
+	if hints := autodiscover.GenerateHints(labels, "co.elastic."); len(hints) > 0 {
+		data["hints"] = hints
+	}
+	return data
+}
+
+func TestLogHints(t *testing.T) {
+	builder := newTestBuilder(t, map[string]interface{}{"enabled": true})
+
+	configs := autodiscover.Builders{builder}.GetConfigs(testData(map[string]string{
+		"co.elastic.logs/multiline.pattern": "^\\s",
+		"co.elastic.logs/multiline.negate":  "false",
+		"co.elastic.logs/multiline.match":   "after",
+		"co.elastic.logs/exclude_lines":     "^DBG,^TRACE",
+		"co.elastic.logs/include_lines":     "^ERR",
+	}))
+	require.Len(t, configs, 1)
+
+	config := prospectorConfig{}
+	require.NoError(t, configs[0].Unpack(&config))
+	assert.Equal(t, "log", config.InputType)
+	assert.Equal(t, []string{"/var/lib/docker/containers/abc/*.log"}, config.Paths)
+	assert.Equal(t, "log", config.JSON.MessageKey)
+	assert.Equal(t, "^\\s", config.Multiline.Pattern)
+	assert.False(t, config.Multiline.Negate)
+	assert.Equal(t, "after", config.Multiline.Match)
+	assert.Equal(t, []string{"^DBG", "^TRACE"}, config.ExcludeLines)
+	assert.Equal(t, []string{"^ERR"}, config.IncludeLines)
+}
+
+func TestLogHintsCustomConfig(t *testing.T) {
+	builder := newTestBuilder(t, map[string]interface{}{
+		"enabled": true,
+		"config": map[string]interface{}{
+			"paths": []string{"/var/log/containers/${data.container.name}.log"},
+		},
+	})
+
+	configs := autodiscover.Builders{builder}.GetConfigs(testData(map[string]string{
+		"co.elastic.logs/enabled": "true",
+	}))
+	require.Len(t, configs, 1)
+
+	config := prospectorConfig{}
+	require.NoError(t, configs[0].Unpack(&config))
+	assert.Equal(t, []string{"/var/log/containers/web.log"}, config.Paths)
+	assert.Equal(t, "log", config.InputType)
+}
+
+func TestLogHintsNoConfig(t *testing.T) {
+	builder := newTestBuilder(t, map[string]interface{}{"enabled": true})
+
+	tests := []map[string]string{
+		nil,
+		{"co.elastic.metrics/module": "redis"},
+		{"co.elastic.logs/enabled": "false"},
+		{"co.elastic.logs/multiline.negate": "maybe"},
+	}
+	for _, labels := range tests {
+		assert.Empty(t, builder.CreateConfig(testData(labels)), "%v", labels)
+	}
+}
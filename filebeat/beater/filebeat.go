@@ -10,14 +10,19 @@ import (
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/outputs/elasticsearch"
 
 	cfg "github.com/elastic/beats/filebeat/config"
 	"github.com/elastic/beats/filebeat/crawler"
 	"github.com/elastic/beats/filebeat/fileset"
+	"github.com/elastic/beats/filebeat/ingest"
 	"github.com/elastic/beats/filebeat/publisher"
 	"github.com/elastic/beats/filebeat/registrar"
 	"github.com/elastic/beats/filebeat/spooler"
+
+	// Register the hints builder of filebeat.
+	_ "github.com/elastic/beats/filebeat/autodiscover/builder/hints"
 )
 
 var (
@@ -66,7 +71,7 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 		}
 	}
 
-	if !config.ProspectorReload.Enabled() && !haveEnabledProspectors {
+	if !config.ProspectorReload.Enabled() && config.Autodiscover == nil && !haveEnabledProspectors {
 		return nil, errors.New("No modules or prospectors enabled and configuration reloading disabled. What files do you want me to watch?")
 	}
 
@@ -74,6 +79,10 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 		return nil, errors.New("prospector reloading and -once cannot be used together.")
 	}
 
+	if *once && config.Autodiscover != nil {
+		return nil, errors.New("autodiscover and -once cannot be used together.")
+	}
+
 	fb := &Filebeat{
 		done:           make(chan struct{}),
 		config:         &config,
@@ -114,6 +123,24 @@ func (fb *Filebeat) loadModulesPipelines(b *beat.Beat) error {
 	return nil
 }
 
+// loadLocalPipelines creates the runner for the ingest pipelines of the modules
+// when the pipelines run in filebeat.
+func (fb *Filebeat) loadLocalPipelines() (*ingest.Runner, error) {
+	logp.Info("Running the ingest pipelines of the modules in filebeat")
+
+	runner, err := ingest.NewRunner(fb.config.LocalPipelines)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fb.moduleRegistry.LoadLocalPipelines(runner)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
 func (fb *Filebeat) loadModulesML(b *beat.Beat) error {
 	logp.Debug("machine-learning", "Setting up ML jobs for modules")
 
@@ -137,8 +164,18 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	var err error
 	config := fb.config
 
+	fb.moduleRegistry.RegisterState(monitoring.State)
+
+	// Pipelines of the modules run in filebeat if they are not loaded into Elasticsearch
+	var pipelines *ingest.Runner
 	if !fb.moduleRegistry.Empty() {
-		err = fb.loadModulesPipelines(b)
+		esConfig := b.Config.Output["elasticsearch"]
+		esEnabled := esConfig != nil && esConfig.Enabled()
+		if config.LocalPipelines.RunLocally(esEnabled) {
+			pipelines, err = fb.loadLocalPipelines()
+		} else {
+			err = fb.loadModulesPipelines(b)
+		}
 		if err != nil {
 			return err
 		}
@@ -152,7 +189,11 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	finishedLogger := newFinishedLogger(wgEvents)
 
 	// Setup registrar to persist state
-	registrar, err := registrar.New(config.RegistryFile, finishedLogger)
+	storeConfig := registrar.StoreConfig{
+		Fsync:          config.RegistryFsync,
+		CheckpointSize: config.RegistryCheckpointSize,
+	}
+	registrar, err := registrar.New(config.RegistryFile, config.RegistryFlush, storeConfig, finishedLogger)
 	if err != nil {
 		logp.Err("Could not init registrar: %v", err)
 		return err
@@ -165,7 +206,7 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 	publisherChan := newPublisherChannel()
 
 	// Publishes event to output
-	publisher := publisher.New(config.PublishAsync, publisherChan.ch, registrarChannel, b.Publisher)
+	publisher := publisher.New(config.PublishAsync, publisherChan.ch, registrarChannel, b.Publisher, pipelines)
 
 	// Init and Start spooler: Harvesters dump events into the spooler.
 	spooler, err := spooler.New(config, publisherChan)
@@ -216,7 +257,7 @@ func (fb *Filebeat) Run(b *beat.Beat) error {
 		spooler.Stop()
 	}()
 
-	err = crawler.Start(registrar, config.ProspectorReload)
+	err = crawler.Start(registrar, config.ProspectorReload, config.Autodiscover)
 	if err != nil {
 		crawler.Stop()
 		return err
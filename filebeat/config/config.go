@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/elastic/beats/filebeat/ingest"
+	"github.com/elastic/beats/libbeat/autodiscover"
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
@@ -18,35 +20,60 @@ const (
 )
 
 type Config struct {
-	Prospectors      []*common.Config `config:"prospectors"`
-	SpoolSize        uint64           `config:"spool_size" validate:"min=1"`
-	PublishAsync     bool             `config:"publish_async"`
-	IdleTimeout      time.Duration    `config:"idle_timeout" validate:"nonzero,min=0s"`
-	RegistryFile     string           `config:"registry_file"`
-	ConfigDir        string           `config:"config_dir"`
-	ShutdownTimeout  time.Duration    `config:"shutdown_timeout"`
-	Modules          []*common.Config `config:"modules"`
-	ProspectorReload *common.Config   `config:"config.prospectors"`
+	Prospectors            []*common.Config     `config:"prospectors"`
+	SpoolSize              uint64               `config:"spool_size" validate:"min=1"`
+	PublishAsync           bool                 `config:"publish_async"`
+	IdleTimeout            time.Duration        `config:"idle_timeout" validate:"nonzero,min=0s"`
+	RegistryFile           string               `config:"registry_file"`
+	RegistryFlush          time.Duration        `config:"registry_flush" validate:"min=0"`
+	RegistryFsync          string               `config:"registry_fsync"`
+	RegistryCheckpointSize int64                `config:"registry_checkpoint_size" validate:"min=1"`
+	ConfigDir              string               `config:"config_dir"`
+	ShutdownTimeout        time.Duration        `config:"shutdown_timeout"`
+	Modules                []*common.Config     `config:"modules"`
+	ProspectorReload       *common.Config       `config:"config.prospectors"`
+	Autodiscover           *autodiscover.Config `config:"autodiscover"`
+	LocalPipelines         ingest.Config        `config:"local_pipelines"`
 }
 
 var (
 	DefaultConfig = Config{
-		RegistryFile:    "registry",
-		SpoolSize:       2048,
-		IdleTimeout:     5 * time.Second,
-		ShutdownTimeout: 0,
+		RegistryFile:           "registry",
+		RegistryFlush:          0,
+		RegistryFsync:          "always",
+		RegistryCheckpointSize: 10 * 1024 * 1024,
+		SpoolSize:              2048,
+		IdleTimeout:            5 * time.Second,
+		ShutdownTimeout:        0,
+		LocalPipelines:         ingest.DefaultConfig,
 	}
 )
 
 const (
-	LogInputType   = "log"
-	StdinInputType = "stdin"
+	LogInputType    = "log"
+	StdinInputType  = "stdin"
+	UDPInputType    = "udp"
+	TCPInputType    = "tcp"
+	SyslogInputType = "syslog"
+	DockerInputType = "docker"
 )
 
 // List of valid input types
 var ValidInputType = map[string]struct{}{
-	StdinInputType: {},
-	LogInputType:   {},
+	StdinInputType:  {},
+	LogInputType:    {},
+	UDPInputType:    {},
+	TCPInputType:    {},
+	SyslogInputType: {},
+	DockerInputType: {},
+}
+
+// List of input types which do not persist their state in the registry
+var StatelessInputType = map[string]struct{}{
+	StdinInputType:  {},
+	UDPInputType:    {},
+	TCPInputType:    {},
+	SyslogInputType: {},
 }
 
 // getConfigFiles returns list of config files.
@@ -7,6 +7,7 @@ import (
 	"github.com/elastic/beats/filebeat/input/file"
 	"github.com/elastic/beats/filebeat/prospector"
 	"github.com/elastic/beats/filebeat/registrar"
+	"github.com/elastic/beats/libbeat/autodiscover"
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
@@ -18,6 +19,7 @@ type Crawler struct {
 	out               prospector.Outlet
 	wg                sync.WaitGroup
 	reloader          *cfgfile.Reloader
+	autodiscover      *autodiscover.Autodiscover
 	once              bool
 	beatDone          chan struct{}
 }
@@ -33,7 +35,11 @@ func New(out prospector.Outlet, prospectorConfigs []*common.Config, beatDone cha
 	}, nil
 }
 
-func (c *Crawler) Start(r *registrar.Registrar, reloaderConfig *common.Config) error {
+func (c *Crawler) Start(
+	r *registrar.Registrar,
+	reloaderConfig *common.Config,
+	autodiscoverConfig *autodiscover.Config,
+) error {
 
 	logp.Info("Loading Prospectors: %v", len(c.prospectorConfigs))
 
@@ -55,6 +61,21 @@ func (c *Crawler) Start(r *registrar.Registrar, reloaderConfig *common.Config) e
 		}()
 	}
 
+	if autodiscoverConfig != nil {
+		logp.Warn("BETA feature autodiscover is enabled.")
+
+		var err error
+		factory := prospector.NewFactory(c.out, r, c.beatDone)
+		c.autodiscover, err = autodiscover.NewAutodiscover(factory, autodiscoverConfig)
+		if err != nil {
+			return err
+		}
+		if err := c.autodiscover.Start(); err != nil {
+			c.autodiscover = nil
+			return err
+		}
+	}
+
 	logp.Info("Loading and starting Prospectors completed. Enabled prospectors: %v", len(c.prospectors))
 
 	return nil
@@ -107,6 +128,10 @@ func (c *Crawler) Stop() {
 		asyncWaitStop(c.reloader.Stop)
 	}
 
+	if c.autodiscover != nil {
+		asyncWaitStop(c.autodiscover.Stop)
+	}
+
 	c.WaitForCompletion()
 
 	logp.Info("Crawler stopped")
@@ -13,6 +13,7 @@ import (
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
 	mlimporter "github.com/elastic/beats/libbeat/ml-importer"
+	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/paths"
 )
 
@@ -282,6 +283,30 @@ func (reg *ModuleRegistry) LoadPipelines(esClient PipelineLoader) error {
 	return nil
 }
 
+// LocalPipelineLoader is implemented by runners executing the pipelines in the
+// beat instead of Elasticsearch.
+type LocalPipelineLoader interface {
+	AddPipeline(pipelineID string, content map[string]interface{}) error
+}
+
+// LoadLocalPipelines adds the pipelines of each configured fileset to the local
+// runner.
+func (reg *ModuleRegistry) LoadLocalPipelines(runner LocalPipelineLoader) error {
+	for module, filesets := range reg.registry {
+		for name, fileset := range filesets {
+			pipelineID, content, err := fileset.GetPipeline()
+			if err != nil {
+				return fmt.Errorf("Error getting pipeline for fileset %s/%s: %v", module, name, err)
+			}
+			err = runner.AddPipeline(pipelineID, content)
+			if err != nil {
+				return fmt.Errorf("Error loading pipeline for fileset %s/%s: %v", module, name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // InfoString returns the enabled modules and filesets in a single string, ready to
 // be shown to the user
 func (reg *ModuleRegistry) InfoString() string {
@@ -302,6 +327,16 @@ func (reg *ModuleRegistry) InfoString() string {
 	return result
 }
 
+// RegisterState adds the enabled modules and the number of their filesets to
+// the given state registry, replacing the previously registered state.
+func (reg *ModuleRegistry) RegisterState(r *monitoring.Registry) {
+	r.Remove("module")
+	modules := r.NewRegistry("module")
+	for module, filesets := range reg.registry {
+		monitoring.NewInt(modules.NewRegistry(module), "filesets").Set(int64(len(filesets)))
+	}
+}
+
 // checkAvailableProcessors calls the /_nodes/ingest API and verifies that all processors listed
 // in the requiredProcessors list are available in Elasticsearch. Returns nil if all required
 // processors are available.
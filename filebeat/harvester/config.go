@@ -29,6 +29,9 @@ var (
 		CloseEOF:        false,
 		CloseTimeout:    0,
 		ForceCloseFiles: false,
+		Containers: containersConfig{
+			Stream: "all",
+		},
 	}
 )
 
@@ -53,11 +56,16 @@ type harvesterConfig struct {
 	MaxBytes             int                     `config:"max_bytes" validate:"min=0,nonzero"`
 	Multiline            *reader.MultilineConfig `config:"multiline"`
 	JSON                 *reader.JSONConfig      `config:"json"`
+	Containers           containersConfig        `config:"containers"`
 	Pipeline             string                  `config:"pipeline"`
 	Module               string                  `config:"_module_name"`  // hidden option to set the module name
 	Fileset              string                  `config:"_fileset_name"` // hidden option to set the fileset name
 }
 
+type containersConfig struct {
+	Stream string `config:"stream"`
+}
+
 var onceCheck sync.Once
 
 func (config *harvesterConfig) Validate() error {
@@ -80,6 +88,14 @@ func (config *harvesterConfig) Validate() error {
 		return fmt.Errorf("Invalid input type: %v", config.InputType)
 	}
 
+	if config.InputType == cfg.DockerInputType {
+		switch config.Containers.Stream {
+		case "all", "stdout", "stderr":
+		default:
+			return fmt.Errorf("Invalid containers.stream: %v, must be one of all, stdout or stderr", config.Containers.Stream)
+		}
+	}
+
 	if config.JSON != nil && len(config.JSON.MessageKey) == 0 &&
 		config.Multiline != nil {
 		return fmt.Errorf("When using the JSON decoder and multiline together, you need to specify a message_key value")
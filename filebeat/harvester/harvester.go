@@ -87,7 +87,7 @@ func (h *Harvester) open() error {
 	switch h.config.InputType {
 	case config.StdinInputType:
 		return h.openStdin()
-	case config.LogInputType:
+	case config.LogInputType, config.DockerInputType:
 		return h.openFile()
 	default:
 		return fmt.Errorf("Invalid input type")
@@ -111,7 +111,13 @@ func (h *Harvester) Harvest(r reader.Reader) {
 			case ErrClosed:
 				logp.Info("Reader was closed: %s. Closing.", h.state.Source)
 			case io.EOF:
-				logp.Info("End of file reached: %s. Closing because close_eof is enabled.", h.state.Source)
+				if _, ok := h.file.(*source.GzipFile); ok {
+					// Compressed files are only read once
+					logp.Info("End of compressed file reached: %s. Closing.", h.state.Source)
+					h.state.EOF = true
+				} else {
+					logp.Info("End of file reached: %s. Closing because close_eof is enabled.", h.state.Source)
+				}
 			case ErrInactive:
 				logp.Info("File is inactive: %s. Closing because close_inactive of %v reached.", h.state.Source, h.config.CloseInactive)
 			default:
@@ -226,34 +232,48 @@ func (h *Harvester) openFile() error {
 	harvesterOpenFiles.Add(1)
 
 	// Makes sure file handler is also closed on errors
-	err = h.validateFile(f)
+	fs, err := h.validateFile(f)
 	if err != nil {
 		f.Close()
 		harvesterOpenFiles.Add(-1)
 		return err
 	}
 
-	h.file = source.File{f}
+	h.file = fs
 	return nil
 }
 
-func (h *Harvester) validateFile(f *os.File) error {
+// validateFile checks the opened file and positions it at the offset of the state.
+// Compressed files are wrapped to read the decompressed content.
+func (h *Harvester) validateFile(f *os.File) (source.FileSource, error) {
 
 	info, err := f.Stat()
 	if err != nil {
-		return fmt.Errorf("Failed getting stats for file %s: %s", h.state.Source, err)
+		return nil, fmt.Errorf("Failed getting stats for file %s: %s", h.state.Source, err)
 	}
 
 	if !info.Mode().IsRegular() {
-		return fmt.Errorf("Tried to open non regular file: %q %s", info.Mode(), info.Name())
+		return nil, fmt.Errorf("Tried to open non regular file: %q %s", info.Mode(), info.Name())
 	}
 
 	// Compares the stat of the opened file to the state given by the prospector. Abort if not match.
 	if !os.SameFile(h.state.Fileinfo, info) {
-		return errors.New("File info is not identical with opened file. Aborting harvesting and retrying file later again.")
+		return nil, errors.New("File info is not identical with opened file. Aborting harvesting and retrying file later again.")
 	}
 
-	h.encoding, err = h.encodingFactory(f)
+	var fs interface {
+		source.FileSource
+		io.Seeker
+	} = source.File{f}
+
+	if source.IsGzipFile(h.state.Source) {
+		fs, err = source.NewGzipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("Failed opening compressed file %s: %s", h.state.Source, err)
+		}
+	}
+
+	h.encoding, err = h.encodingFactory(fs)
 	if err != nil {
 
 		if err == transform.ErrShortSrc {
@@ -261,22 +281,22 @@ func (h *Harvester) validateFile(f *os.File) error {
 		} else {
 			logp.Err("Initialising encoding for '%v' failed: %v", f, err)
 		}
-		return err
+		return nil, err
 	}
 
 	// get file offset. Only update offset if no error
-	offset, err := h.initFileOffset(f)
+	offset, err := h.initFileOffset(fs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	logp.Debug("harvester", "Setting offset for file: %s. Offset: %d ", h.state.Source, offset)
 	h.state.Offset = offset
 
-	return nil
+	return fs, nil
 }
 
-func (h *Harvester) initFileOffset(file *os.File) (int64, error) {
+func (h *Harvester) initFileOffset(file io.Seeker) (int64, error) {
 
 	// continue from last known offset
 	if h.state.Offset > 0 {
@@ -334,12 +354,12 @@ func (h *Harvester) close() {
 //
 // It creates a chain of readers which looks as following:
 //
-//   limit -> (multiline -> timeout) -> strip_newline -> json -> encode -> line -> log_file
+//   limit -> (multiline -> timeout) -> strip_newline -> json -> docker_json -> encode -> line -> log_file
 //
 // Each reader on the left, contains the reader on the right and calls `Next()` to fetch more data.
 // At the base of all readers the the log_file reader. That means in the data is flowing in the opposite direction:
 //
-//   log_file -> line -> encode -> json -> strip_newline -> (timeout -> multiline) -> limit
+//   log_file -> line -> encode -> docker_json -> json -> strip_newline -> (timeout -> multiline) -> limit
 //
 // log_file implements io.Reader interface and encode reader is an adapter for io.Reader to
 // reader.Reader also handling file encodings. All other readers implement reader.Reader
@@ -361,6 +381,10 @@ func (h *Harvester) newLogFileReader() (reader.Reader, error) {
 		return nil, err
 	}
 
+	if h.config.InputType == config.DockerInputType {
+		r = reader.NewDockerJSON(r, h.config.Containers.Stream, h.config.MaxBytes)
+	}
+
 	if h.config.JSON != nil {
 		r = reader.NewJSON(r, h.config.JSON)
 	}
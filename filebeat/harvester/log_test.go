@@ -3,7 +3,9 @@
 package harvester
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -14,6 +16,7 @@ import (
 	"github.com/elastic/beats/filebeat/harvester/encoding"
 	"github.com/elastic/beats/filebeat/harvester/reader"
 	"github.com/elastic/beats/filebeat/harvester/source"
+	"github.com/elastic/beats/filebeat/input/file"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/stretchr/testify/assert"
 )
@@ -102,6 +105,78 @@ func TestReadLine(t *testing.T) {
 	assert.Equal(t, err, ErrInactive)
 }
 
+func TestReadGzipFile(t *testing.T) {
+	absPath, err := filepath.Abs("../tests/files/logs/")
+	if err != nil {
+		t.Fatalf("Error creating the absolute path: %s", absPath)
+	}
+
+	// All files starting with tmp are ignored
+	logFile := absPath + "/tmp" + strconv.Itoa(rand.Int()) + ".log.gz"
+	defer os.Remove(logFile)
+
+	firstLineString := "9Characte\n"
+	secondLineString := "This is line 2\n"
+
+	f, err := os.Create(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := gzip.NewWriter(f)
+	w.Write([]byte(firstLineString + secondLineString))
+	w.Close()
+	f.Close()
+
+	readFile, err := os.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readFile.Close()
+
+	info, err := readFile.Stat()
+	assert.NoError(t, err)
+
+	h := Harvester{
+		config: harvesterConfig{
+			CloseInactive: 500 * time.Millisecond,
+			Backoff:       100 * time.Millisecond,
+			MaxBackoff:    1 * time.Second,
+			BackoffFactor: 2,
+			BufferSize:    100,
+			MaxBytes:      1000,
+		},
+		state: file.State{
+			Source:   logFile,
+			Fileinfo: info,
+			// Continue after the first line, the offset is based on the decompressed data
+			Offset: int64(len(firstLineString)),
+		},
+	}
+
+	var ok bool
+	h.encodingFactory, ok = encoding.FindEncoding(h.config.Encoding)
+	assert.True(t, ok)
+
+	h.file, err = h.validateFile(readFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, h.file.Continuable())
+	assert.Equal(t, int64(len(firstLineString)), h.state.Offset)
+
+	r, err := h.newLogFileReader()
+	assert.NoError(t, err)
+
+	_, text, bytesread, _, err := readLine(r)
+	assert.NoError(t, err)
+	assert.Equal(t, secondLineString[0:len(secondLineString)-1], text)
+	assert.Equal(t, len(secondLineString), bytesread)
+
+	// Compressed files are not tailed
+	_, _, _, _, err = readLine(r)
+	assert.Equal(t, io.EOF, err)
+}
+
 func TestExcludeLine(t *testing.T) {
 	regexp, err := InitMatchers("^DBG")
 	assert.Nil(t, err)
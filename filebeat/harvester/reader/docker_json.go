@@ -0,0 +1,161 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// DockerJSON reader decodes the container log files written by the Docker
+// json-file logging driver and by CRI runtimes. The format is detected for
+// every line:
+//
+//   json-file: {"log":"message\n","stream":"stdout","time":"2017-11-09T13:27:36.277747246Z"}
+//   CRI:       2017-11-09T13:27:36.277747246Z stdout F message
+//
+// Long lines are split into partial lines by the runtime. These are joined
+// back together, so every message contains one complete line.
+type DockerJSON struct {
+	reader   Reader
+	stream   string
+	maxBytes int
+}
+
+type dockerLog struct {
+	Log    string    `json:"log"`
+	Stream string    `json:"stream"`
+	Time   time.Time `json:"time"`
+}
+
+// criPartialTag marks a CRI line which is continued in the next line
+const criPartialTag = "P"
+
+// NewDockerJSON creates a new reader decoding container logs. Only lines of
+// the given stream are returned, unless the stream is "all". The content of
+// joined partial lines is limited to maxBytes.
+func NewDockerJSON(r Reader, stream string, maxBytes int) *DockerJSON {
+	return &DockerJSON{
+		reader:   r,
+		stream:   stream,
+		maxBytes: maxBytes,
+	}
+}
+
+// Next returns the next complete line of the configured stream. The bytes of
+// skipped lines are added to the returned message to keep the offset correct.
+func (r *DockerJSON) Next() (Message, error) {
+	var skippedBytes int
+
+	for {
+		message, err := r.next()
+		if err != nil {
+			message.Bytes += skippedBytes
+			return message, err
+		}
+
+		// Empty lines are skipped like in log files, as the stream field
+		// would otherwise mark them as non empty
+		stream, _ := message.Fields["stream"].(string)
+		if len(message.Content) == 0 || (r.stream != "all" && stream != "" && stream != r.stream) {
+			skippedBytes += message.Bytes
+			continue
+		}
+
+		message.Bytes += skippedBytes
+		return message, nil
+	}
+}
+
+// next returns the next line with all partial lines joined
+func (r *DockerJSON) next() (Message, error) {
+	message, err := r.reader.Next()
+	if err != nil {
+		return message, err
+	}
+
+	partial := r.decode(&message)
+	for partial {
+		next, err := r.reader.Next()
+		if err != nil {
+			// Keep the bytes of the lines read so far, to not lose the offset
+			message.Bytes += next.Bytes
+			return message, err
+		}
+
+		partial = r.decode(&next)
+		message.Bytes += next.Bytes
+		if len(message.Content)+len(next.Content) <= r.maxBytes {
+			message.Content = append(message.Content, next.Content...)
+		}
+	}
+
+	return message, nil
+}
+
+// decode replaces the content of the message by the logged line without the
+// trailing newline and sets the timestamp and the stream. It returns true in
+// case the line is partial.
+func (r *DockerJSON) decode(message *Message) bool {
+	line := bytes.TrimRight(message.Content, "\r\n")
+
+	if len(line) > 0 && line[0] == '{' {
+		var log dockerLog
+		if err := json.Unmarshal(line, &log); err != nil {
+			logp.Err("Error decoding docker JSON log line: %v", err)
+			message.Content = line
+			return false
+		}
+
+		message.Ts = log.Time
+		message.Content = []byte(log.Log)
+		message.AddFields(common.MapStr{"stream": log.Stream})
+
+		// Complete lines are terminated by a newline
+		if len(log.Log) > 0 && log.Log[len(log.Log)-1] == '\n' {
+			message.Content = message.Content[:len(message.Content)-1]
+			return false
+		}
+		return len(log.Log) > 0
+	}
+
+	return r.decodeCRI(message, line)
+}
+
+func (r *DockerJSON) decodeCRI(message *Message, line []byte) bool {
+	// TIMESTAMP STREAM [TAG] CONTENT
+	parts := bytes.SplitN(line, []byte{' '}, 4)
+	if len(parts) < 3 {
+		logp.Err("Error decoding CRI log line: %s", line)
+		message.Content = line
+		return false
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, string(parts[0]))
+	if err != nil {
+		logp.Err("Error parsing timestamp of CRI log line: %v", err)
+		message.Content = line
+		return false
+	}
+
+	message.Ts = ts
+	message.AddFields(common.MapStr{"stream": string(parts[1])})
+
+	// Older CRI versions do not write the tag, which is either P (partial) or F (full),
+	// optionally followed by further flags separated by a colon
+	tag := parts[2]
+	if bytes.Equal(tag, []byte(criPartialTag)) || bytes.Equal(tag, []byte("F")) || bytes.IndexByte(tag, ':') > 0 {
+		if len(parts) == 4 {
+			// Copy the content, as partial lines are appended to it
+			message.Content = append([]byte(nil), parts[3]...)
+		} else {
+			message.Content = nil
+		}
+		return bytes.Equal(tag, []byte(criPartialTag)) || bytes.HasPrefix(tag, []byte(criPartialTag+":"))
+	}
+
+	message.Content = bytes.Join(parts[2:], []byte{' '})
+	return false
+}
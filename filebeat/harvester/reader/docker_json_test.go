@@ -0,0 +1,161 @@
+// +build !integration
+
+package reader
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// linesReader returns the given lines as messages
+type linesReader struct {
+	lines []string
+}
+
+func (r *linesReader) Next() (Message, error) {
+	if len(r.lines) == 0 {
+		return Message{}, io.EOF
+	}
+
+	line := r.lines[0]
+	r.lines = r.lines[1:]
+	return Message{
+		Ts:      time.Now(),
+		Content: []byte(line),
+		Bytes:   len(line),
+	}, nil
+}
+
+func TestDockerJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		stream   string
+		input    []string
+		expected []Message
+	}{
+		{
+			name:   "json-file line",
+			stream: "all",
+			input:  []string{`{"log":"1:M 09 Nov 13:27:36.276 # User requested shutdown...\n","stream":"stdout","time":"2017-11-09T13:27:36.277747246Z"}` + "\n"},
+			expected: []Message{
+				{
+					Ts:      time.Date(2017, 11, 9, 13, 27, 36, 277747246, time.UTC),
+					Content: []byte("1:M 09 Nov 13:27:36.276 # User requested shutdown..."),
+					Fields:  common.MapStr{"stream": "stdout"},
+				},
+			},
+		},
+		{
+			name:   "json-file partial lines",
+			stream: "all",
+			input: []string{
+				`{"log":"first ","stream":"stdout","time":"2017-11-09T13:27:36Z"}` + "\n",
+				`{"log":"second ","stream":"stdout","time":"2017-11-09T13:27:37Z"}` + "\n",
+				`{"log":"third\n","stream":"stdout","time":"2017-11-09T13:27:38Z"}` + "\n",
+			},
+			expected: []Message{
+				{
+					Ts:      time.Date(2017, 11, 9, 13, 27, 36, 0, time.UTC),
+					Content: []byte("first second third"),
+					Fields:  common.MapStr{"stream": "stdout"},
+				},
+			},
+		},
+		{
+			name:   "CRI lines",
+			stream: "all",
+			input: []string{
+				"2017-09-12T22:32:21.212861448Z stdout F 2017-09-12 22:32:21.212 [INFO][88] table.go 710: Invalidating dataplane cache\n",
+				"2017-09-12T22:32:22Z stderr P partial \n",
+				"2017-09-12T22:32:23Z stderr F line\n",
+				"2017-09-12T22:32:24Z stdout without tag\n",
+			},
+			expected: []Message{
+				{
+					Ts:      time.Date(2017, 9, 12, 22, 32, 21, 212861448, time.UTC),
+					Content: []byte("2017-09-12 22:32:21.212 [INFO][88] table.go 710: Invalidating dataplane cache"),
+					Fields:  common.MapStr{"stream": "stdout"},
+				},
+				{
+					Ts:      time.Date(2017, 9, 12, 22, 32, 22, 0, time.UTC),
+					Content: []byte("partial line"),
+					Fields:  common.MapStr{"stream": "stderr"},
+				},
+				{
+					Ts:      time.Date(2017, 9, 12, 22, 32, 24, 0, time.UTC),
+					Content: []byte("without tag"),
+					Fields:  common.MapStr{"stream": "stdout"},
+				},
+			},
+		},
+		{
+			name:   "stream filter",
+			stream: "stderr",
+			input: []string{
+				`{"log":"out\n","stream":"stdout","time":"2017-11-09T13:27:36Z"}` + "\n",
+				`{"log":"\n","stream":"stderr","time":"2017-11-09T13:27:37Z"}` + "\n",
+				`{"log":"err\n","stream":"stderr","time":"2017-11-09T13:27:38Z"}` + "\n",
+			},
+			expected: []Message{
+				{
+					Ts:      time.Date(2017, 11, 9, 13, 27, 38, 0, time.UTC),
+					Content: []byte("err"),
+					Fields:  common.MapStr{"stream": "stderr"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		r := NewDockerJSON(&linesReader{lines: test.input}, test.stream, 10*1024)
+
+		bytes := 0
+		for _, expected := range test.expected {
+			message, err := r.Next()
+			if assert.NoError(t, err, test.name) {
+				assert.Equal(t, expected.Ts, message.Ts, test.name)
+				assert.Equal(t, string(expected.Content), string(message.Content), test.name)
+				assert.Equal(t, expected.Fields, message.Fields, test.name)
+				bytes += message.Bytes
+			}
+		}
+
+		_, err := r.Next()
+		assert.Equal(t, io.EOF, err, test.name)
+
+		// All bytes read must be reported to keep the offset correct
+		expectedBytes := 0
+		for _, line := range test.input {
+			expectedBytes += len(line)
+		}
+		assert.Equal(t, expectedBytes, bytes, test.name)
+	}
+}
+
+func TestDockerJSONMaxBytes(t *testing.T) {
+	input := []string{
+		`{"log":"0123456789","stream":"stdout","time":"2017-11-09T13:27:36Z"}` + "\n",
+		`{"log":"0123456789","stream":"stdout","time":"2017-11-09T13:27:36Z"}` + "\n",
+		`{"log":"end\n","stream":"stdout","time":"2017-11-09T13:27:36Z"}` + "\n",
+	}
+
+	r := NewDockerJSON(&linesReader{lines: input}, "all", 15)
+	message, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789end", string(message.Content))
+	assert.Equal(t, len(input[0])+len(input[1])+len(input[2]), message.Bytes)
+}
+
+func TestDockerJSONInvalidLine(t *testing.T) {
+	r := NewDockerJSON(&linesReader{lines: []string{"{invalid\n"}}, "all", 1024)
+
+	message, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "{invalid", string(message.Content))
+	assert.Equal(t, 9, message.Bytes)
+}
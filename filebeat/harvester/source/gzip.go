@@ -0,0 +1,81 @@
+package source
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// GzipFile decompresses a gzip compressed file. The offset is counted in
+// decompressed bytes. As compressed files are not expected to change,
+// reading stops at the end of the file.
+type GzipFile struct {
+	file   *os.File
+	reader *gzip.Reader
+	offset int64
+}
+
+// IsGzipFile returns true if the file at path is expected to be gzip compressed
+func IsGzipFile(path string) bool {
+	return strings.HasSuffix(path, ".gz")
+}
+
+// NewGzipFile creates a new GzipFile reading from the beginning of the given file
+func NewGzipFile(f *os.File) (*GzipFile, error) {
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GzipFile{
+		file:   f,
+		reader: reader,
+	}, nil
+}
+
+func (g *GzipFile) Read(b []byte) (int, error) {
+	n, err := g.reader.Read(b)
+	g.offset += int64(n)
+
+	// Only report the end of the file once all data was returned, as readers
+	// further up drop data returned together with an error
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek moves to the given offset in the decompressed data. Only seeking forward
+// is supported, as all data up to the offset has to be decompressed.
+func (g *GzipFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case os.SEEK_SET:
+	case os.SEEK_CUR:
+		offset += g.offset
+	default:
+		return g.offset, fmt.Errorf("unsupported seek whence for compressed file: %d", whence)
+	}
+
+	if offset < g.offset {
+		return g.offset, fmt.Errorf("cannot seek backwards in compressed file from %d to %d", g.offset, offset)
+	}
+
+	n, err := io.CopyN(ioutil.Discard, g.reader, offset-g.offset)
+	g.offset += n
+	if err == io.EOF {
+		return g.offset, fmt.Errorf("offset %d is behind the end of the compressed file", offset)
+	}
+	return g.offset, err
+}
+
+func (g *GzipFile) Close() error {
+	g.reader.Close()
+	return g.file.Close()
+}
+
+func (g *GzipFile) Name() string               { return g.file.Name() }
+func (g *GzipFile) Stat() (os.FileInfo, error) { return g.file.Stat() }
+func (g *GzipFile) Continuable() bool          { return false }
@@ -0,0 +1,29 @@
+package syslog
+
+import (
+	"errors"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var defaultConfig = config{
+	DocumentType: "log",
+}
+
+type config struct {
+	common.EventMetadata `config:",inline"` // Fields and tags to add to events.
+	UDP                  *common.Config     `config:"protocol.udp"`
+	TCP                  *common.Config     `config:"protocol.tcp"`
+	DocumentType         string             `config:"document_type"`
+	InputType            string             `config:"input_type"`
+	Pipeline             string             `config:"pipeline"`
+	Module               string             `config:"_module_name"`  // hidden option to set the module name
+	Fileset              string             `config:"_fileset_name"` // hidden option to set the fileset name
+}
+
+func (c *config) Validate() error {
+	if c.UDP == nil && c.TCP == nil {
+		return errors.New("protocol.udp or protocol.tcp must be configured for the syslog input")
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package syslog
+
+import (
+	"bufio"
+	"strconv"
+)
+
+// maxLengthDigits limits the length prefix of octet counted messages
+const maxLengthDigits = 10
+
+// splitFunc splits a TCP stream into syslog messages. Both framing methods of
+// RFC 6587 are supported and detected for every message. With octet counting,
+// the message is prefixed by its length and a space, e.g. "11 <13>Hi there".
+// With non-transparent framing, messages are terminated by a newline. As
+// syslog messages start with "<", a leading digit indicates octet counting.
+func splitFunc(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if data[0] >= '1' && data[0] <= '9' {
+		i := 1
+		for i < len(data) && i <= maxLengthDigits && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+
+		switch {
+		case i == len(data) && !atEOF:
+			// Request more data to read the complete length
+			return 0, nil, nil
+		case i < len(data) && i <= maxLengthDigits && data[i] == ' ':
+			length, err := strconv.Atoi(string(data[:i]))
+			if err != nil {
+				return 0, nil, err
+			}
+
+			end := i + 1 + length
+			if end <= len(data) {
+				return end, data[i+1 : end], nil
+			}
+			if atEOF {
+				// Connection was closed, return the truncated message
+				return len(data), data[i+1:], nil
+			}
+			return 0, nil, nil
+		}
+	}
+
+	// Non-transparent framing
+	return bufio.ScanLines(data, atEOF)
+}
@@ -0,0 +1,65 @@
+// +build !integration
+
+package syslog
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFunc(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			input:    "<13>first\n<13>second\r\n",
+			expected: []string{"<13>first", "<13>second"},
+		},
+		{
+			input:    "9 <13>first10 <13>second",
+			expected: []string{"<13>first", "<13>second"},
+		},
+		{
+			// Octet counting allows newlines in messages
+			input:    "14 <13>multi\nline\n<13>framed\n",
+			expected: []string{"<13>multi\nline", "", "<13>framed"},
+		},
+		{
+			// Digits which are not followed by a space are no length prefix
+			input:    "123abc\n",
+			expected: []string{"123abc"},
+		},
+		{
+			// Truncated message at the end of the stream
+			input:    "20 <13>truncated",
+			expected: []string{"<13>truncated"},
+		},
+	}
+
+	for _, test := range tests {
+		scanner := bufio.NewScanner(strings.NewReader(test.input))
+		scanner.Split(splitFunc)
+
+		var messages []string
+		for scanner.Scan() {
+			messages = append(messages, scanner.Text())
+		}
+
+		assert.NoError(t, scanner.Err(), test.input)
+		assert.Equal(t, test.expected, messages, test.input)
+	}
+}
+
+func TestSplitFuncPartialData(t *testing.T) {
+	// Length prefix and message are incomplete, more data is requested
+	for _, input := range []string{"1", "12", "20 <13>incomplete"} {
+		advance, token, err := splitFunc([]byte(input), false)
+		assert.NoError(t, err, input)
+		assert.Equal(t, 0, advance, input)
+		assert.Nil(t, token, input)
+	}
+}
@@ -0,0 +1,142 @@
+// Package syslog contains the harvester used by the syslog prospector. It
+// receives syslog messages over UDP and TCP and parses RFC3164 and RFC5424
+// messages into the syslog fields of the event.
+//
+// Messages which cannot be parsed are not dropped. They are published with the
+// raw message and tagged with _syslog_parse_failure.
+package syslog
+
+import (
+	"net"
+	"time"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/harvester/tcp"
+	"github.com/elastic/beats/filebeat/harvester/udp"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const parseFailureTag = "_syslog_parse_failure"
+
+type Harvester struct {
+	config   config
+	outlet   *channel.Outlet
+	udp      *udp.Server
+	tcp      *tcp.Server
+	location *time.Location
+}
+
+// NewHarvester creates a new syslog harvester from the prospector config. Events
+// are forwarded to the given outlet.
+func NewHarvester(cfg *common.Config, outlet *channel.Outlet) (*Harvester, error) {
+	h := &Harvester{
+		config:   defaultConfig,
+		outlet:   outlet,
+		location: time.Local,
+	}
+
+	if err := cfg.Unpack(&h.config); err != nil {
+		return nil, err
+	}
+
+	if h.config.UDP != nil {
+		config := udp.DefaultServerConfig
+		if err := h.config.UDP.Unpack(&config); err != nil {
+			return nil, err
+		}
+		h.udp = udp.NewServer(&config, h.onUDPMessage)
+	}
+
+	if h.config.TCP != nil {
+		config := tcp.DefaultServerConfig
+		if err := h.config.TCP.Unpack(&config); err != nil {
+			return nil, err
+		}
+
+		var err error
+		h.tcp, err = tcp.NewServer(&config, splitFunc, h.onTCPMessage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Start starts listening on all configured protocols
+func (h *Harvester) Start() error {
+	if h.udp != nil {
+		if err := h.udp.Start(); err != nil {
+			return err
+		}
+	}
+
+	if h.tcp != nil {
+		if err := h.tcp.Start(); err != nil {
+			if h.udp != nil {
+				h.udp.Stop()
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops all servers and waits until the harvester is stopped
+func (h *Harvester) Stop() {
+	if h.udp != nil {
+		h.udp.Stop()
+	}
+	if h.tcp != nil {
+		h.tcp.Stop()
+	}
+}
+
+func (h *Harvester) onUDPMessage(data []byte, addr net.Addr) {
+	h.outlet.OnEvent(h.createEvent(data, addr))
+}
+
+func (h *Harvester) onTCPMessage(data []byte, metadata tcp.Metadata) {
+	h.outlet.OnEvent(h.createEvent(data, metadata.RemoteAddr))
+}
+
+func (h *Harvester) createEvent(data []byte, addr net.Addr) *input.Event {
+	now := time.Now()
+
+	event := input.NewEvent(file.State{Source: addr.String()})
+	event.ReadTime = now
+	event.Bytes = len(data)
+	event.EventMetadata = h.config.EventMetadata
+	event.InputType = h.config.InputType
+	event.DocumentType = h.config.DocumentType
+	event.Pipeline = h.config.Pipeline
+	event.Module = h.config.Module
+	event.Fileset = h.config.Fileset
+
+	m, err := parse(data, now, h.location)
+	if err != nil {
+		logp.Debug("syslog", "Failed to parse message from %s: %v", addr, err)
+
+		text := string(data)
+		event.Text = &text
+
+		// Copy the tags to not modify the tags shared by all events
+		tags := make([]string, 0, len(event.Tags)+1)
+		event.Tags = append(append(tags, event.Tags...), parseFailureTag)
+		return event
+	}
+
+	if !m.timestamp.IsZero() {
+		event.ReadTime = m.timestamp
+	}
+	event.Text = &m.message
+	event.Data = common.MapStr{
+		"syslog": m.fields(),
+	}
+
+	return event
+}
@@ -0,0 +1,116 @@
+// +build !integration
+
+package syslog
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestHarvester(t *testing.T, events chan *input.Event) *Harvester {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"input_type":        "syslog",
+		"protocol.udp.host": "127.0.0.1:0",
+		"protocol.tcp.host": "127.0.0.1:0",
+		"tags":              []string{"network"},
+	})
+	require.NoError(t, err)
+
+	h, err := NewHarvester(cfg, channel.NewOutlet(make(chan struct{}), events, &sync.WaitGroup{}))
+	require.NoError(t, err)
+	h.location = time.UTC
+	require.NoError(t, h.Start())
+
+	return h
+}
+
+func receive(t *testing.T, events chan *input.Event) common.MapStr {
+	select {
+	case event := <-events:
+		return event.ToMapStr()
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return nil
+}
+
+func TestHarvester(t *testing.T) {
+	events := make(chan *input.Event)
+	h := newTestHarvester(t, events)
+	defer h.Stop()
+
+	udpConn, err := net.Dial("udp", h.udp.Addr().String())
+	require.NoError(t, err)
+	defer udpConn.Close()
+
+	tcpConn, err := net.Dial("tcp", h.tcp.Addr().String())
+	require.NoError(t, err)
+	defer tcpConn.Close()
+
+	message := "<34>1 2003-10-11T22:14:15.003Z mymachine su - ID47 - 'su root' failed"
+
+	udpConn.Write([]byte(message))
+	event := receive(t, events)
+	assert.Equal(t, "'su root' failed", event["message"])
+	assert.Equal(t, udpConn.LocalAddr().String(), event["source"])
+	assert.Equal(t, "syslog", event["input_type"])
+	assert.Equal(t, common.Time(time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC)), event["@timestamp"])
+	assert.Equal(t, common.MapStr{
+		"priority":       34,
+		"facility":       4,
+		"facility_label": "security/authorization",
+		"severity":       2,
+		"severity_label": "Critical",
+		"version":        1,
+		"hostname":       "mymachine",
+		"program":        "su",
+		"msgid":          "ID47",
+	}, event["syslog"])
+
+	fmt.Fprintf(tcpConn, "%d %s", len(message), message)
+	event = receive(t, events)
+	assert.Equal(t, "'su root' failed", event["message"])
+	assert.Equal(t, tcpConn.LocalAddr().String(), event["source"])
+}
+
+func TestHarvesterParseFailure(t *testing.T) {
+	events := make(chan *input.Event)
+	h := newTestHarvester(t, events)
+	defer h.Stop()
+
+	tcpConn, err := net.Dial("tcp", h.tcp.Addr().String())
+	require.NoError(t, err)
+	defer tcpConn.Close()
+
+	tcpConn.Write([]byte("not a syslog message\n<13>Oct 11 22:14:15 host app: valid\n"))
+
+	event := receive(t, events)
+	assert.Equal(t, "not a syslog message", event["message"])
+	assert.Nil(t, event["syslog"])
+	assert.Equal(t, []string{"network", parseFailureTag}, event[common.EventMetadataKey].(common.EventMetadata).Tags)
+
+	// The tags of the config are not modified
+	event = receive(t, events)
+	assert.Equal(t, "valid", event["message"])
+	assert.Equal(t, []string{"network"}, event[common.EventMetadataKey].(common.EventMetadata).Tags)
+}
+
+func TestConfigRequiresProtocol(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"input_type": "syslog",
+	})
+	require.NoError(t, err)
+
+	_, err = NewHarvester(cfg, channel.NewOutlet(nil, nil, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,359 @@
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+const (
+	nilValue    = "-"
+	maxPriority = 191
+
+	// rfc3164 timestamps do not contain a year, e.g. "Oct  9 22:14:15"
+	rfc3164TimeLayout = "Jan _2 15:04:05"
+)
+
+var (
+	errNoPriority  = errors.New("message does not start with a valid priority")
+	errNoTimestamp = errors.New("message does not contain a valid timestamp")
+	errNoHeader    = errors.New("message header is incomplete")
+
+	// utf8BOM can prefix the rfc5424 message part
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	// tagRegexp matches the rfc3164 tag with the optional pid, e.g. "sshd[1234]: "
+	tagRegexp = regexp.MustCompile(`(?s)^([^\s\[\]:]+)(?:\[([^\]]*)\])?: ?(.*)$`)
+)
+
+var facilityLabels = []string{
+	"kernel",
+	"user-level",
+	"mail",
+	"system",
+	"security/authorization",
+	"syslogd",
+	"line printer",
+	"network news",
+	"UUCP",
+	"clock",
+	"security/authorization",
+	"FTP",
+	"NTP",
+	"log audit",
+	"log alert",
+	"clock",
+	"local0",
+	"local1",
+	"local2",
+	"local3",
+	"local4",
+	"local5",
+	"local6",
+	"local7",
+}
+
+var severityLabels = []string{
+	"Emergency",
+	"Alert",
+	"Critical",
+	"Error",
+	"Warning",
+	"Notice",
+	"Informational",
+	"Debug",
+}
+
+// message contains the parts of a parsed syslog message
+type message struct {
+	priority       int
+	version        int // 0 for rfc3164 messages
+	timestamp      time.Time
+	hostname       string
+	program        string
+	pid            string
+	msgID          string
+	structuredData common.MapStr
+	message        string
+}
+
+// parse parses a rfc3164 or rfc5424 syslog message. The format is detected
+// based on the version, which is only present in rfc5424 messages. The current
+// time and the location are used to complete rfc3164 timestamps, which contain
+// neither the year nor the timezone.
+func parse(data []byte, now time.Time, loc *time.Location) (*message, error) {
+	priority, rest, err := parsePriority(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &message{priority: priority}
+
+	if version, header, ok := parseVersion(rest); ok {
+		m.version = version
+		err = m.parseRFC5424(header)
+	} else {
+		err = m.parseRFC3164(rest, now, loc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// parsePriority parses the "<PRI>" prefix of the message
+func parsePriority(s string) (int, string, error) {
+	if len(s) < 3 || s[0] != '<' {
+		return 0, "", errNoPriority
+	}
+
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return 0, "", errNoPriority
+	}
+
+	priority, err := strconv.Atoi(s[1:end])
+	if err != nil || priority < 0 || priority > maxPriority {
+		return 0, "", errNoPriority
+	}
+
+	return priority, s[end+1:], nil
+}
+
+// parseVersion parses the rfc5424 version, which is followed by a space
+func parseVersion(s string) (int, string, bool) {
+	end := strings.IndexByte(s, ' ')
+	if end < 1 || end > 2 {
+		return 0, "", false
+	}
+
+	version, err := strconv.Atoi(s[:end])
+	if err != nil || version < 1 {
+		return 0, "", false
+	}
+
+	return version, s[end+1:], true
+}
+
+func (m *message) parseRFC5424(s string) error {
+	// TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+	parts := strings.SplitN(s, " ", 6)
+	if len(parts) < 6 {
+		return errNoHeader
+	}
+
+	if parts[0] != nilValue {
+		ts, err := time.Parse(time.RFC3339Nano, parts[0])
+		if err != nil {
+			return errNoTimestamp
+		}
+		m.timestamp = ts
+	}
+
+	m.hostname = nilToEmpty(parts[1])
+	m.program = nilToEmpty(parts[2])
+	m.pid = nilToEmpty(parts[3])
+	m.msgID = nilToEmpty(parts[4])
+
+	structuredData, rest, err := parseStructuredData(parts[5])
+	if err != nil {
+		return err
+	}
+	m.structuredData = structuredData
+
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return fmt.Errorf("unexpected character after structured data: %q", rest[0])
+		}
+		m.message = strings.TrimPrefix(rest[1:], string(utf8BOM))
+	}
+
+	return nil
+}
+
+// parseStructuredData parses the rfc5424 structured data elements, e.g.
+// [exampleSDID@32473 iut="3" eventSource="Application"]. The elements are
+// returned as a map from the element ID to its parameters.
+func parseStructuredData(s string) (common.MapStr, string, error) {
+	if strings.HasPrefix(s, nilValue) {
+		return nil, s[len(nilValue):], nil
+	}
+
+	if len(s) == 0 || s[0] != '[' {
+		return nil, "", errors.New("invalid structured data")
+	}
+
+	data := common.MapStr{}
+	for len(s) > 0 && s[0] == '[' {
+		end := strings.IndexAny(s, " ]")
+		if end < 2 {
+			return nil, "", errors.New("invalid structured data element ID")
+		}
+
+		id := s[1:end]
+		params := common.MapStr{}
+		s = s[end:]
+
+		for len(s) > 0 && s[0] == ' ' {
+			eq := strings.Index(s, "=\"")
+			if eq < 2 {
+				return nil, "", errors.New("invalid structured data parameter")
+			}
+			name := s[1:eq]
+
+			value, rest, err := parseParamValue(s[eq+2:])
+			if err != nil {
+				return nil, "", err
+			}
+
+			params[name] = value
+			s = rest
+		}
+
+		if len(s) == 0 || s[0] != ']' {
+			return nil, "", errors.New("unterminated structured data element")
+		}
+		s = s[1:]
+
+		data[id] = params
+	}
+
+	return data, s, nil
+}
+
+// parseParamValue parses a quoted parameter value up to the closing quote,
+// resolving the escaped characters '"', '\' and ']'.
+func parseParamValue(s string) (string, string, error) {
+	var value bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\' || s[i+1] == ']') {
+				i++
+			}
+			value.WriteByte(s[i])
+		case '"':
+			return value.String(), s[i+1:], nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+
+	return "", "", errors.New("unterminated structured data parameter value")
+}
+
+func (m *message) parseRFC3164(s string, now time.Time, loc *time.Location) error {
+	// TIMESTAMP [HOSTNAME] TAG[PID]: MSG
+	rest, err := m.parseRFC3164Timestamp(s, now, loc)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) == 0 || rest[0] != ' ' {
+		return errNoHeader
+	}
+	rest = rest[1:]
+
+	// The hostname is optional, e.g. for messages sent to the local syslog daemon.
+	// The first field is the tag if it contains the pid or ends with a colon.
+	if end := strings.IndexByte(rest, ' '); end > 0 {
+		first := rest[:end]
+		if !strings.HasSuffix(first, ":") && !strings.ContainsRune(first, '[') {
+			m.hostname = first
+			rest = rest[end+1:]
+		}
+	}
+
+	if match := tagRegexp.FindStringSubmatch(rest); match != nil {
+		m.program = match[1]
+		m.pid = match[2]
+		m.message = match[3]
+	} else {
+		m.message = rest
+	}
+
+	return nil
+}
+
+// parseRFC3164Timestamp parses the timestamp and infers the year. Besides the
+// rfc3164 format, rfc3339 timestamps as sent by many syslog daemons are accepted.
+func (m *message) parseRFC3164Timestamp(s string, now time.Time, loc *time.Location) (string, error) {
+	if len(s) >= len(rfc3164TimeLayout) {
+		if ts, err := time.ParseInLocation(rfc3164TimeLayout, s[:len(rfc3164TimeLayout)], loc); err == nil {
+			m.timestamp = inferYear(ts, now.In(loc))
+			return s[len(rfc3164TimeLayout):], nil
+		}
+	}
+
+	end := strings.IndexByte(s, ' ')
+	if end < 0 {
+		end = len(s)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, s[:end])
+	if err != nil {
+		return "", errNoTimestamp
+	}
+
+	m.timestamp = ts
+	return s[end:], nil
+}
+
+// inferYear sets the year of the timestamp. The current year is used, unless the
+// timestamp would be more than a day in the future. This happens for messages
+// sent at the end of December which are received in January.
+func inferYear(ts time.Time, now time.Time) time.Time {
+	ts = time.Date(now.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), ts.Location())
+	if ts.After(now.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts
+}
+
+func nilToEmpty(s string) string {
+	if s == nilValue {
+		return ""
+	}
+	return s
+}
+
+// fields returns the syslog fields of the event. Empty values are omitted.
+func (m *message) fields() common.MapStr {
+	facility := m.priority / 8
+	severity := m.priority % 8
+
+	fields := common.MapStr{
+		"priority":       m.priority,
+		"facility":       facility,
+		"facility_label": facilityLabels[facility],
+		"severity":       severity,
+		"severity_label": severityLabels[severity],
+	}
+
+	if m.version > 0 {
+		fields["version"] = m.version
+	}
+
+	for name, value := range map[string]string{
+		"hostname": m.hostname,
+		"program":  m.program,
+		"pid":      m.pid,
+		"msgid":    m.msgID,
+	} {
+		if value != "" {
+			fields[name] = value
+		}
+	}
+
+	if len(m.structuredData) > 0 {
+		fields["structured_data"] = m.structuredData
+	}
+
+	return fields
+}
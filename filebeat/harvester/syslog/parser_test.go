@@ -0,0 +1,236 @@
+// +build !integration
+
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2017, time.October, 12, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input    string
+		expected message
+	}{
+		{
+			input: "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+			expected: message{
+				priority:  34,
+				timestamp: time.Date(2017, time.October, 11, 22, 14, 15, 0, time.UTC),
+				hostname:  "mymachine",
+				program:   "su",
+				message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			input: "<86>Oct  9 08:01:02 web-1 sshd[1234]: Accepted publickey for admin",
+			expected: message{
+				priority:  86,
+				timestamp: time.Date(2017, time.October, 9, 8, 1, 2, 0, time.UTC),
+				hostname:  "web-1",
+				program:   "sshd",
+				pid:       "1234",
+				message:   "Accepted publickey for admin",
+			},
+		},
+		{
+			// Without hostname, as sent to the local syslog daemon
+			input: "<13>Oct 12 09:00:00 myapp[42]: started",
+			expected: message{
+				priority:  13,
+				timestamp: time.Date(2017, time.October, 12, 9, 0, 0, 0, time.UTC),
+				program:   "myapp",
+				pid:       "42",
+				message:   "started",
+			},
+		},
+		{
+			// Without tag
+			input: "<13>Oct 12 09:00:00 router link down on port 3",
+			expected: message{
+				priority:  13,
+				timestamp: time.Date(2017, time.October, 12, 9, 0, 0, 0, time.UTC),
+				hostname:  "router",
+				message:   "link down on port 3",
+			},
+		},
+		{
+			// rfc3339 timestamp as sent by rsyslog
+			input: "<30>2017-10-11T22:14:15.003+02:00 db-1 mysqld: ready",
+			expected: message{
+				priority:  30,
+				timestamp: time.Date(2017, time.October, 11, 22, 14, 15, 3000000, time.FixedZone("", 2*60*60)),
+				hostname:  "db-1",
+				program:   "mysqld",
+				message:   "ready",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		m, err := parse([]byte(test.input), now, time.UTC)
+		if assert.NoError(t, err, test.input) {
+			assert.True(t, test.expected.timestamp.Equal(m.timestamp), test.input)
+			m.timestamp = test.expected.timestamp
+			assert.Equal(t, test.expected, *m, test.input)
+		}
+	}
+}
+
+func TestParseRFC3164YearInference(t *testing.T) {
+	tests := []struct {
+		now      time.Time
+		input    string
+		expected time.Time
+	}{
+		{
+			now:      time.Date(2018, time.January, 1, 0, 5, 0, 0, time.UTC),
+			input:    "<13>Dec 31 23:59:59 host app: end of year",
+			expected: time.Date(2017, time.December, 31, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			// Small clock skew of the sender does not change the year
+			now:      time.Date(2017, time.December, 31, 23, 59, 0, 0, time.UTC),
+			input:    "<13>Jan  1 00:01:00 host app: new year",
+			expected: time.Date(2018, time.January, 1, 0, 1, 0, 0, time.UTC).AddDate(-1, 0, 0),
+		},
+		{
+			now:      time.Date(2017, time.June, 1, 12, 0, 0, 0, time.UTC),
+			input:    "<13>Jun  1 12:30:00 host app: slightly ahead",
+			expected: time.Date(2017, time.June, 1, 12, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		m, err := parse([]byte(test.input), test.now, time.UTC)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, m.timestamp, test.input)
+		}
+	}
+}
+
+func TestParseRFC3164Location(t *testing.T) {
+	loc := time.FixedZone("test", -5*60*60)
+	now := time.Date(2017, time.October, 12, 10, 0, 0, 0, time.UTC)
+
+	m, err := parse([]byte("<13>Oct 12 01:00:00 host app: message"), now, loc)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2017, time.October, 12, 6, 0, 0, 0, time.UTC), m.timestamp.UTC())
+}
+
+func TestParseRFC5424(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected message
+	}{
+		{
+			input: "<34>1 2003-10-11T22:14:15.003Z mymachine.example.com su - ID47 - \xEF\xBB\xBF'su root' failed for lonvick on /dev/pts/8",
+			expected: message{
+				priority:  34,
+				version:   1,
+				timestamp: time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC),
+				hostname:  "mymachine.example.com",
+				program:   "su",
+				msgID:     "ID47",
+				message:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			input: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event`,
+			expected: message{
+				priority:  165,
+				version:   1,
+				timestamp: time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC),
+				hostname:  "mymachine.example.com",
+				program:   "evntslog",
+				pid:       "1234",
+				msgID:     "ID47",
+				structuredData: common.MapStr{
+					"exampleSDID@32473": common.MapStr{
+						"iut":         "3",
+						"eventSource": "Application",
+						"eventID":     "1011",
+					},
+					"examplePriority@32473": common.MapStr{
+						"class": "high",
+					},
+				},
+				message: "An application event",
+			},
+		},
+		{
+			// Escaped characters and no message
+			input: `<165>1 - - - - - [meta value="a \"quoted\" \] value\\"]`,
+			expected: message{
+				priority: 165,
+				version:  1,
+				structuredData: common.MapStr{
+					"meta": common.MapStr{
+						"value": `a "quoted" ] value\`,
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		m, err := parse([]byte(test.input), time.Now(), time.UTC)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, *m, test.input)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"no priority",
+		"<>Oct 11 22:14:15 host app: message",
+		"<192>Oct 11 22:14:15 host app: message",
+		"<13>not a timestamp",
+		"<13>Oct 11 22:14:15",
+		"<13>1 2003-10-11T22:14:15Z host app",
+		"<13>1 yesterday host app - - - message",
+		`<13>1 - host app - - [unterminated key="value"`,
+		`<13>1 - host app - - [id key=value] message`,
+		"<13>1 - host app - - -message",
+	}
+
+	for _, input := range tests {
+		_, err := parse([]byte(input), time.Now(), time.UTC)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestMessageFields(t *testing.T) {
+	m := message{
+		priority: 165,
+		version:  1,
+		hostname: "host",
+		program:  "app",
+		pid:      "12",
+		structuredData: common.MapStr{
+			"id": common.MapStr{"key": "value"},
+		},
+	}
+
+	assert.Equal(t, common.MapStr{
+		"priority":       165,
+		"facility":       20,
+		"facility_label": "local4",
+		"severity":       5,
+		"severity_label": "Notice",
+		"version":        1,
+		"hostname":       "host",
+		"program":        "app",
+		"pid":            "12",
+		"structured_data": common.MapStr{
+			"id": common.MapStr{"key": "value"},
+		},
+	}, m.fields())
+}
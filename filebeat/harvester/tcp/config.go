@@ -0,0 +1,132 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+)
+
+// DefaultServerConfig contains the default settings of the TCP server
+var DefaultServerConfig = ServerConfig{
+	Host:           "localhost:9000",
+	MaxMessageSize: 20 * humanize.MiByte,
+	MaxConnections: 0,
+	Timeout:        5 * time.Minute,
+}
+
+var defaultConfig = config{
+	ServerConfig:  DefaultServerConfig,
+	LineDelimiter: "\n",
+	DocumentType:  "log",
+}
+
+type config struct {
+	common.EventMetadata `config:",inline"` // Fields and tags to add to events.
+	ServerConfig         `config:",inline"`
+	LineDelimiter        string `config:"line_delimiter" validate:"nonzero"`
+	DocumentType         string `config:"document_type"`
+	InputType            string `config:"input_type"`
+	Pipeline             string `config:"pipeline"`
+	Module               string `config:"_module_name"`  // hidden option to set the module name
+	Fileset              string `config:"_fileset_name"` // hidden option to set the fileset name
+}
+
+// ServerConfig contains the settings of the TCP server. It can be inlined
+// into the config of other inputs which listen on TCP.
+type ServerConfig struct {
+	Host           string        `config:"host" validate:"nonzero"`
+	MaxMessageSize int           `config:"max_message_size" validate:"nonzero,min=1"`
+	MaxConnections int           `config:"max_connections" validate:"min=0"`
+	Timeout        time.Duration `config:"timeout" validate:"nonzero,min=0"`
+	TLS            *TLSConfig    `config:"ssl"`
+}
+
+// TLSConfig extends the TLS settings of the outputs with the client
+// authentication mode, as the server side needs to verify its clients.
+// The certificate_authorities are used to verify client certificates.
+type TLSConfig struct {
+	outputs.TLSConfig `config:",inline"`
+	ClientAuth        *tlsClientAuth `config:"client_authentication"`
+}
+
+type tlsClientAuth tls.ClientAuthType
+
+var tlsClientAuthTypes = map[string]tlsClientAuth{
+	"none":     tlsClientAuth(tls.NoClientCert),
+	"optional": tlsClientAuth(tls.VerifyClientCertIfGiven),
+	"required": tlsClientAuth(tls.RequireAndVerifyClientCert),
+}
+
+var (
+	// ErrServerNoCertificate indicates a missing certificate for a TLS server
+	ErrServerNoCertificate = errors.New("ssl.certificate and ssl.key are required when ssl is enabled")
+
+	// ErrClientAuthNoCA indicates client authentication without CAs to verify the client certificates
+	ErrClientAuthNoCA = errors.New("ssl.certificate_authorities are required for client_authentication")
+)
+
+func (c *TLSConfig) Validate() error {
+	if err := c.TLSConfig.Validate(); err != nil {
+		return err
+	}
+
+	if !c.IsEnabled() {
+		return nil
+	}
+
+	if c.Certificate.Certificate == "" {
+		return ErrServerNoCertificate
+	}
+
+	if c.ClientAuth != nil && tls.ClientAuthType(*c.ClientAuth) != tls.NoClientCert && len(c.CAs) == 0 {
+		return ErrClientAuthNoCA
+	}
+
+	return nil
+}
+
+// IsEnabled returns true if the ssl section is configured and not disabled
+func (c *TLSConfig) IsEnabled() bool {
+	return c != nil && c.TLSConfig.IsEnabled()
+}
+
+// clientAuth returns the configured client authentication mode. By default
+// client certificates are required if certificate authorities are configured.
+func (c *TLSConfig) clientAuth() tls.ClientAuthType {
+	if c.ClientAuth != nil {
+		return tls.ClientAuthType(*c.ClientAuth)
+	}
+	if len(c.CAs) > 0 {
+		return tls.RequireAndVerifyClientCert
+	}
+	return tls.NoClientCert
+}
+
+// BuildServerConfig loads the certificates and creates the TLS config used by the server
+func (c *TLSConfig) BuildServerConfig() (*tls.Config, error) {
+	tlsConfig, err := outputs.LoadTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	config := tlsConfig.BuildModuleConfig("")
+	config.ClientCAs = tlsConfig.RootCAs
+	config.ClientAuth = c.clientAuth()
+	return config, nil
+}
+
+func (a *tlsClientAuth) Unpack(s string) error {
+	t, found := tlsClientAuthTypes[s]
+	if !found {
+		return fmt.Errorf("invalid tls client authentication type '%v'", s)
+	}
+
+	*a = t
+	return nil
+}
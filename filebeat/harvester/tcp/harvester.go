@@ -0,0 +1,99 @@
+// Package tcp contains the harvester used by the tcp prospector. It accepts
+// connections on a TCP socket and splits the received streams into events on
+// the configured delimiter.
+//
+// Opposed to udp, no events are dropped. If the output cannot keep up, reading
+// from the connections blocks and the clients are slowed down by TCP flow control.
+package tcp
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type Harvester struct {
+	config config
+	outlet *channel.Outlet
+	server *Server
+}
+
+// NewHarvester creates a new tcp harvester from the prospector config. Events
+// are forwarded to the given outlet.
+func NewHarvester(cfg *common.Config, outlet *channel.Outlet) (*Harvester, error) {
+	h := &Harvester{
+		config: defaultConfig,
+		outlet: outlet,
+	}
+
+	if err := cfg.Unpack(&h.config); err != nil {
+		return nil, err
+	}
+
+	var err error
+	h.server, err = NewServer(&h.config.ServerConfig, SplitFunc([]byte(h.config.LineDelimiter)), h.onMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Start starts accepting connections
+func (h *Harvester) Start() error {
+	return h.server.Start()
+}
+
+// Addr returns the address the harvester is listening on
+func (h *Harvester) Addr() net.Addr {
+	return h.server.Addr()
+}
+
+// Stop closes all connections and waits until the harvester is stopped
+func (h *Harvester) Stop() {
+	h.server.Stop()
+}
+
+// onMessage is called concurrently by the connection handlers
+func (h *Harvester) onMessage(data []byte, metadata Metadata) {
+	h.outlet.OnEvent(h.createEvent(data, metadata))
+}
+
+func (h *Harvester) createEvent(data []byte, metadata Metadata) *input.Event {
+	text := string(data)
+
+	event := input.NewEvent(file.State{Source: metadata.RemoteAddr.String()})
+	event.ReadTime = time.Now()
+	event.Bytes = len(data)
+	event.Text = &text
+	event.EventMetadata = h.config.EventMetadata
+	event.InputType = h.config.InputType
+	event.DocumentType = h.config.DocumentType
+	event.Pipeline = h.config.Pipeline
+	event.Module = h.config.Module
+	event.Fileset = h.config.Fileset
+	event.Data = common.MapStr{
+		"remote": remoteFields(metadata.RemoteAddr),
+	}
+
+	return event
+}
+
+// remoteFields returns the ip and port of the remote address
+func remoteFields(addr net.Addr) common.MapStr {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return common.MapStr{"ip": addr.String()}
+	}
+
+	fields := common.MapStr{"ip": host}
+	if p, err := strconv.Atoi(port); err == nil {
+		fields["port"] = p
+	}
+	return fields
+}
@@ -0,0 +1,54 @@
+// +build !integration
+
+package tcp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestHarvester(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"host":           "127.0.0.1:0",
+		"input_type":     "tcp",
+		"line_delimiter": "|",
+		"fields":         map[string]interface{}{"app": "billing"},
+	})
+	require.NoError(t, err)
+
+	events := make(chan *input.Event)
+	h, err := NewHarvester(cfg, channel.NewOutlet(make(chan struct{}), events, &sync.WaitGroup{}))
+	require.NoError(t, err)
+	require.NoError(t, h.Start())
+	defer h.Stop()
+
+	conn, err := net.Dial("tcp", h.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("hello|world|"))
+	require.NoError(t, err)
+
+	local := conn.LocalAddr().(*net.TCPAddr)
+	for _, expected := range []string{"hello", "world"} {
+		select {
+		case event := <-events:
+			fields := event.ToMapStr()
+			assert.Equal(t, expected, fields["message"])
+			assert.Equal(t, "tcp", fields["input_type"])
+			assert.Equal(t, local.String(), fields["source"])
+			assert.Equal(t, common.MapStr{"ip": local.IP.String(), "port": local.Port}, fields["remote"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	connectionsActive   = expvar.NewInt("filebeat.tcp.connections")
+	connectionsRejected = expvar.NewInt("filebeat.tcp.rejected")
+)
+
+// Metadata contains the information about the connection a message was received on
+type Metadata struct {
+	RemoteAddr net.Addr
+	TLS        *tls.ConnectionState
+}
+
+// CallbackFunc is called for every message received. The data is only valid
+// until the callback returns.
+type CallbackFunc func(data []byte, metadata Metadata)
+
+// Server accepts TCP connections and splits the received streams into
+// messages with the given split function.
+type Server struct {
+	config    *ServerConfig
+	splitFunc bufio.SplitFunc
+	callback  CallbackFunc
+	tlsConfig *tls.Config
+	listener  net.Listener
+	clients   map[net.Conn]struct{}
+	mutex     sync.Mutex
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a new TCP server. The server does not listen before Start is called.
+func NewServer(config *ServerConfig, splitFunc bufio.SplitFunc, callback CallbackFunc) (*Server, error) {
+	s := &Server{
+		config:    config,
+		splitFunc: splitFunc,
+		callback:  callback,
+		clients:   map[net.Conn]struct{}{},
+		done:      make(chan struct{}),
+	}
+
+	if config.TLS.IsEnabled() {
+		var err error
+		s.tlsConfig, err = config.TLS.BuildServerConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Start starts listening on the configured host and accepts connections
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.config.Host)
+	if err != nil {
+		return err
+	}
+
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+
+	logp.Info("Started listening for TCP connections on: %s", s.listener.Addr())
+
+	s.wg.Add(1)
+	go s.run()
+
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stop stops accepting new connections, closes all open connections and waits
+// until all connection handlers returned.
+func (s *Server) Stop() {
+	logp.Info("Stopping TCP server on: %s", s.listener.Addr())
+	close(s.done)
+	s.listener.Close()
+
+	s.mutex.Lock()
+	for conn := range s.clients {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) run() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+
+			logp.Err("Error accepting TCP connection: %v", err)
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			return
+		}
+
+		if !s.register(conn) {
+			connectionsRejected.Add(1)
+			logp.Warn("Connection from %s rejected, max_connections of %d reached", conn.RemoteAddr(), s.config.MaxConnections)
+			conn.Close()
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.unregister(conn)
+
+			s.handle(conn)
+		}()
+	}
+}
+
+// register adds the connection to the list of clients. It returns false if the
+// connection limit is reached or the server is stopping.
+func (s *Server) register(conn net.Conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+
+	if s.config.MaxConnections > 0 && len(s.clients) >= s.config.MaxConnections {
+		return false
+	}
+
+	s.clients[conn] = struct{}{}
+	connectionsActive.Add(1)
+	return true
+}
+
+func (s *Server) unregister(conn net.Conn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	conn.Close()
+	delete(s.clients, conn)
+	connectionsActive.Add(-1)
+}
+
+// handle reads messages from the connection until it is closed, idle for
+// longer than the timeout or a message exceeds the max message size.
+func (s *Server) handle(conn net.Conn) {
+	logp.Debug("tcp", "New connection from %s", conn.RemoteAddr())
+
+	metadata := Metadata{RemoteAddr: conn.RemoteAddr()}
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		tlsConn.SetDeadline(time.Now().Add(s.config.Timeout))
+		if err := tlsConn.Handshake(); err != nil {
+			logp.Err("TLS handshake with %s failed: %v", conn.RemoteAddr(), err)
+			return
+		}
+		tlsConn.SetDeadline(time.Time{})
+
+		state := tlsConn.ConnectionState()
+		metadata.TLS = &state
+	}
+
+	scanner := bufio.NewScanner(&deadlineReader{conn: conn, timeout: s.config.Timeout})
+	bufferSize := bufio.MaxScanTokenSize
+	if s.config.MaxMessageSize < bufferSize {
+		bufferSize = s.config.MaxMessageSize
+	}
+	scanner.Buffer(make([]byte, 0, bufferSize), s.config.MaxMessageSize)
+	scanner.Split(s.splitFunc)
+
+	for scanner.Scan() {
+		// Empty messages do not carry any data
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		s.callback(scanner.Bytes(), metadata)
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		logp.Debug("tcp", "Connection from %s closed", conn.RemoteAddr())
+		return
+	}
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		logp.Debug("tcp", "Closing idle connection from %s", conn.RemoteAddr())
+		return
+	}
+
+	if err == bufio.ErrTooLong {
+		logp.Err("Closing connection from %s, message exceeds max_message_size of %d bytes", conn.RemoteAddr(), s.config.MaxMessageSize)
+		return
+	}
+
+	logp.Err("Error reading from connection %s: %v", conn.RemoteAddr(), err)
+}
+
+// deadlineReader resets the read deadline of the connection before every read,
+// which closes connections that were idle for longer than the timeout.
+type deadlineReader struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	r.conn.SetReadDeadline(time.Now().Add(r.timeout))
+	return r.conn.Read(p)
+}
+
+// SplitFunc returns a split function which splits the stream on the given delimiter.
+// For the newline delimiter, a trailing carriage return is removed.
+func SplitFunc(delimiter []byte) bufio.SplitFunc {
+	if bytes.Equal(delimiter, []byte("\n")) {
+		return bufio.ScanLines
+	}
+
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		if i := bytes.Index(data, delimiter); i >= 0 {
+			return i + len(delimiter), data[:i], nil
+		}
+
+		// Return the remaining data as last message if the connection is closed
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+	}
+}
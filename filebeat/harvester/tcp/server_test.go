@@ -0,0 +1,297 @@
+// +build !integration
+
+package tcp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs/transport/transptest"
+)
+
+type message struct {
+	data     string
+	metadata Metadata
+}
+
+func newTestServer(t *testing.T, config *ServerConfig, delimiter string) (*Server, chan message) {
+	messages := make(chan message, 10)
+	callback := func(data []byte, metadata Metadata) {
+		messages <- message{string(data), metadata}
+	}
+
+	s, err := NewServer(config, SplitFunc([]byte(delimiter)), callback)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+
+	return s, messages
+}
+
+func testServerConfig() *ServerConfig {
+	return &ServerConfig{
+		Host:           "127.0.0.1:0",
+		MaxMessageSize: 1024,
+		Timeout:        5 * time.Second,
+	}
+}
+
+func receive(t *testing.T, messages chan message) message {
+	select {
+	case m := <-messages:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	return message{}
+}
+
+// waitClosed waits until the server closed the connection
+func waitClosed(t *testing.T, conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := conn.Read(make([]byte, 1))
+	if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		t.Fatal("connection was not closed by the server")
+	}
+	assert.Error(t, err)
+}
+
+func TestServerSplit(t *testing.T) {
+	tests := []struct {
+		delimiter string
+		input     string
+		expected  []string
+	}{
+		{
+			delimiter: "\n",
+			input:     "first\r\nsecond\n\nthird",
+			expected:  []string{"first", "second", "third"},
+		},
+		{
+			delimiter: ";;",
+			input:     "first;;second\nline;;;;third",
+			expected:  []string{"first", "second\nline", "third"},
+		},
+	}
+
+	for _, test := range tests {
+		s, messages := newTestServer(t, testServerConfig(), test.delimiter)
+
+		conn, err := net.Dial("tcp", s.Addr().String())
+		require.NoError(t, err)
+		_, err = conn.Write([]byte(test.input))
+		require.NoError(t, err)
+		conn.Close()
+
+		for _, expected := range test.expected {
+			m := receive(t, messages)
+			assert.Equal(t, expected, m.data)
+			assert.Equal(t, conn.LocalAddr().String(), m.metadata.RemoteAddr.String())
+			assert.Nil(t, m.metadata.TLS)
+		}
+
+		s.Stop()
+	}
+}
+
+func TestServerMaxMessageSize(t *testing.T) {
+	config := testServerConfig()
+	config.MaxMessageSize = 10
+	s, messages := newTestServer(t, config, "\n")
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("short\n" + strings.Repeat("x", 20) + "\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "short", receive(t, messages).data)
+	waitClosed(t, conn)
+}
+
+func TestServerMaxConnections(t *testing.T) {
+	config := testServerConfig()
+	config.MaxConnections = 1
+	s, messages := newTestServer(t, config, "\n")
+	defer s.Stop()
+
+	first, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+
+	// Make sure the first connection is registered
+	first.Write([]byte("first\n"))
+	assert.Equal(t, "first", receive(t, messages).data)
+
+	second, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	waitClosed(t, second)
+
+	// Closing the first connection frees up a slot
+	first.Close()
+	for i := 0; ; i++ {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		require.NoError(t, err)
+		conn.Write([]byte("third\n"))
+		conn.Close()
+
+		select {
+		case m := <-messages:
+			assert.Equal(t, "third", m.data)
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+		if i > 50 {
+			t.Fatal("connection was never accepted")
+		}
+	}
+}
+
+func TestServerIdleTimeout(t *testing.T) {
+	config := testServerConfig()
+	config.Timeout = 100 * time.Millisecond
+	s, _ := newTestServer(t, config, "\n")
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	waitClosed(t, conn)
+}
+
+func TestServerStopClosesConnections(t *testing.T) {
+	s, messages := newTestServer(t, testServerConfig(), "\n")
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.Write([]byte("message\n"))
+	receive(t, messages)
+
+	s.Stop()
+	waitClosed(t, conn)
+}
+
+func TestServerTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tcp-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "ca")
+	require.NoError(t, transptest.GenCertsForIPIfMIssing(t, net.IP{127, 0, 0, 1}, name))
+
+	certificate, err := tls.LoadX509KeyPair(name+".pem", name+".key")
+	require.NoError(t, err)
+	pem, err := ioutil.ReadFile(name + ".pem")
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pem)
+
+	tests := []struct {
+		name       string
+		clientAuth string
+		clientCert bool
+		ok         bool
+	}{
+		{"client cert required", "required", true, true},
+		{"client cert missing", "required", false, false},
+		{"client cert optional", "optional", false, true},
+		{"client cert not requested", "none", false, true},
+	}
+
+	for _, test := range tests {
+		cfg, err := common.NewConfigFrom(map[string]interface{}{
+			"host": "127.0.0.1:0",
+			"ssl": map[string]interface{}{
+				"certificate":             name + ".pem",
+				"key":                     name + ".key",
+				"certificate_authorities": []string{name + ".pem"},
+				"client_authentication":   test.clientAuth,
+			},
+		})
+		require.NoError(t, err)
+
+		config := defaultConfig
+		require.NoError(t, cfg.Unpack(&config))
+
+		s, messages := newTestServer(t, &config.ServerConfig, "\n")
+
+		clientConfig := &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+		if test.clientCert {
+			clientConfig.Certificates = []tls.Certificate{certificate}
+		}
+
+		conn, err := tls.Dial("tcp", s.Addr().String(), clientConfig)
+		if err == nil {
+			_, err = conn.Write([]byte("secure\n"))
+		}
+		if err == nil && !test.ok {
+			// Handshake failures on the server side are only noticed by the client on read
+			_, err = bufio.NewReader(conn).ReadByte()
+		}
+
+		if test.ok {
+			require.NoError(t, err, test.name)
+			m := receive(t, messages)
+			assert.Equal(t, "secure", m.data, test.name)
+			require.NotNil(t, m.metadata.TLS, test.name)
+			assert.Equal(t, test.clientCert, len(m.metadata.TLS.PeerCertificates) > 0, test.name)
+		} else {
+			assert.Error(t, err, test.name)
+		}
+
+		if conn != nil {
+			conn.Close()
+		}
+		s.Stop()
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := map[string]map[string]interface{}{
+		"no certificate": {
+			"certificate_authorities": []string{"ca.pem"},
+		},
+		"client auth without CAs": {
+			"certificate":           "cert.pem",
+			"key":                   "cert.key",
+			"client_authentication": "required",
+		},
+		"invalid client auth": {
+			"certificate":           "cert.pem",
+			"key":                   "cert.key",
+			"client_authentication": "always",
+		},
+	}
+
+	for name, settings := range tests {
+		cfg, err := common.NewConfigFrom(map[string]interface{}{"ssl": settings})
+		require.NoError(t, err)
+
+		config := defaultConfig
+		assert.Error(t, cfg.Unpack(&config), name)
+	}
+
+	// Disabled ssl does not require any certificate
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"ssl.enabled": false})
+	require.NoError(t, err)
+	config := defaultConfig
+	assert.NoError(t, cfg.Unpack(&config))
+	assert.False(t, config.TLS.IsEnabled())
+}
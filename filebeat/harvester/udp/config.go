@@ -0,0 +1,37 @@
+package udp
+
+import (
+	"github.com/dustin/go-humanize"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// DefaultServerConfig contains the default settings of the UDP server
+var DefaultServerConfig = ServerConfig{
+	Host:           "localhost:9000",
+	MaxMessageSize: 10 * humanize.KiByte,
+	ReadBuffer:     0,
+}
+
+var defaultConfig = config{
+	ServerConfig: DefaultServerConfig,
+	DocumentType: "log",
+}
+
+type config struct {
+	common.EventMetadata `config:",inline"` // Fields and tags to add to events.
+	ServerConfig         `config:",inline"`
+	DocumentType         string `config:"document_type"`
+	InputType            string `config:"input_type"`
+	Pipeline             string `config:"pipeline"`
+	Module               string `config:"_module_name"`  // hidden option to set the module name
+	Fileset              string `config:"_fileset_name"` // hidden option to set the fileset name
+}
+
+// ServerConfig contains the settings of the UDP server. It can be inlined
+// into the config of other inputs which listen on UDP.
+type ServerConfig struct {
+	Host           string `config:"host" validate:"nonzero"`
+	MaxMessageSize int    `config:"max_message_size" validate:"nonzero,min=1"`
+	ReadBuffer     int    `config:"read_buffer" validate:"min=0"`
+}
@@ -0,0 +1,94 @@
+// Package udp contains the harvester used by the udp prospector. It listens on
+// a UDP socket and turns every received datagram into a single event.
+//
+// Reading from the socket never blocks on the publisher pipeline. In case the
+// output cannot keep up, datagrams are dropped and counted under filebeat.udp.dropped.
+package udp
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type Harvester struct {
+	config   config
+	outlet   *channel.Outlet
+	server   *Server
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHarvester creates a new udp harvester from the prospector config. Events
+// are forwarded to the given outlet.
+func NewHarvester(cfg *common.Config, outlet *channel.Outlet) (*Harvester, error) {
+	h := &Harvester{
+		config: defaultConfig,
+		outlet: outlet,
+		done:   make(chan struct{}),
+	}
+
+	if err := cfg.Unpack(&h.config); err != nil {
+		return nil, err
+	}
+
+	h.server = NewServer(&h.config.ServerConfig, h.onMessage)
+
+	// Make sure the harvester can stop itself while blocked on the outlet
+	h.outlet.SetSignal(h.done)
+
+	return h, nil
+}
+
+// Start binds the socket and starts reading datagrams
+func (h *Harvester) Start() error {
+	return h.server.Start()
+}
+
+// Addr returns the address the harvester is listening on
+func (h *Harvester) Addr() net.Addr {
+	return h.server.Addr()
+}
+
+// Stop closes the socket and waits until the harvester is stopped
+func (h *Harvester) Stop() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+		h.server.Stop()
+	})
+}
+
+// onMessage is only called by the single forwarding go routine of the server
+func (h *Harvester) onMessage(data []byte, addr net.Addr) {
+	// The outlet signal only interrupts a single blocked event, so events
+	// queued after stopping must not be sent
+	select {
+	case <-h.done:
+		return
+	default:
+	}
+
+	h.outlet.OnEventSignal(h.createEvent(data, addr))
+}
+
+func (h *Harvester) createEvent(data []byte, addr net.Addr) *input.Event {
+	text := string(data)
+
+	event := input.NewEvent(file.State{Source: addr.String()})
+	event.ReadTime = time.Now()
+	event.Bytes = len(data)
+	event.Text = &text
+	event.EventMetadata = h.config.EventMetadata
+	event.InputType = h.config.InputType
+	event.DocumentType = h.config.DocumentType
+	event.Pipeline = h.config.Pipeline
+	event.Module = h.config.Module
+	event.Fileset = h.config.Fileset
+
+	return event
+}
@@ -0,0 +1,128 @@
+// +build !integration
+
+package udp
+
+import (
+	"expvar"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/input"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func expvarValue(v *expvar.Int) int64 {
+	n, _ := strconv.ParseInt(v.String(), 10, 64)
+	return n
+}
+
+func newTestHarvester(t *testing.T, settings map[string]interface{}, events chan *input.Event) *Harvester {
+	config, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+
+	outlet := channel.NewOutlet(make(chan struct{}), events, &sync.WaitGroup{})
+	h, err := NewHarvester(config, outlet)
+	require.NoError(t, err)
+	require.NoError(t, h.Start())
+
+	return h
+}
+
+func send(t *testing.T, addr net.Addr, messages ...string) net.Addr {
+	conn, err := net.Dial("udp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	for _, m := range messages {
+		_, err = conn.Write([]byte(m))
+		require.NoError(t, err)
+	}
+	return conn.LocalAddr()
+}
+
+func receive(t *testing.T, events chan *input.Event) *input.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return nil
+}
+
+func TestHarvester(t *testing.T) {
+	events := make(chan *input.Event)
+	h := newTestHarvester(t, map[string]interface{}{
+		"host":       "127.0.0.1:0",
+		"input_type": "udp",
+		"fields":     map[string]interface{}{"device": "router"},
+	}, events)
+	defer h.Stop()
+
+	from := send(t, h.Addr(), "first message", "second message")
+
+	event := receive(t, events)
+	assert.Equal(t, "first message", *event.Text)
+	assert.Equal(t, from.String(), event.State.Source)
+	assert.Equal(t, "udp", event.InputType)
+	assert.Equal(t, len("first message"), event.Bytes)
+
+	fields := event.ToMapStr()
+	assert.Equal(t, from.String(), fields["source"])
+	assert.Equal(t, "first message", fields["message"])
+
+	event = receive(t, events)
+	assert.Equal(t, "second message", *event.Text)
+}
+
+func TestHarvesterMaxMessageSize(t *testing.T) {
+	events := make(chan *input.Event)
+	h := newTestHarvester(t, map[string]interface{}{
+		"host":             "127.0.0.1:0",
+		"max_message_size": 5,
+	}, events)
+	defer h.Stop()
+
+	send(t, h.Addr(), "truncated message")
+
+	event := receive(t, events)
+	assert.Equal(t, "trunc", *event.Text)
+}
+
+func TestHarvesterDropsWhenQueueFull(t *testing.T) {
+	// Nothing reads from the events channel, so the pipeline is blocked
+	events := make(chan *input.Event)
+	h := newTestHarvester(t, map[string]interface{}{
+		"host": "127.0.0.1:0",
+	}, events)
+	defer h.Stop()
+
+	dropped := expvarValue(eventsDropped)
+
+	// Send in small batches to not overflow the socket buffer itself
+	for i := 0; expvarValue(eventsDropped) == dropped; i++ {
+		if i > 2*queueSize/10 {
+			t.Fatal("no messages were dropped")
+		}
+		send(t, h.Addr(), "a", "b", "c", "d", "e", "f", "g", "h", "i", "j")
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHarvesterInvalidConfig(t *testing.T) {
+	config, err := common.NewConfigFrom(map[string]interface{}{
+		"host":             "127.0.0.1:0",
+		"max_message_size": 0,
+	})
+	require.NoError(t, err)
+
+	_, err = NewHarvester(config, channel.NewOutlet(nil, nil, nil))
+	assert.Error(t, err)
+}
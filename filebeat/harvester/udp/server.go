@@ -0,0 +1,143 @@
+package udp
+
+import (
+	"expvar"
+	"net"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// queueSize is the number of datagrams buffered between the socket and the callback
+const queueSize = 1024
+
+var (
+	eventsReceived = expvar.NewInt("filebeat.udp.received")
+	eventsDropped  = expvar.NewInt("filebeat.udp.dropped")
+)
+
+// CallbackFunc is called for every datagram received
+type CallbackFunc func(data []byte, addr net.Addr)
+
+// Server listens on a UDP socket. Reading from the socket never blocks on the
+// callback. Datagrams are put on an internal queue, which is drained by calling
+// the callback. In case the queue is full, new datagrams are dropped and
+// counted under filebeat.udp.dropped.
+type Server struct {
+	config   *ServerConfig
+	callback CallbackFunc
+	conn     *net.UDPConn
+	queue    chan datagram
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type datagram struct {
+	data []byte
+	addr net.Addr
+}
+
+// NewServer creates a new UDP server. The server does not listen before Start is called.
+func NewServer(config *ServerConfig, callback CallbackFunc) *Server {
+	return &Server{
+		config:   config,
+		callback: callback,
+		queue:    make(chan datagram, queueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start binds the socket and starts reading datagrams
+func (s *Server) Start() error {
+	addr, err := net.ResolveUDPAddr("udp", s.config.Host)
+	if err != nil {
+		return err
+	}
+
+	s.conn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	if s.config.ReadBuffer > 0 {
+		if err := s.conn.SetReadBuffer(s.config.ReadBuffer); err != nil {
+			s.conn.Close()
+			return err
+		}
+	}
+
+	logp.Info("Started listening for UDP messages on: %s", s.conn.LocalAddr())
+
+	s.wg.Add(2)
+	go s.read()
+	go s.forward()
+
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Stop closes the socket and waits until all go routines are stopped. Datagrams
+// still in the queue are discarded.
+func (s *Server) Stop() {
+	logp.Info("Stopping UDP listener on: %s", s.conn.LocalAddr())
+	close(s.done)
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+// read reads datagrams from the socket until it is closed
+func (s *Server) read() {
+	defer s.wg.Done()
+
+	buffer := make([]byte, s.config.MaxMessageSize)
+	for {
+		n, addr, err := s.conn.ReadFrom(buffer)
+		if err != nil {
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+
+			logp.Err("Error reading from UDP socket: %v", err)
+			if nerr, ok := err.(net.Error); ok && nerr.Temporary() {
+				continue
+			}
+			return
+		}
+
+		// Empty datagrams do not carry any data
+		if n == 0 {
+			continue
+		}
+
+		eventsReceived.Add(1)
+		data := make([]byte, n)
+		copy(data, buffer[:n])
+
+		select {
+		case s.queue <- datagram{data: data, addr: addr}:
+		default:
+			eventsDropped.Add(1)
+			logp.Debug("udp", "Queue full, dropping message from %s", addr)
+		}
+	}
+}
+
+// forward passes the queued datagrams to the callback
+func (s *Server) forward() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case d := <-s.queue:
+			s.callback(d.data, d.addr)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package ingest
+
+import "fmt"
+
+// Modes deciding where the ingest pipelines of the modules run
+const (
+	ModeAuto          = "auto"          // in the beat if the Elasticsearch output is not enabled
+	ModeLocal         = "local"         // always in the beat
+	ModeElasticsearch = "elasticsearch" // always in Elasticsearch
+)
+
+// Config configures the local ingest pipeline runner
+type Config struct {
+	Mode  string      `config:"mode"`
+	GeoIP GeoIPConfig `config:"geoip"`
+}
+
+// GeoIPConfig configures the database used by the geoip processor
+type GeoIPConfig struct {
+	DatabaseFile string `config:"database_file"`
+}
+
+var DefaultConfig = Config{
+	Mode: ModeAuto,
+}
+
+func (c *Config) Validate() error {
+	switch c.Mode {
+	case ModeAuto, ModeLocal, ModeElasticsearch:
+		return nil
+	default:
+		return fmt.Errorf("invalid local_pipelines.mode: %s", c.Mode)
+	}
+}
+
+// RunLocally returns true if the pipelines must run in the beat
+func (c *Config) RunLocally(elasticsearchEnabled bool) bool {
+	switch c.Mode {
+	case ModeLocal:
+		return true
+	case ModeElasticsearch:
+		return false
+	default:
+		return !elasticsearchEnabled
+	}
+}
@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("convert", newConvert)
+}
+
+// convert changes the type of a field. Arrays are converted element by element.
+type convert struct {
+	field         string
+	target        string
+	typ           string
+	ignoreMissing bool
+}
+
+func newConvert(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Target        string `config:"target_field"`
+		Type          string `config:"type" validate:"required"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	typ := strings.ToLower(config.Type)
+	switch typ {
+	case "integer", "long", "float", "double", "string", "boolean", "auto":
+	default:
+		return nil, fmt.Errorf("type [%s] not supported, cannot convert field", config.Type)
+	}
+
+	target := config.Target
+	if target == "" {
+		target = config.Field
+	}
+	return &convert{config.Field, target, typ, config.IgnoreMissing}, nil
+}
+
+func (p *convert) Run(event common.MapStr) error {
+	value, err := event.GetValue(p.field)
+	if err != nil || value == nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", p.field, p.field)
+	}
+
+	var converted interface{}
+	if list, ok := value.([]interface{}); ok {
+		result := make([]interface{}, len(list))
+		for i, elem := range list {
+			if result[i], err = convertValue(elem, p.typ); err != nil {
+				return err
+			}
+		}
+		converted = result
+	} else if converted, err = convertValue(value, p.typ); err != nil {
+		return err
+	}
+
+	_, err = event.Put(p.target, converted)
+	return err
+}
+
+func convertValue(value interface{}, typ string) (interface{}, error) {
+	s := fmt.Sprint(value)
+
+	switch typ {
+	case "integer", "long":
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert [%s] to %s", s, typ)
+		}
+		return i, nil
+	case "float", "double":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert [%s] to %s", s, typ)
+		}
+		return f, nil
+	case "boolean":
+		switch strings.ToLower(s) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("[%s] is not a boolean value, cannot convert to boolean", s)
+	case "auto":
+		if _, ok := value.(string); !ok {
+			return value, nil
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, nil
+		}
+		if b, err := convertValue(s, "boolean"); err == nil {
+			return b, nil
+		}
+		return s, nil
+	default:
+		return s, nil
+	}
+}
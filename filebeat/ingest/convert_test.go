@@ -0,0 +1,53 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		typ      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"integer", "42", int64(42)},
+		{"long", "-1", int64(-1)},
+		{"float", "1.5", float64(1.5)},
+		{"double", "2", float64(2)},
+		{"boolean", "true", true},
+		{"string", int64(3), "3"},
+		{"auto", "7", int64(7)},
+		{"auto", "0.5", float64(0.5)},
+		{"auto", "false", false},
+		{"auto", "text", "text"},
+		{"integer", []interface{}{"1", "2"}, []interface{}{int64(1), int64(2)}},
+	}
+
+	for _, test := range tests {
+		s := newTestStep(t, "convert", map[string]interface{}{"field": "a", "type": test.typ})
+
+		event := common.MapStr{"a": test.value}
+		if assert.NoError(t, s.run(event), "%s %v", test.typ, test.value) {
+			assert.Equal(t, test.expected, event["a"], "%s %v", test.typ, test.value)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	s := newTestStep(t, "convert", map[string]interface{}{"field": "a", "target_field": "b", "type": "integer"})
+	assert.Error(t, s.run(common.MapStr{"a": "x"}))
+	assert.Error(t, s.run(common.MapStr{}))
+
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newStep("convert", map[string]interface{}{"field": "a", "type": "date"}, r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,243 @@
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("date", newDate)
+}
+
+// date parses a date with the first matching format and stores it as timestamp
+type date struct {
+	field    string
+	target   string
+	formats  []dateFormat
+	location *time.Location
+}
+
+// dateFormat parses a value in the given location
+type dateFormat func(value string, loc *time.Location) (time.Time, error)
+
+// iso8601Layouts are the variants of ISO8601 accepted by the ISO8601 format.
+// Fractional seconds are accepted by all layouts.
+var iso8601Layouts = []string{
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// jodaLayouts maps the Joda-Time pattern letters to Go layouts. Longer patterns
+// must be listed before their prefixes.
+var jodaLayouts = []struct {
+	pattern string
+	layout  string
+}{
+	{"yyyy", "2006"}, {"YYYY", "2006"}, {"yy", "06"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"dd", "02"}, {"d", "2"},
+	{"EEEE", "Monday"}, {"EEE", "Mon"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"a", "PM"},
+	{"ZZ", "-07:00"}, {"Z", "-0700"}, {"z", "MST"},
+}
+
+func newDate(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field    string   `config:"field" validate:"required"`
+		Target   string   `config:"target_field"`
+		Formats  []string `config:"formats" validate:"required"`
+		Timezone string   `config:"timezone"`
+		Locale   string   `config:"locale"`
+	}{
+		Target:   "@timestamp",
+		Timezone: "UTC",
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone [%s]: %v", config.Timezone, err)
+	}
+
+	p := &date{field: config.Field, target: config.Target, location: loc}
+	for _, format := range config.Formats {
+		f, err := newDateFormat(format)
+		if err != nil {
+			return nil, err
+		}
+		p.formats = append(p.formats, f)
+	}
+	return p, nil
+}
+
+func newDateFormat(format string) (dateFormat, error) {
+	switch format {
+	case "ISO8601":
+		return parseISO8601, nil
+	case "UNIX":
+		return parseUnix, nil
+	case "UNIX_MS":
+		return parseUnixMs, nil
+	case "TAI64N":
+		return parseTAI64N, nil
+	}
+
+	layout, hasYear, err := jodaToLayout(format)
+	if err != nil {
+		return nil, err
+	}
+	return func(value string, loc *time.Location) (time.Time, error) {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil || hasYear {
+			return t, err
+		}
+
+		// Like Elasticsearch, dates without a year are in the current year
+		return t.AddDate(time.Now().In(loc).Year(), 0, 0), nil
+	}, nil
+}
+
+// jodaToLayout converts a Joda-Time pattern to a Go layout. Text in single quotes
+// is copied literally.
+func jodaToLayout(format string) (string, bool, error) {
+	var layout []string
+	hasYear := false
+
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				return "", false, fmt.Errorf("unterminated quote in date format [%s]", format)
+			}
+			layout = append(layout, format[i+1:i+1+end])
+			i += end + 2
+			continue
+		}
+
+		// Fractions of a second can only follow a separator in Go layouts
+		if format[i] == 'S' {
+			n := countRepeated(format[i:], 'S')
+			if i == 0 || (format[i-1] != '.' && format[i-1] != ',') {
+				return "", false, fmt.Errorf("unsupported fraction of second in date format [%s]", format)
+			}
+			layout = append(layout, strings.Repeat("0", n))
+			i += n
+			continue
+		}
+
+		matched := false
+		for _, joda := range jodaLayouts {
+			if strings.HasPrefix(format[i:], joda.pattern) {
+				if joda.pattern[0] == 'y' || joda.pattern[0] == 'Y' {
+					hasYear = true
+				}
+				layout = append(layout, joda.layout)
+				i += len(joda.pattern)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		c := format[i]
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			return "", false, fmt.Errorf("unsupported pattern letter %c in date format [%s]", c, format)
+		}
+		layout = append(layout, string(c))
+		i++
+	}
+
+	return strings.Join(layout, ""), hasYear, nil
+}
+
+func countRepeated(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func parseISO8601(value string, loc *time.Location) (time.Time, error) {
+	var err error
+	for _, layout := range iso8601Layouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+func parseUnix(value string, loc *time.Location) (time.Time, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(seconds*1000)*int64(time.Millisecond)).In(loc), nil
+}
+
+func parseUnixMs(value string, loc *time.Location) (time.Time, error) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).In(loc), nil
+}
+
+// parseTAI64N parses a TAI64N label, optionally prefixed by @
+func parseTAI64N(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimPrefix(value, "@")
+	if len(value) != 24 {
+		return time.Time{}, fmt.Errorf("invalid TAI64N label [%s]", value)
+	}
+
+	seconds, err := strconv.ParseUint(value[:16], 16, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	nanos, err := strconv.ParseUint(value[16:], 16, 32)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// The label counts from 2^62 before the epoch, offset by the TAI-UTC difference
+	const tai64Epoch = 1 << 62
+	const taiOffset = 10
+	return time.Unix(int64(seconds-tai64Epoch)-taiOffset, int64(nanos)).In(loc), nil
+}
+
+func (p *date) Run(event common.MapStr) error {
+	value, err := getField(event, p.field)
+	if err != nil {
+		return err
+	}
+	s := fmt.Sprint(value)
+
+	for _, format := range p.formats {
+		t, err := format(s, p.location)
+		if err == nil {
+			_, err = event.Put(p.target, common.Time(t.UTC()))
+			return err
+		}
+	}
+
+	return fmt.Errorf("unable to parse date [%s]", s)
+}
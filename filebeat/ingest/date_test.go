@@ -0,0 +1,82 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestDate(t *testing.T) {
+	tests := []struct {
+		formats  []interface{}
+		timezone string
+		value    string
+		expected string
+	}{
+		{[]interface{}{"dd/MMM/YYYY:H:m:s Z"}, "", "07/Dec/2016:11:05:07 +0100", "2016-12-07T10:05:07.000Z"},
+		{[]interface{}{"YYYY/MM/dd H:m:s"}, "Europe/Berlin", "2016/10/25 14:49:34", "2016-10-25T12:49:34.000Z"},
+		{[]interface{}{"EEE MMM dd H:m:s.SSSSSS YYYY"}, "", "Mon Dec 26 16:15:55.103786 2016", "2016-12-26T16:15:55.103Z"},
+		{[]interface{}{"ISO8601"}, "", "2016-12-09T12:08:33.335060Z", "2016-12-09T12:08:33.335Z"},
+		{[]interface{}{"ISO8601", "YYMMdd H:m:s"}, "", "161209 13:08:33", "2016-12-09T13:08:33.000Z"},
+		{[]interface{}{"yyyy-MM-dd'T'HH:mm:ss"}, "", "2017-03-14T19:20:30", "2017-03-14T19:20:30.000Z"},
+		{[]interface{}{"UNIX"}, "", "1489519230.177", "2017-03-14T19:20:30.177Z"},
+		{[]interface{}{"UNIX_MS"}, "", "1489519230177", "2017-03-14T19:20:30.177Z"},
+		{[]interface{}{"TAI64N"}, "", "@4000000058c842880a8cce40", "2017-03-14T19:20:30.177Z"},
+	}
+
+	for _, test := range tests {
+		options := map[string]interface{}{"field": "time", "formats": test.formats}
+		if test.timezone != "" {
+			options["timezone"] = test.timezone
+		}
+		s := newTestStep(t, "date", options)
+
+		event := common.MapStr{"time": test.value}
+		if assert.NoError(t, s.run(event), test.value) {
+			assert.Equal(t, test.expected, event["@timestamp"].(common.Time).String(), test.value)
+		}
+	}
+}
+
+func TestDateWithoutYear(t *testing.T) {
+	s := newTestStep(t, "date", map[string]interface{}{
+		"field":        "time",
+		"target_field": "parsed",
+		"formats":      []interface{}{"MMM  d HH:mm:ss", "MMM dd HH:mm:ss"},
+	})
+
+	event := common.MapStr{"time": "Feb 21 21:54:44"}
+	assert.NoError(t, s.run(event))
+	parsed := time.Time(event["parsed"].(common.Time))
+	assert.Equal(t, time.Now().UTC().Year(), parsed.Year())
+	assert.Equal(t, time.February, parsed.Month())
+	assert.Equal(t, 21, parsed.Day())
+
+	event = common.MapStr{"time": "Feb  9 21:19:40"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, 9, time.Time(event["parsed"].(common.Time)).Day())
+
+	err := s.run(common.MapStr{"time": "yesterday"})
+	if assert.Error(t, err) {
+		assert.Equal(t, "unable to parse date [yesterday]", err.Error())
+	}
+}
+
+func TestJodaToLayout(t *testing.T) {
+	layout, hasYear, err := jodaToLayout("dd/MMM/YYYY:HH:mm:ss.SSS Z")
+	assert.NoError(t, err)
+	assert.True(t, hasYear)
+	assert.Equal(t, "02/Jan/2006:15:04:05.000 -0700", layout)
+
+	_, _, err = jodaToLayout("HH:mm:ssSSS")
+	assert.Error(t, err)
+	_, _, err = jodaToLayout("QQ")
+	assert.Error(t, err)
+	_, _, err = jodaToLayout("'T")
+	assert.Error(t, err)
+}
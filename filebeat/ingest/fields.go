@@ -0,0 +1,115 @@
+package ingest
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("rename", newRename)
+	registerProcessor("remove", newRemove)
+	registerProcessor("set", newSet)
+}
+
+// rename moves a field to a new name. The target must not exist.
+type rename struct {
+	field         string
+	target        string
+	ignoreMissing bool
+}
+
+func newRename(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Target        string `config:"target_field" validate:"required"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &rename{config.Field, config.Target, config.IgnoreMissing}, nil
+}
+
+func (p *rename) Run(event common.MapStr) error {
+	value, err := event.GetValue(p.field)
+	if err != nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] doesn't exist", p.field)
+	}
+	if hasField(event, p.target) {
+		return fmt.Errorf("field [%s] already exists", p.target)
+	}
+
+	event.Delete(p.field)
+	_, err = event.Put(p.target, value)
+	return err
+}
+
+// remove deletes a field
+type remove struct {
+	field         string
+	ignoreMissing bool
+}
+
+func newRemove(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &remove{config.Field, config.IgnoreMissing}, nil
+}
+
+func (p *remove) Run(event common.MapStr) error {
+	if err := event.Delete(p.field); err != nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", p.field, p.field)
+	}
+	return nil
+}
+
+// set sets a field to a value. {{ field }} references in string values are
+// replaced by the value of the field.
+type set struct {
+	field    string
+	value    interface{}
+	override bool
+}
+
+func newSet(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field    string      `config:"field" validate:"required"`
+		Value    interface{} `config:"value"`
+		Override bool        `config:"override"`
+	}{
+		Override: true,
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+	if config.Value == nil {
+		return nil, fmt.Errorf("required property [value] is missing")
+	}
+	return &set{config.Field, config.Value, config.Override}, nil
+}
+
+func (p *set) Run(event common.MapStr) error {
+	if !p.override && hasField(event, p.field) {
+		return nil
+	}
+
+	value := p.value
+	if s, ok := value.(string); ok {
+		value = renderTemplate(s, event)
+	}
+
+	_, err := event.Put(p.field, value)
+	return err
+}
@@ -0,0 +1,58 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestRename(t *testing.T) {
+	s := newTestStep(t, "rename", map[string]interface{}{"field": "a.b", "target_field": "c"})
+
+	event := common.MapStr{"a": common.MapStr{"b": 1}}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, common.MapStr{"a": common.MapStr{}, "c": 1}, event)
+
+	assert.Error(t, s.run(common.MapStr{}))
+	assert.Error(t, s.run(common.MapStr{"a": common.MapStr{"b": 1}, "c": 2}))
+
+	s = newTestStep(t, "rename", map[string]interface{}{"field": "a", "target_field": "c", "ignore_missing": true})
+	assert.NoError(t, s.run(common.MapStr{}))
+}
+
+func TestRemove(t *testing.T) {
+	s := newTestStep(t, "remove", map[string]interface{}{"field": "a.b"})
+
+	event := common.MapStr{"a": common.MapStr{"b": 1, "c": 2}}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, common.MapStr{"a": common.MapStr{"c": 2}}, event)
+
+	err := s.run(event)
+	if assert.Error(t, err) {
+		assert.Equal(t, "field [a.b] not present as part of path [a.b]", err.Error())
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := newTestStep(t, "set", map[string]interface{}{"field": "a.b", "value": "{{ c }}-{{d}}"})
+
+	event := common.MapStr{"c": "x", "d": 1}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "x-1", event["a"].(common.MapStr)["b"])
+
+	s = newTestStep(t, "set", map[string]interface{}{"field": "a", "value": 2, "override": false})
+	event = common.MapStr{"a": 1}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, 1, event["a"])
+
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = newStep("set", map[string]interface{}{"field": "a"}, r)
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nranchev/go-libGeoIP"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("geoip", newGeoIP)
+}
+
+// geoip adds the location of an IP address. The legacy GeoIP database format
+// only supports IPv4 addresses, IPv6 addresses are not looked up.
+type geoip struct {
+	field         string
+	target        string
+	ignoreMissing bool
+	db            *libgeo.GeoIP
+}
+
+func newGeoIP(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Target        string `config:"target_field"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{
+		Target: "geoip",
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	if r.geoip == nil {
+		r.warnOnce("geoip", "The geoip ingest processor is skipped because no GeoIP database is "+
+			"configured. Set local_pipelines.geoip.database_file to enable it.")
+		return nil, errDisabled
+	}
+
+	return &geoip{config.Field, config.Target, config.IgnoreMissing, r.geoip}, nil
+}
+
+func (p *geoip) Run(event common.MapStr) error {
+	value, err := event.GetValue(p.field)
+	if err != nil || value == nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", p.field, p.field)
+	}
+
+	s := fmt.Sprint(value)
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return fmt.Errorf("'%s' is not an IP string literal.", s)
+	}
+	if ip.To4() == nil {
+		return nil
+	}
+
+	loc := p.db.GetLocationByIP(s)
+	if loc == nil {
+		return nil
+	}
+
+	geo := common.MapStr{}
+	addString(geo, "country_iso_code", loc.CountryCode)
+	addString(geo, "country_name", loc.CountryName)
+	addString(geo, "region_name", loc.Region)
+	addString(geo, "city_name", loc.City)
+	if loc.Latitude != 0 || loc.Longitude != 0 {
+		geo["location"] = common.MapStr{
+			"lat": float64(loc.Latitude),
+			"lon": float64(loc.Longitude),
+		}
+	}
+	if len(geo) == 0 {
+		return nil
+	}
+
+	_, err = event.Put(p.target, geo)
+	return err
+}
+
+func addString(m common.MapStr, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
@@ -0,0 +1,196 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("grok", newGrok)
+}
+
+// grok extracts fields from a string field with the first matching pattern
+type grok struct {
+	field         string
+	patterns      []*grokPattern
+	ignoreMissing bool
+}
+
+// grokPattern is a grok expression compiled to a regular expression. The
+// capture groups of the expression are named g0, g1, ... and map to the
+// fields in captures.
+type grokPattern struct {
+	regexp   *regexp.Regexp
+	captures map[string]grokCapture
+}
+
+type grokCapture struct {
+	field string
+	typ   string
+}
+
+var grokReference = regexp.MustCompile(`%{(\w+)(?::([\w.@\[\]-]+))?(?::(\w+))?}`)
+
+func newGrok(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field              string            `config:"field" validate:"required"`
+		Patterns           []string          `config:"patterns" validate:"required"`
+		PatternDefinitions map[string]string `config:"pattern_definitions"`
+		IgnoreMissing      bool              `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]string, len(grokPatterns)+len(config.PatternDefinitions))
+	for name, definition := range grokPatterns {
+		definitions[name] = definition
+	}
+	for name, definition := range config.PatternDefinitions {
+		definitions[name] = definition
+	}
+
+	p := &grok{field: config.Field, ignoreMissing: config.IgnoreMissing}
+	for _, expression := range config.Patterns {
+		pattern, err := compileGrok(expression, definitions)
+		if err != nil {
+			return nil, err
+		}
+		p.patterns = append(p.patterns, pattern)
+	}
+	return p, nil
+}
+
+// compileGrok expands the %{NAME:field:type} references of the expression and
+// compiles the result.
+func compileGrok(expression string, definitions map[string]string) (*grokPattern, error) {
+	p := &grokPattern{captures: map[string]grokCapture{}}
+
+	expanded, err := p.expand(expression, definitions, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	p.regexp, err = regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grok expression [%s]: %v", expression, err)
+	}
+	return p, nil
+}
+
+func (p *grokPattern) expand(expression string, definitions map[string]string, parents []string) (string, error) {
+	var err error
+	expanded := grokReference.ReplaceAllStringFunc(expression, func(reference string) string {
+		if err != nil {
+			return ""
+		}
+
+		match := grokReference.FindStringSubmatch(reference)
+		name, field, typ := match[1], match[2], match[3]
+
+		for _, parent := range parents {
+			if parent == name {
+				err = fmt.Errorf("circular reference in grok pattern [%s]", name)
+				return ""
+			}
+		}
+
+		definition, found := definitions[name]
+		if !found {
+			err = fmt.Errorf("unable to find pattern [%s] in grok's pattern dictionary", name)
+			return ""
+		}
+
+		var inner string
+		inner, err = p.expand(definition, definitions, append(parents, name))
+		if err != nil {
+			return ""
+		}
+
+		if field == "" {
+			return "(?:" + inner + ")"
+		}
+
+		group := fmt.Sprintf("g%d", len(p.captures))
+		p.captures[group] = grokCapture{field: field, typ: typ}
+		return "(?P<" + group + ">" + inner + ")"
+	})
+	return expanded, err
+}
+
+// match returns the captured fields if the value matches the pattern
+func (p *grokPattern) match(value string) (map[string]interface{}, bool, error) {
+	indices := p.regexp.FindStringSubmatchIndex(value)
+	if indices == nil {
+		return nil, false, nil
+	}
+
+	fields := map[string]interface{}{}
+	for i, group := range p.regexp.SubexpNames() {
+		capture, found := p.captures[group]
+		if !found || indices[2*i] < 0 {
+			continue
+		}
+
+		// The same field can be captured by alternative parts of the expression
+		if _, exists := fields[capture.field]; exists {
+			continue
+		}
+
+		s := value[indices[2*i]:indices[2*i+1]]
+		v, err := convertCapture(s, capture.typ)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to convert field [%s]: %v", capture.field, err)
+		}
+		fields[capture.field] = v
+	}
+	return fields, true, nil
+}
+
+func convertCapture(s string, typ string) (interface{}, error) {
+	switch typ {
+	case "int":
+		return strconv.ParseInt(s, 10, 64)
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	default:
+		return s, nil
+	}
+}
+
+func (p *grok) Run(event common.MapStr) error {
+	value, err := event.GetValue(p.field)
+	if err != nil || value == nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", p.field, p.field)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("field [%s] of type [%T] cannot be cast to [string]", p.field, value)
+	}
+
+	for _, pattern := range p.patterns {
+		fields, matched, err := pattern.match(s)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		for field, v := range fields {
+			if _, err := event.Put(field, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Provided Grok expressions do not match field value: [%s]", s)
+}
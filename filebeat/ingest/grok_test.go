@@ -0,0 +1,79 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestGrok(t *testing.T) {
+	s := newTestStep(t, "grok", map[string]interface{}{
+		"field": "message",
+		"patterns": []interface{}{
+			`^%{IP:client} \[%{HTTPDATE:time}\] %{WORD:method} %{NUMBER:bytes:int}( %{GREEDYDATA:rest})?$`,
+			`%{LEVEL:level}: %{GREEDYDATA:msg}`,
+		},
+		"pattern_definitions": map[string]interface{}{
+			"LEVEL": "(?:INFO|WARN|ERROR)",
+		},
+	})
+
+	event := common.MapStr{"message": "127.0.0.1 [07/Dec/2016:11:05:07 +0100] GET 512"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "127.0.0.1", event["client"])
+	assert.Equal(t, "07/Dec/2016:11:05:07 +0100", event["time"])
+	assert.Equal(t, "GET", event["method"])
+	assert.Equal(t, int64(512), event["bytes"])
+	assert.NotContains(t, event, "rest")
+
+	event = common.MapStr{"message": "WARN: disk full"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "WARN", event["level"])
+	assert.Equal(t, "disk full", event["msg"])
+
+	err := s.run(common.MapStr{"message": "no match"})
+	if assert.Error(t, err) {
+		assert.Equal(t, "Provided Grok expressions do not match field value: [no match]", err.Error())
+	}
+	assert.Error(t, s.run(common.MapStr{}))
+}
+
+func TestGrokNestedFields(t *testing.T) {
+	s := newTestStep(t, "grok", map[string]interface{}{
+		"field":          "message",
+		"patterns":       []interface{}{`%{SYSLOGTIMESTAMP:log.timestamp} %{SYSLOGHOST:log.host} %{SYSLOGPROG}: %{GREEDYDATA:log.message}`},
+		"ignore_missing": true,
+	})
+
+	event := common.MapStr{"message": "Feb  9 21:19:40 precise32 sshd[8317]: session opened"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, common.MapStr{
+		"timestamp": "Feb  9 21:19:40",
+		"host":      "precise32",
+		"message":   "session opened",
+	}, event["log"])
+
+	assert.NoError(t, s.run(common.MapStr{}))
+}
+
+func TestGrokInvalidPatterns(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"patterns": []interface{}{"%{UNKNOWN:a}"}},
+		{"patterns": []interface{}{"%{A}"}, "pattern_definitions": map[string]interface{}{"A": "%{B}", "B": "%{A}"}},
+		{"patterns": []interface{}{"(unclosed"}},
+	}
+
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, options := range tests {
+		options["field"] = "message"
+		_, err := newStep("grok", options, r)
+		assert.Error(t, err, "%v", options)
+	}
+}
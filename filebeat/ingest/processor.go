@@ -0,0 +1,234 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// processor is a single step of an ingest pipeline
+type processor interface {
+	Run(event common.MapStr) error
+}
+
+// constructor creates a processor from its options. The runner provides shared
+// resources like the GeoIP database.
+type constructor func(config *common.Config, r *Runner) (processor, error)
+
+// errDisabled is returned by constructors if the processor cannot run with the
+// current configuration. Disabled processors are skipped.
+var errDisabled = errors.New("processor is disabled")
+
+var processorTypes = map[string]constructor{}
+
+func registerProcessor(name string, c constructor) {
+	if _, exists := processorTypes[name]; exists {
+		panic(fmt.Sprintf("ingest processor %s is already registered", name))
+	}
+	processorTypes[name] = c
+}
+
+// ingestKey is the field holding the ingest metadata, like the failure message
+// in on_failure handlers
+const ingestKey = "_ingest"
+
+// step wraps a processor with the options supported by all processor types
+type step struct {
+	typ           string
+	tag           string
+	processor     processor
+	ignoreFailure bool
+	onFailure     []*step
+}
+
+type stepConfig struct {
+	Tag           string `config:"tag"`
+	IgnoreFailure bool   `config:"ignore_failure"`
+}
+
+// failure is the error of a processor
+type failure struct {
+	typ string
+	tag string
+	err error
+}
+
+func (f *failure) Error() string { return f.err.Error() }
+
+func (s *step) run(event common.MapStr) error {
+	err := s.processor.Run(event)
+	if err == nil || s.ignoreFailure {
+		return nil
+	}
+
+	f, ok := err.(*failure)
+	if !ok {
+		f = &failure{typ: s.typ, tag: s.tag, err: err}
+	}
+	if len(s.onFailure) > 0 {
+		return runFailureHandlers(s.onFailure, event, f)
+	}
+	return f
+}
+
+func runSteps(steps []*step, event common.MapStr) error {
+	for _, s := range steps {
+		if err := s.run(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runFailureHandlers runs the on_failure processors. The details of the failure
+// are available in the _ingest metadata while the handlers run.
+func runFailureHandlers(handlers []*step, event common.MapStr, f *failure) error {
+	previous, found := event[ingestKey]
+	event[ingestKey] = common.MapStr{
+		"on_failure_message":        f.Error(),
+		"on_failure_processor_type": f.typ,
+		"on_failure_processor_tag":  f.tag,
+	}
+	defer func() {
+		if found {
+			event[ingestKey] = previous
+		} else {
+			delete(event, ingestKey)
+		}
+	}()
+
+	return runSteps(handlers, event)
+}
+
+// newSteps creates the processors defined in the list of a pipeline or an
+// on_failure handler. Unsupported and disabled processors are skipped.
+func newSteps(list []interface{}, r *Runner) ([]*step, error) {
+	var steps []*step
+	for _, item := range list {
+		definition, ok := item.(map[string]interface{})
+		if !ok || len(definition) != 1 {
+			return nil, fmt.Errorf("processor must be an object with a single key, got %v", item)
+		}
+
+		for typ, value := range definition {
+			options, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("options of processor %s must be an object", typ)
+			}
+
+			s, err := newStep(typ, options, r)
+			if err == errDisabled {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error creating processor %s: %v", typ, err)
+			}
+			steps = append(steps, s)
+		}
+	}
+	return steps, nil
+}
+
+func newStep(typ string, options map[string]interface{}, r *Runner) (*step, error) {
+	c, found := processorTypes[typ]
+	if !found {
+		r.warnOnce("processor_"+typ, "The ingest processor %s is not supported in Filebeat and is skipped.", typ)
+		return nil, errDisabled
+	}
+
+	var onFailure []interface{}
+	if value, found := options["on_failure"]; found {
+		onFailure, _ = value.([]interface{})
+		options = copyWithout(options, "on_failure")
+	}
+
+	config, err := common.NewConfigFrom(options)
+	if err != nil {
+		return nil, err
+	}
+
+	stepConfig := stepConfig{}
+	if err := config.Unpack(&stepConfig); err != nil {
+		return nil, err
+	}
+
+	p, err := c(config, r)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &step{
+		typ:           typ,
+		tag:           stepConfig.Tag,
+		processor:     p,
+		ignoreFailure: stepConfig.IgnoreFailure,
+	}
+	if len(onFailure) > 0 {
+		s.onFailure, err = newSteps(onFailure, r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func copyWithout(m map[string]interface{}, key string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// getField returns the value of the field. The error mentions the field if it
+// does not exist.
+func getField(event common.MapStr, field string) (interface{}, error) {
+	value, err := event.GetValue(field)
+	if err != nil {
+		return nil, fmt.Errorf("field [%s] not present as part of path [%s]", field, field)
+	}
+	return value, nil
+}
+
+// getStringField returns the value of a field which must be a string
+func getStringField(event common.MapStr, field string) (string, error) {
+	value, err := getField(event, field)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field [%s] of type [%T] cannot be cast to [string]", field, value)
+	}
+	return s, nil
+}
+
+func hasField(event common.MapStr, field string) bool {
+	found, err := event.HasKey(field)
+	return err == nil && found
+}
+
+var templateVar = regexp.MustCompile(`{{\s*([^}\s]+)\s*}}`)
+
+// renderTemplate replaces the {{ field }} references in value with the values
+// of the fields. Missing fields are replaced by an empty string.
+func renderTemplate(value string, event common.MapStr) string {
+	if !strings.Contains(value, "{{") {
+		return value
+	}
+
+	return templateVar.ReplaceAllStringFunc(value, func(match string) string {
+		field := templateVar.FindStringSubmatch(match)[1]
+		v, err := event.GetValue(field)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprint(v)
+	})
+}
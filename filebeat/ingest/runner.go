@@ -0,0 +1,113 @@
+package ingest
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nranchev/go-libGeoIP"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// Runner executes ingest pipelines in the beat. Pipelines are defined in the
+// format of the Elasticsearch Ingest Node. Processors which are not supported
+// are skipped.
+type Runner struct {
+	config    Config
+	geoip     *libgeo.GeoIP
+	pipelines map[string]*pipeline
+
+	warnedMutex sync.Mutex
+	warned      map[string]bool
+}
+
+type pipeline struct {
+	id         string
+	processors []*step
+	onFailure  []*step
+}
+
+// NewRunner creates a runner without any pipelines
+func NewRunner(config Config) (*Runner, error) {
+	r := &Runner{
+		config:    config,
+		pipelines: map[string]*pipeline{},
+		warned:    map[string]bool{},
+	}
+
+	if path := config.GeoIP.DatabaseFile; path != "" {
+		db, err := libgeo.Load(path)
+		if err != nil {
+			return nil, fmt.Errorf("error loading GeoIP database %s: %v", path, err)
+		}
+		r.geoip = db
+		logp.Info("Loaded GeoIP database for ingest pipelines from %s", path)
+	}
+
+	return r, nil
+}
+
+// AddPipeline creates the pipeline from the definition used by Elasticsearch
+func (r *Runner) AddPipeline(id string, content map[string]interface{}) error {
+	processors, _ := content["processors"].([]interface{})
+	onFailure, _ := content["on_failure"].([]interface{})
+
+	p := &pipeline{id: id}
+
+	var err error
+	p.processors, err = newSteps(processors, r)
+	if err != nil {
+		return fmt.Errorf("error loading pipeline %s: %v", id, err)
+	}
+	p.onFailure, err = newSteps(onFailure, r)
+	if err != nil {
+		return fmt.Errorf("error loading pipeline %s: %v", id, err)
+	}
+
+	r.pipelines[id] = p
+	logp.Debug("ingest", "Loaded pipeline %s with %d processors", id, len(p.processors))
+	return nil
+}
+
+// Has returns true if the runner executes the pipeline with the given id
+func (r *Runner) Has(id string) bool {
+	if r == nil || id == "" {
+		return false
+	}
+	_, found := r.pipelines[id]
+	return found
+}
+
+// Run executes the pipeline on the event. The event is modified in place. In
+// case a processor fails and the pipeline has no on_failure handlers, the
+// event is kept as processed up to the failure and the error is returned.
+func (r *Runner) Run(id string, event common.MapStr) error {
+	p, found := r.pipelines[id]
+	if !found {
+		return fmt.Errorf("unknown pipeline %s", id)
+	}
+
+	err := runSteps(p.processors, event)
+	if err == nil {
+		return nil
+	}
+
+	f := err.(*failure)
+	if len(p.onFailure) == 0 {
+		return fmt.Errorf("processor %s of pipeline %s failed: %v", f.typ, id, f.err)
+	}
+	return runFailureHandlers(p.onFailure, event, f)
+}
+
+// warnOnce logs a warning only the first time it is reported for key
+func (r *Runner) warnOnce(key string, format string, v ...interface{}) {
+	r.warnedMutex.Lock()
+	defer r.warnedMutex.Unlock()
+
+	if r.warned[key] {
+		return
+	}
+	r.warned[key] = true
+	logp.Warn(format, v...)
+}
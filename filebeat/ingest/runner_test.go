@@ -0,0 +1,180 @@
+// +build !integration
+
+package ingest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// newTestStep creates a processor of the given type with a runner without any
+// GeoIP database
+func newTestStep(t *testing.T, typ string, options map[string]interface{}) *step {
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := newStep(typ, options, r)
+	if err != nil {
+		t.Fatalf("error creating processor %s: %v", typ, err)
+	}
+	return s
+}
+
+func TestRunnerPipeline(t *testing.T) {
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.AddPipeline("test", map[string]interface{}{
+		"processors": []interface{}{
+			map[string]interface{}{"rename": map[string]interface{}{"field": "a", "target_field": "b"}},
+			map[string]interface{}{"script": map[string]interface{}{"inline": "ctx.c = 1"}},
+			map[string]interface{}{"convert": map[string]interface{}{"field": "b", "type": "integer"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, r.Has("test"))
+	assert.False(t, r.Has("other"))
+	assert.False(t, r.Has(""))
+
+	// Unsupported processors are skipped
+	event := common.MapStr{"a": "1"}
+	assert.NoError(t, r.Run("test", event))
+	assert.Equal(t, common.MapStr{"b": int64(1)}, event)
+
+	event = common.MapStr{"a": "x"}
+	assert.Error(t, r.Run("test", event))
+
+	var nilRunner *Runner
+	assert.False(t, nilRunner.Has("test"))
+}
+
+func TestRunnerOnFailure(t *testing.T) {
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.AddPipeline("test", map[string]interface{}{
+		"processors": []interface{}{
+			map[string]interface{}{"remove": map[string]interface{}{
+				"field": "missing",
+				"tag":   "remove-missing",
+				"on_failure": []interface{}{
+					map[string]interface{}{"set": map[string]interface{}{
+						"field": "failed_tag",
+						"value": "{{ _ingest.on_failure_processor_tag }}",
+					}},
+				},
+			}},
+			map[string]interface{}{"rename": map[string]interface{}{"field": "missing", "target_field": "b"}},
+		},
+		"on_failure": []interface{}{
+			map[string]interface{}{"set": map[string]interface{}{
+				"field": "error",
+				"value": "{{ _ingest.on_failure_message }}",
+			}},
+		},
+	})
+	assert.NoError(t, err)
+
+	event := common.MapStr{}
+	assert.NoError(t, r.Run("test", event))
+	assert.Equal(t, common.MapStr{
+		"failed_tag": "remove-missing",
+		"error":      "field [missing] doesn't exist",
+	}, event)
+}
+
+func TestRunnerIgnoreFailure(t *testing.T) {
+	s := newTestStep(t, "remove", map[string]interface{}{"field": "missing", "ignore_failure": true})
+	assert.NoError(t, s.run(common.MapStr{}))
+}
+
+func TestRunnerGeoIPDisabled(t *testing.T) {
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = newStep("geoip", map[string]interface{}{"field": "ip"}, r)
+	assert.Equal(t, errDisabled, err)
+}
+
+// TestModulePipelines loads all pipelines of the filebeat modules
+func TestModulePipelines(t *testing.T) {
+	paths, err := filepath.Glob("../module/*/*/ingest/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, paths)
+
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content map[string]interface{}
+		err = json.NewDecoder(f).Decode(&content)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NoError(t, r.AddPipeline(path, content), path)
+	}
+}
+
+func TestModulePipelineNginx(t *testing.T) {
+	f, err := os.Open("../module/nginx/access/ingest/default.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var content map[string]interface{}
+	if err := json.NewDecoder(f).Decode(&content); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRunner(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, r.AddPipeline("nginx", content))
+
+	event := common.MapStr{
+		"@timestamp": common.MustParseTime("2017-01-01T00:00:00.000Z"),
+		"message": `10.0.0.2, 10.0.0.1, 127.0.0.1 - - [07/Dec/2016:11:05:07 +0100] "GET /ocelot HTTP/1.1" 200 571 "-" ` +
+			`"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.12; rv:49.0) Gecko/20100101 Firefox/49.0"`,
+	}
+	assert.NoError(t, r.Run("nginx", event))
+
+	assert.Equal(t, common.MustParseTime("2016-12-07T10:05:07.000Z"), event["@timestamp"])
+	assert.Equal(t, common.MustParseTime("2017-01-01T00:00:00.000Z"), event["read_timestamp"])
+	assert.NotContains(t, event, "message")
+
+	access, err := event.GetValue("nginx.access")
+	if assert.NoError(t, err) {
+		fields := access.(common.MapStr)
+		assert.Equal(t, "GET", fields["method"])
+		assert.Equal(t, "/ocelot", fields["url"])
+		assert.Equal(t, "200", fields["response_code"])
+		assert.Equal(t, []interface{}{"10.0.0.2", "10.0.0.1", "127.0.0.1"}, fields["remote_ip_list"])
+		assert.Equal(t, "Firefox", fields["user_agent"].(common.MapStr)["name"])
+	}
+}
@@ -0,0 +1,189 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("split", newSplit)
+	registerProcessor("gsub", newGsub)
+	registerProcessor("kv", newKV)
+}
+
+// split splits a string field into an array using a regular expression
+type split struct {
+	field         string
+	target        string
+	separator     *regexp.Regexp
+	ignoreMissing bool
+}
+
+func newSplit(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Target        string `config:"target_field"`
+		Separator     string `config:"separator" validate:"required"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	separator, err := regexp.Compile(config.Separator)
+	if err != nil {
+		return nil, fmt.Errorf("invalid separator: %v", err)
+	}
+
+	target := config.Target
+	if target == "" {
+		target = config.Field
+	}
+	return &split{config.Field, target, separator, config.IgnoreMissing}, nil
+}
+
+func (p *split) Run(event common.MapStr) error {
+	if p.ignoreMissing && !hasField(event, p.field) {
+		return nil
+	}
+
+	value, err := getStringField(event, p.field)
+	if err != nil {
+		return err
+	}
+
+	parts := p.separator.Split(value, -1)
+	result := make([]interface{}, len(parts))
+	for i, part := range parts {
+		result[i] = part
+	}
+
+	_, err = event.Put(p.target, result)
+	return err
+}
+
+// gsub replaces all matches of a regular expression in a string field
+type gsub struct {
+	field         string
+	pattern       *regexp.Regexp
+	replacement   string
+	ignoreMissing bool
+}
+
+func newGsub(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Pattern       string `config:"pattern" validate:"required"`
+		Replacement   string `config:"replacement"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	return &gsub{config.Field, pattern, config.Replacement, config.IgnoreMissing}, nil
+}
+
+func (p *gsub) Run(event common.MapStr) error {
+	if p.ignoreMissing && !hasField(event, p.field) {
+		return nil
+	}
+
+	value, err := getStringField(event, p.field)
+	if err != nil {
+		return err
+	}
+
+	_, err = event.Put(p.field, p.pattern.ReplaceAllString(value, p.replacement))
+	return err
+}
+
+// kv splits a string field into key value pairs. Values of keys found multiple
+// times are collected in an array.
+type kv struct {
+	field         string
+	target        string
+	fieldSplit    *regexp.Regexp
+	valueSplit    *regexp.Regexp
+	includeKeys   map[string]bool
+	ignoreMissing bool
+}
+
+func newKV(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string   `config:"field" validate:"required"`
+		Target        string   `config:"target_field"`
+		FieldSplit    string   `config:"field_split" validate:"required"`
+		ValueSplit    string   `config:"value_split" validate:"required"`
+		IncludeKeys   []string `config:"include_keys"`
+		IgnoreMissing bool     `config:"ignore_missing"`
+	}{}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	fieldSplit, err := regexp.Compile(config.FieldSplit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field_split: %v", err)
+	}
+	valueSplit, err := regexp.Compile(config.ValueSplit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value_split: %v", err)
+	}
+
+	var includeKeys map[string]bool
+	if len(config.IncludeKeys) > 0 {
+		includeKeys = map[string]bool{}
+		for _, key := range config.IncludeKeys {
+			includeKeys[key] = true
+		}
+	}
+
+	return &kv{config.Field, config.Target, fieldSplit, valueSplit, includeKeys, config.IgnoreMissing}, nil
+}
+
+func (p *kv) Run(event common.MapStr) error {
+	if p.ignoreMissing && !hasField(event, p.field) {
+		return nil
+	}
+
+	value, err := getStringField(event, p.field)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range p.fieldSplit.Split(strings.TrimSpace(value), -1) {
+		parts := p.valueSplit.Split(pair, 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("field [%s] does not contain value_split [%s]", p.field, p.valueSplit)
+		}
+
+		key, value := parts[0], parts[1]
+		if p.includeKeys != nil && !p.includeKeys[key] {
+			continue
+		}
+		if p.target != "" {
+			key = p.target + "." + key
+		}
+
+		if old, err := event.GetValue(key); err == nil {
+			if list, ok := old.([]interface{}); ok {
+				event.Put(key, append(list, value))
+			} else {
+				event.Put(key, []interface{}{old, value})
+			}
+			continue
+		}
+		if _, err := event.Put(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestSplit(t *testing.T) {
+	s := newTestStep(t, "split", map[string]interface{}{"field": "ips", "separator": `"?,?\s+`})
+
+	event := common.MapStr{"ips": "10.0.0.2, 10.0.0.1, 127.0.0.1"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, []interface{}{"10.0.0.2", "10.0.0.1", "127.0.0.1"}, event["ips"])
+
+	assert.Error(t, s.run(common.MapStr{}))
+	assert.Error(t, s.run(common.MapStr{"ips": 1}))
+}
+
+func TestGsub(t *testing.T) {
+	s := newTestStep(t, "gsub", map[string]interface{}{"field": "query", "pattern": `\n# Time: .*$`, "replacement": ""})
+
+	event := common.MapStr{"query": "select 1;\n# Time: 161209 13:08:33"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "select 1;", event["query"])
+
+	s = newTestStep(t, "gsub", map[string]interface{}{"field": "query", "pattern": "a", "ignore_missing": true})
+	assert.NoError(t, s.run(common.MapStr{}))
+}
+
+func TestKV(t *testing.T) {
+	s := newTestStep(t, "kv", map[string]interface{}{
+		"field":        "kv",
+		"target_field": "log",
+		"field_split":  `\s+`,
+		"value_split":  "=",
+	})
+
+	event := common.MapStr{"kv": "pid=251 uid=0 a=1 a=2 a=3 msg=x=y"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, common.MapStr{
+		"pid": "251",
+		"uid": "0",
+		"a":   []interface{}{"1", "2", "3"},
+		"msg": "x=y",
+	}, event["log"])
+
+	assert.Error(t, s.run(common.MapStr{"kv": "a=1 b"}))
+
+	s = newTestStep(t, "kv", map[string]interface{}{
+		"field":        "kv",
+		"field_split":  " ",
+		"value_split":  ":",
+		"include_keys": []interface{}{"a"},
+	})
+	event = common.MapStr{"kv": "a:1 b:2"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "1", event["a"])
+	assert.NotContains(t, event, "b")
+}
@@ -0,0 +1,165 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func init() {
+	registerProcessor("user_agent", newUserAgent)
+}
+
+// userAgent extracts the browser, operating system and device from a user agent
+// string. It recognizes the common browsers and systems, other agents are
+// reported with the name Other.
+type userAgent struct {
+	field         string
+	target        string
+	ignoreMissing bool
+}
+
+// agentPattern matches a browser or operating system. If name is empty the
+// first group of the expression is the name. The remaining groups are the
+// major, minor and patch version.
+type agentPattern struct {
+	re   *regexp.Regexp
+	name string
+}
+
+func agent(expr, name string) agentPattern {
+	return agentPattern{regexp.MustCompile(expr), name}
+}
+
+var (
+	spiderPattern = regexp.MustCompile(`(?i)bot\b|spider|crawler|slurp|facebookexternalhit`)
+
+	browserPatterns = []agentPattern{
+		agent(`(Googlebot|bingbot|YandexBot|Baiduspider|DuckDuckBot)/(\d+)(?:\.(\d+))?`, ""),
+		agent(`Edge/(\d+)\.(\d+)`, "Edge"),
+		agent(`OPR/(\d+)\.(\d+)\.(\d+)`, "Opera"),
+		agent(`CriOS/(\d+)\.(\d+)\.(\d+)`, "Chrome Mobile iOS"),
+		agent(`Chrome/(\d+)\.(\d+)\.(\d+).* Mobile`, "Chrome Mobile"),
+		agent(`(Chromium|Chrome)/(\d+)\.(\d+)\.(\d+)`, ""),
+		agent(`FxiOS/(\d+)\.(\d+)`, "Firefox iOS"),
+		agent(`Firefox/(\d+)\.(\d+)(?:\.(\d+))?`, "Firefox"),
+		agent(`MSIE (\d+)\.(\d+)`, "IE"),
+		agent(`Trident/\d+\.\d+.*rv:(\d+)\.(\d+)`, "IE"),
+		agent(`Version/(\d+)\.(\d+)(?:\.(\d+))?.* Mobile/.*Safari/`, "Mobile Safari"),
+		agent(`Version/(\d+)\.(\d+)(?:\.(\d+))?.*Safari/`, "Safari"),
+		agent(`(curl|Wget|python-requests|Go-http-client|Apache-HttpClient|okhttp)/(\d+)(?:\.(\d+))?(?:\.(\d+))?`, ""),
+	}
+
+	osPatterns = []agentPattern{
+		agent(`Windows NT 10\.0`, "Windows 10"),
+		agent(`Windows NT 6\.3`, "Windows 8.1"),
+		agent(`Windows NT 6\.2`, "Windows 8"),
+		agent(`Windows NT 6\.1`, "Windows 7"),
+		agent(`Windows NT 6\.0`, "Windows Vista"),
+		agent(`Windows NT 5\.[12]`, "Windows XP"),
+		agent(`(?:iPhone|CPU) OS (\d+)_(\d+)(?:_(\d+))?`, "iOS"),
+		agent(`Android[ -](\d+)(?:\.(\d+))?(?:\.(\d+))?`, "Android"),
+		agent(`Mac OS X (\d+)[_.](\d+)(?:[_.](\d+))?`, "Mac OS X"),
+		agent(`CrOS`, "Chrome OS"),
+		agent(`(Ubuntu|Fedora|Debian)`, ""),
+		agent(`Linux`, "Linux"),
+	}
+
+	devicePattern = regexp.MustCompile(`iPhone|iPad|iPod`)
+)
+
+func newUserAgent(c *common.Config, r *Runner) (processor, error) {
+	config := struct {
+		Field         string `config:"field" validate:"required"`
+		Target        string `config:"target_field"`
+		IgnoreMissing bool   `config:"ignore_missing"`
+	}{
+		Target: "user_agent",
+	}
+	if err := c.Unpack(&config); err != nil {
+		return nil, err
+	}
+	return &userAgent{config.Field, config.Target, config.IgnoreMissing}, nil
+}
+
+func (p *userAgent) Run(event common.MapStr) error {
+	value, err := event.GetValue(p.field)
+	if err != nil || value == nil {
+		if p.ignoreMissing {
+			return nil
+		}
+		return fmt.Errorf("field [%s] not present as part of path [%s]", p.field, p.field)
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("field [%s] of type [%T] cannot be cast to [string]", p.field, value)
+	}
+
+	_, err = event.Put(p.target, parseUserAgent(s))
+	return err
+}
+
+func parseUserAgent(s string) common.MapStr {
+	ua := common.MapStr{"name": "Other"}
+	if name, versions := matchAgent(browserPatterns, s); name != "" {
+		ua["name"] = name
+		addVersions(ua, "", versions, "major", "minor", "patch")
+	}
+
+	if name, versions := matchAgent(osPatterns, s); name != "" {
+		ua["os_name"] = name
+		addVersions(ua, "os_", versions, "major", "minor")
+		ua["os"] = name
+		if len(versions) > 0 {
+			ua["os"] = name + " " + strings.Join(versions, ".")
+		}
+	} else {
+		ua["os"] = "Other"
+	}
+
+	switch {
+	case spiderPattern.MatchString(s):
+		ua["device"] = "Spider"
+	case devicePattern.MatchString(s):
+		ua["device"] = devicePattern.FindString(s)
+	default:
+		ua["device"] = "Other"
+	}
+	return ua
+}
+
+// matchAgent returns the name and the versions of the first matching pattern
+func matchAgent(patterns []agentPattern, s string) (string, []string) {
+	for _, pattern := range patterns {
+		match := pattern.re.FindStringSubmatch(s)
+		if match == nil {
+			continue
+		}
+
+		name, groups := pattern.name, match[1:]
+		if name == "" {
+			name, groups = match[1], match[2:]
+		}
+
+		var versions []string
+		for _, v := range groups {
+			if v == "" {
+				break
+			}
+			versions = append(versions, v)
+		}
+		return name, versions
+	}
+	return "", nil
+}
+
+func addVersions(ua common.MapStr, prefix string, versions []string, keys ...string) {
+	for i, v := range versions {
+		if i < len(keys) {
+			ua[prefix+keys[i]] = v
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// +build !integration
+
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		agent    string
+		expected common.MapStr
+	}{
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_12_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/54.0.2840.59 Safari/537.36",
+			common.MapStr{"name": "Chrome", "major": "54", "minor": "0", "patch": "2840",
+				"os": "Mac OS X 10.12.0", "os_name": "Mac OS X", "os_major": "10", "os_minor": "12", "device": "Other"},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 6.1; rv:15.0) Gecko/20120716 Firefox/15.0a2",
+			common.MapStr{"name": "Firefox", "major": "15", "minor": "0",
+				"os": "Windows 7", "os_name": "Windows 7", "device": "Other"},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 10_2 like Mac OS X) AppleWebKit/602.3.12 (KHTML, like Gecko) Version/10.0 Mobile/14C92 Safari/602.1",
+			common.MapStr{"name": "Mobile Safari", "major": "10", "minor": "0",
+				"os": "iOS 10.2", "os_name": "iOS", "os_major": "10", "os_minor": "2", "device": "iPhone"},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			common.MapStr{"name": "Googlebot", "major": "2", "minor": "1", "os": "Other", "device": "Spider"},
+		},
+		{
+			"Wget/1.13.4 (linux-gnu)",
+			common.MapStr{"name": "Wget", "major": "1", "minor": "13", "patch": "4", "os": "Other", "device": "Other"},
+		},
+		{
+			"unknown",
+			common.MapStr{"name": "Other", "os": "Other", "device": "Other"},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, parseUserAgent(test.agent), test.agent)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	s := newTestStep(t, "user_agent", map[string]interface{}{"field": "agent", "target_field": "ua"})
+
+	event := common.MapStr{"agent": "curl/7.54.0"}
+	assert.NoError(t, s.run(event))
+	assert.Equal(t, "curl", event["ua"].(common.MapStr)["name"])
+
+	assert.Error(t, s.run(common.MapStr{}))
+}
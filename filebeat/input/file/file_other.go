@@ -3,6 +3,7 @@
 package file
 
 import (
+	"fmt"
 	"os"
 	"syscall"
 
@@ -33,6 +34,11 @@ func (fs StateOS) IsSame(state StateOS) bool {
 	return fs.Inode == state.Inode && fs.Device == state.Device
 }
 
+// String returns a string representation of the file identifiers
+func (fs StateOS) String() string {
+	return fmt.Sprintf("%d-%d", fs.Inode, fs.Device)
+}
+
 // SafeFileRotate safely rotates an existing file under path and replaces it with the tempfile
 func SafeFileRotate(path, tempfile string) error {
 	if e := os.Rename(tempfile, path); e != nil {
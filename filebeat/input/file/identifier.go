@@ -0,0 +1,108 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Names of the supported file identities
+const (
+	NativeIdentity      = "native"
+	PathIdentity        = "path"
+	FingerprintIdentity = "fingerprint"
+)
+
+// ErrFileTooSmall is returned if a file is too small to generate its fingerprint
+var ErrFileTooSmall = errors.New("file is too small to be fingerprinted")
+
+// StateIdentifier generates the IDs used to find the previous state of a file.
+// The IDs are prefixed by the name of the identity, so it can be detected which
+// identity was used to create the ID of a stored state.
+type StateIdentifier interface {
+	// ID returns the ID of the file of the given state
+	ID(state State) (string, error)
+	Name() string
+}
+
+// NewStateIdentifier creates the identifier with the given name. The fingerprint
+// is based on the first fingerprintLength bytes of a file. If no name is given,
+// the native identity is used.
+func NewStateIdentifier(name string, fingerprintLength int) (StateIdentifier, error) {
+	switch name {
+	case NativeIdentity, "":
+		return nativeIdentifier{}, nil
+	case PathIdentity:
+		return pathIdentifier{}, nil
+	case FingerprintIdentity:
+		return fingerprintIdentifier{length: fingerprintLength}, nil
+	default:
+		return nil, fmt.Errorf("unknown file identity: %s", name)
+	}
+}
+
+// HasIdentity returns true if the ID of the state was generated by the given identifier
+func HasIdentity(state State, identifier StateIdentifier) bool {
+	return strings.HasPrefix(state.ID, identifier.Name()+"::")
+}
+
+// nativeIdentifier identifies files by the identifiers of the operating system,
+// for example inode and device
+type nativeIdentifier struct{}
+
+func (nativeIdentifier) Name() string { return NativeIdentity }
+
+func (i nativeIdentifier) ID(state State) (string, error) {
+	return i.Name() + "::" + state.FileStateOS.String(), nil
+}
+
+// pathIdentifier identifies files by their path. Rotated files are read again
+// under their new name.
+type pathIdentifier struct{}
+
+func (pathIdentifier) Name() string { return PathIdentity }
+
+func (i pathIdentifier) ID(state State) (string, error) {
+	return i.Name() + "::" + state.Source, nil
+}
+
+// fingerprintIdentifier identifies files by the hash of their first bytes
+type fingerprintIdentifier struct {
+	length int
+}
+
+func (fingerprintIdentifier) Name() string { return FingerprintIdentity }
+
+func (i fingerprintIdentifier) ID(state State) (string, error) {
+	f, err := ReadOpen(state.Source)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// Make sure the fingerprint is generated for the file the state was created for
+	if state.Fileinfo != nil {
+		info, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		if !os.SameFile(state.Fileinfo, info) {
+			return "", fmt.Errorf("file %s was replaced", state.Source)
+		}
+	}
+
+	hash := sha256.New()
+	_, err = io.CopyN(hash, f, int64(i.length))
+	if err == io.EOF {
+		return "", ErrFileTooSmall
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return i.Name() + "::" + hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,97 @@
+// +build !integration
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNativeIdentifier(t *testing.T) {
+	identifier, err := NewStateIdentifier(NativeIdentity, 0)
+	assert.NoError(t, err)
+
+	state := State{Source: "/var/log/test.log"}
+	id, err := identifier.ID(state)
+	assert.NoError(t, err)
+	assert.Equal(t, "native::"+state.FileStateOS.String(), id)
+
+	state.ID = id
+	assert.True(t, HasIdentity(state, identifier))
+}
+
+func TestPathIdentifier(t *testing.T) {
+	identifier, err := NewStateIdentifier(PathIdentity, 0)
+	assert.NoError(t, err)
+
+	id, err := identifier.ID(State{Source: "/var/log/test.log"})
+	assert.NoError(t, err)
+	assert.Equal(t, "path::/var/log/test.log", id)
+
+	assert.False(t, HasIdentity(State{ID: id}, nativeIdentifier{}))
+	assert.False(t, HasIdentity(State{}, identifier))
+}
+
+func TestFingerprintIdentifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "identifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	identifier, err := NewStateIdentifier(FingerprintIdentity, 10)
+	assert.NoError(t, err)
+
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+
+	// Files are only identified once they reach the fingerprint length
+	assert.NoError(t, ioutil.WriteFile(first, []byte("short"), 0644))
+	_, err = identifier.ID(State{Source: first})
+	assert.Equal(t, ErrFileTooSmall, err)
+
+	assert.NoError(t, ioutil.WriteFile(first, []byte("0123456789 first"), 0644))
+	assert.NoError(t, ioutil.WriteFile(second, []byte("0123456789 second"), 0644))
+
+	firstID, err := identifier.ID(State{Source: first})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(firstID, "fingerprint::"))
+
+	// Only the first bytes are part of the fingerprint
+	secondID, err := identifier.ID(State{Source: second})
+	assert.NoError(t, err)
+	assert.Equal(t, firstID, secondID)
+
+	assert.NoError(t, ioutil.WriteFile(second, []byte("9876543210 second"), 0644))
+	secondID, err = identifier.ID(State{Source: second})
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstID, secondID)
+
+	_, err = identifier.ID(State{Source: filepath.Join(dir, "missing.log")})
+	assert.Error(t, err)
+}
+
+func TestUnknownIdentifier(t *testing.T) {
+	_, err := NewStateIdentifier("inode", 0)
+	assert.Error(t, err)
+}
+
+func TestStateIsSame(t *testing.T) {
+	a := State{ID: "path::/var/log/a.log", FileStateOS: StateOS{}}
+	b := State{ID: "path::/var/log/b.log", FileStateOS: StateOS{}}
+
+	// The IDs are compared if both states have one
+	assert.False(t, a.IsSame(b))
+	assert.True(t, a.IsSame(State{ID: "path::/var/log/a.log"}))
+
+	// States without ID only match other states without ID
+	c := State{}
+	assert.False(t, a.IsSame(c))
+	assert.False(t, c.IsSame(a))
+	assert.True(t, c.IsSame(State{}))
+}
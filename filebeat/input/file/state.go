@@ -10,10 +10,12 @@ import (
 
 // State is used to communicate the reading state of a file
 type State struct {
+	ID          string      `json:"id,omitempty"` // identifier of the file, see StateIdentifier
 	Source      string      `json:"source"`
 	Offset      int64       `json:"offset"`
-	Finished    bool        `json:"-"` // harvester state
-	Fileinfo    os.FileInfo `json:"-"` // the file info
+	Finished    bool        `json:"-"`             // harvester state
+	EOF         bool        `json:"eof,omitempty"` // compressed file was read completely
+	Fileinfo    os.FileInfo `json:"-"`             // the file info
 	FileStateOS StateOS
 	Timestamp   time.Time     `json:"timestamp"`
 	TTL         time.Duration `json:"ttl"`
@@ -36,6 +38,18 @@ func (s *State) IsEmpty() bool {
 	return *s == State{}
 }
 
+// IsSame returns true if both states belong to the same file. The IDs are compared
+// if one of the states has an ID. States written before IDs were introduced and
+// which could not be migrated are compared based on the FileStateOS.
+func (s *State) IsSame(other State) bool {
+	if s.ID != "" || other.ID != "" {
+		return s.ID == other.ID
+	}
+
+	// This is using the FileStateOS for comparison as FileInfo identifiers can only be fetched for existing files
+	return s.FileStateOS.IsSame(other.FileStateOS)
+}
+
 // States handles list of FileState
 type States struct {
 	states []State
@@ -79,8 +93,7 @@ func (s *States) findPrevious(newState State) (int, State) {
 
 	// TODO: This could be made potentially more performance by using an index (harvester id) and only use iteration as fall back
 	for index, oldState := range s.states {
-		// This is using the FileStateOS for comparison as FileInfo identifiers can only be fetched for existing files
-		if oldState.FileStateOS.IsSame(newState.FileStateOS) {
+		if oldState.IsSame(newState) {
 			return index, oldState
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/elastic/beats/filebeat/beater"
+	"github.com/elastic/beats/filebeat/registrar"
 	"github.com/elastic/beats/libbeat/beat"
 )
 
@@ -19,6 +20,8 @@ var Name = "filebeat"
 // determine where in each file to restart a harvester.
 
 func main() {
+	registrar.RegisterCommands()
+
 	if err := beat.Run(Name, "", beater.New); err != nil {
 		os.Exit(1)
 	}
@@ -5,35 +5,56 @@ import (
 	"time"
 
 	cfg "github.com/elastic/beats/filebeat/config"
+	"github.com/elastic/beats/filebeat/input/file"
 	"github.com/elastic/beats/libbeat/common/match"
 )
 
+const (
+	pollScanMode   = "poll"
+	notifyScanMode = "notify"
+)
+
 var (
 	defaultConfig = prospectorConfig{
-		Enabled:        true,
-		IgnoreOlder:    0,
-		ScanFrequency:  10 * time.Second,
-		InputType:      cfg.DefaultInputType,
-		CleanInactive:  0,
-		CleanRemoved:   true,
-		HarvesterLimit: 0,
-		Symlinks:       false,
-		TailFiles:      false,
+		Enabled:           true,
+		IgnoreOlder:       0,
+		ScanFrequency:     10 * time.Second,
+		ScanMode:          pollScanMode,
+		FileIdentity:      file.NativeIdentity,
+		FingerprintLength: 1024,
+		InputType:         cfg.DefaultInputType,
+		CleanInactive:     0,
+		CleanRemoved:      true,
+		HarvesterLimit:    0,
+		Symlinks:          false,
+		TailFiles:         false,
+		Containers: containersConfig{
+			Path: "/var/lib/docker/containers",
+		},
 	}
 )
 
 type prospectorConfig struct {
-	Enabled        bool            `config:"enabled"`
-	ExcludeFiles   []match.Matcher `config:"exclude_files"`
-	IgnoreOlder    time.Duration   `config:"ignore_older"`
-	Paths          []string        `config:"paths"`
-	ScanFrequency  time.Duration   `config:"scan_frequency" validate:"min=0,nonzero"`
-	InputType      string          `config:"input_type"`
-	CleanInactive  time.Duration   `config:"clean_inactive" validate:"min=0"`
-	CleanRemoved   bool            `config:"clean_removed"`
-	HarvesterLimit uint64          `config:"harvester_limit" validate:"min=0"`
-	Symlinks       bool            `config:"symlinks"`
-	TailFiles      bool            `config:"tail_files"`
+	Enabled           bool             `config:"enabled"`
+	ExcludeFiles      []match.Matcher  `config:"exclude_files"`
+	IgnoreOlder       time.Duration    `config:"ignore_older"`
+	Paths             []string         `config:"paths"`
+	ScanFrequency     time.Duration    `config:"scan_frequency" validate:"min=0,nonzero"`
+	ScanMode          string           `config:"scan_mode"`
+	FileIdentity      string           `config:"file_identity"`
+	FingerprintLength int              `config:"fingerprint_length" validate:"min=1"`
+	InputType         string           `config:"input_type"`
+	CleanInactive     time.Duration    `config:"clean_inactive" validate:"min=0"`
+	CleanRemoved      bool             `config:"clean_removed"`
+	HarvesterLimit    uint64           `config:"harvester_limit" validate:"min=0"`
+	Symlinks          bool             `config:"symlinks"`
+	TailFiles         bool             `config:"tail_files"`
+	Containers        containersConfig `config:"containers"`
+}
+
+type containersConfig struct {
+	IDs  []string `config:"ids"`
+	Path string   `config:"path"`
 }
 
 func (config *prospectorConfig) Validate() error {
@@ -42,6 +63,24 @@ func (config *prospectorConfig) Validate() error {
 		return fmt.Errorf("No paths were defined for prospector")
 	}
 
+	if config.InputType == cfg.DockerInputType && len(config.Containers.IDs) == 0 {
+		return fmt.Errorf("No containers.ids were defined for prospector")
+	}
+
+	isFileInput := config.InputType == cfg.LogInputType || config.InputType == cfg.DockerInputType
+	if isFileInput && config.ScanMode != pollScanMode && config.ScanMode != notifyScanMode {
+		return fmt.Errorf("Invalid scan_mode: %v, must be one of %v or %v", config.ScanMode, pollScanMode, notifyScanMode)
+	}
+
+	if isFileInput {
+		switch config.FileIdentity {
+		case file.NativeIdentity, file.PathIdentity, file.FingerprintIdentity:
+		default:
+			return fmt.Errorf("Invalid file_identity: %v, must be one of %v, %v or %v",
+				config.FileIdentity, file.NativeIdentity, file.PathIdentity, file.FingerprintIdentity)
+		}
+	}
+
 	if config.CleanInactive != 0 && config.IgnoreOlder == 0 {
 		return fmt.Errorf("ignore_older must be enabled when clean_inactive is used.")
 	}
@@ -52,3 +52,24 @@ func TestCleanOlderIgnoreOlder(t *testing.T) {
 	err := config.Validate()
 	assert.NoError(t, err)
 }
+
+func TestDockerNoContainersError(t *testing.T) {
+
+	config := prospectorConfig{
+		InputType: "docker",
+	}
+
+	err := config.Validate()
+	assert.Error(t, err)
+}
+
+func TestInvalidScanMode(t *testing.T) {
+	config := defaultConfig
+	config.Paths = []string{"/var/log/*.log"}
+	config.ScanMode = "inotify"
+
+	assert.Error(t, config.Validate())
+
+	config.ScanMode = notifyScanMode
+	assert.NoError(t, config.Validate())
+}
@@ -0,0 +1,181 @@
+package prospector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// notifyInterval is the time during which notifications are collected before the changed
+// files are checked
+const notifyInterval = 100 * time.Millisecond
+
+// notifyEvent is a change of a file in a watched directory
+type notifyEvent struct {
+	path    string
+	removed bool
+	rescan  bool // changes were missed or a directory was created, a full scan is required
+}
+
+// updateWatches watches all directories which can contain files matching the configured paths.
+// In case file system notifications are not available, the prospector falls back to polling.
+func (p *ProspectorLog) updateWatches() {
+	if p.watcher == nil {
+		w, err := newWatcher()
+		if err != nil {
+			logp.Warn("Falling back to scan_mode %s, file system notifications are not available: %v", pollScanMode, err)
+			p.config.ScanMode = pollScanMode
+			return
+		}
+
+		p.watcher = w
+		p.watcherWg.Add(1)
+		go p.handleNotifications()
+	}
+
+	for _, glob := range p.config.Paths {
+		// Parent directories are watched up to the first one without a pattern,
+		// so new directories matching the pattern are noticed
+		for dirGlob := filepath.Dir(glob); ; dirGlob = filepath.Dir(dirGlob) {
+			p.watchDirs(dirGlob)
+
+			if !strings.ContainsAny(dirGlob, `*?[\`) || dirGlob == filepath.Dir(dirGlob) {
+				break
+			}
+		}
+	}
+}
+
+// watchDirs watches all directories matching the glob pattern
+func (p *ProspectorLog) watchDirs(glob string) {
+	dirs, err := filepath.Glob(glob)
+	if err != nil {
+		logp.Err("glob(%s) failed: %v", glob, err)
+		return
+	}
+
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		// Files in directories which cannot be watched are still found by the periodic scans
+		if err := p.watcher.Watch(dir); err != nil {
+			logp.Warn("Failed to watch %s, changes are only found every scan_frequency: %v", dir, err)
+		}
+	}
+}
+
+// handleNotifications handles file system notifications until the prospector is stopped.
+// All notifications received within notifyInterval of the first one are handled together,
+// so a file which is written continuously is only checked once per interval.
+func (p *ProspectorLog) handleNotifications() {
+	defer p.watcherWg.Done()
+
+	events := p.watcher.Events()
+	for {
+		var event notifyEvent
+		var ok bool
+
+		select {
+		case <-p.Prospector.runDone:
+			return
+		case event, ok = <-events:
+			if !ok {
+				return
+			}
+		}
+
+		rescan := event.rescan
+		changes := map[string]bool{}
+		if !rescan {
+			changes[event.path] = event.removed
+		}
+
+		timer := time.NewTimer(notifyInterval)
+	PENDING:
+		for {
+			select {
+			case <-p.Prospector.runDone:
+				timer.Stop()
+				return
+			case event, ok = <-events:
+				if !ok {
+					timer.Stop()
+					return
+				}
+				rescan = rescan || event.rescan
+				if !rescan {
+					changes[event.path] = event.removed
+				}
+			case <-timer.C:
+				break PENDING
+			}
+		}
+
+		if rescan {
+			logp.Debug("prospector", "Start full scan because of file system notification")
+			p.Run()
+			continue
+		}
+
+		p.handleChanges(changes)
+	}
+}
+
+// handleChanges checks the changed files. The value of changes is true for removed files.
+func (p *ProspectorLog) handleChanges(changes map[string]bool) {
+	p.scanLock.Lock()
+	defer p.scanLock.Unlock()
+
+	// Existing files are checked first, so renamed files are updated before
+	// states of removed files are cleaned up
+	for path, removed := range changes {
+		select {
+		case <-p.Prospector.runDone:
+			return
+		default:
+		}
+
+		if removed || !p.matchesFile(path) {
+			continue
+		}
+
+		info, isSymlink, ok := p.getFileInfo(path)
+		if !ok {
+			continue
+		}
+
+		// Symlinks are only checked during scans to detect if the original file is harvested too
+		if isSymlink {
+			continue
+		}
+
+		p.harvestFile(path, info)
+	}
+
+	if !p.config.CleanRemoved {
+		return
+	}
+
+	for path, removed := range changes {
+		if !removed {
+			continue
+		}
+
+		path, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+
+		for _, state := range p.Prospector.states.GetStates() {
+			if state.Source == path {
+				p.cleanRemovedState(state)
+			}
+		}
+	}
+}
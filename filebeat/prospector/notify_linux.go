@@ -0,0 +1,191 @@
+package prospector
+
+import (
+	"bytes"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_ONLYDIR
+
+// watcher reports changes to the files in the watched directories based on inotify.
+//
+// The inotify file descriptor is blocking. The reading goroutine waits with epoll until
+// either notifications are available or the watcher is closed, which is signalled by
+// writing to the wake pipe.
+type watcher struct {
+	fd     int    // inotify instance
+	epfd   int    // epoll instance waiting for fd and wake
+	wake   [2]int // read and write end of the pipe used to stop the reader
+	mutex  sync.Mutex
+	dirs   map[int32]string
+	events chan notifyEvent
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newWatcher() (*watcher, error) {
+	w := &watcher{
+		fd:     -1,
+		epfd:   -1,
+		wake:   [2]int{-1, -1},
+		dirs:   map[int32]string{},
+		events: make(chan notifyEvent, 1024),
+		done:   make(chan struct{}),
+	}
+
+	if err := w.init(); err != nil {
+		w.closeFds()
+		return nil, err
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w, nil
+}
+
+func (w *watcher) init() error {
+	var err error
+	if w.fd, err = syscall.InotifyInit1(syscall.IN_CLOEXEC); err != nil {
+		return err
+	}
+	if err = syscall.Pipe2(w.wake[:], syscall.O_CLOEXEC); err != nil {
+		return err
+	}
+	if w.epfd, err = syscall.EpollCreate1(syscall.EPOLL_CLOEXEC); err != nil {
+		return err
+	}
+
+	for _, fd := range []int{w.fd, w.wake[0]} {
+		event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+		if err := syscall.EpollCtl(w.epfd, syscall.EPOLL_CTL_ADD, fd, &event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *watcher) closeFds() {
+	for _, fd := range []int{w.epfd, w.fd, w.wake[0], w.wake[1]} {
+		if fd >= 0 {
+			syscall.Close(fd)
+		}
+	}
+}
+
+// Watch adds a directory to the watched directories. Watching the same directory again
+// has no effect.
+func (w *watcher) Watch(dir string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, watchMask)
+	if err != nil {
+		return err
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.dirs[int32(wd)] = dir
+	return nil
+}
+
+// Events returns the channel of changes. It is closed when the watcher is closed.
+func (w *watcher) Events() <-chan notifyEvent {
+	return w.events
+}
+
+// Close stops watching all directories
+func (w *watcher) Close() {
+	close(w.done)
+	syscall.Write(w.wake[1], []byte{0})
+	w.wg.Wait()
+	w.closeFds()
+}
+
+func (w *watcher) run() {
+	defer w.wg.Done()
+	defer close(w.events)
+
+	buf := make([]byte, 4096*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	ready := make([]syscall.EpollEvent, 2)
+	for {
+		n, err := syscall.EpollWait(w.epfd, ready, -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			logp.Err("Waiting for file system notifications failed: %v", err)
+			return
+		}
+
+		for _, event := range ready[:n] {
+			if event.Fd == int32(w.wake[0]) {
+				return
+			}
+		}
+
+		n, err = syscall.Read(w.fd, buf)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			logp.Err("Reading file system notifications failed: %v", err)
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			name := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(raw.Len)]
+			offset += syscall.SizeofInotifyEvent + int(raw.Len)
+
+			event, ok := w.toEvent(raw, string(bytes.TrimRight(name, "\x00")))
+			if !ok {
+				continue
+			}
+
+			select {
+			case <-w.done:
+				return
+			case w.events <- event:
+			}
+		}
+	}
+}
+
+func (w *watcher) toEvent(raw *syscall.InotifyEvent, name string) (notifyEvent, bool) {
+	// Events were lost, only a full scan finds all changes
+	if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		return notifyEvent{rescan: true}, true
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	// The watch was removed, because the directory was deleted or unmounted
+	if raw.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, raw.Wd)
+		return notifyEvent{}, false
+	}
+
+	dir, found := w.dirs[raw.Wd]
+	if !found || name == "" {
+		return notifyEvent{}, false
+	}
+
+	// New directories can match the configured paths and the files of directories moved
+	// away were removed. Both are handled by a full scan.
+	if raw.Mask&syscall.IN_ISDIR != 0 {
+		rescan := raw.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO|syscall.IN_MOVED_FROM) != 0
+		return notifyEvent{rescan: rescan}, rescan
+	}
+
+	// Files moved to another directory are removed from the watched directory
+	return notifyEvent{
+		path:    filepath.Join(dir, name),
+		removed: raw.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0,
+	}, true
+}
@@ -0,0 +1,83 @@
+// +build !integration
+
+package prospector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w, err := newWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	assert.NoError(t, w.Watch(dir))
+
+	path := filepath.Join(dir, "test.log")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("hello\n"), 0644))
+	event := nextEvent(t, w)
+	assert.Equal(t, notifyEvent{path: path}, event)
+
+	assert.NoError(t, os.Remove(path))
+
+	// Writing the content is reported separately from the creation
+	event = nextEvent(t, w)
+	for event == (notifyEvent{path: path}) {
+		event = nextEvent(t, w)
+	}
+	assert.Equal(t, notifyEvent{path: path, removed: true}, event)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	assert.Equal(t, notifyEvent{rescan: true}, nextEvent(t, w))
+
+	// Files moved out of the directory are reported as removed
+	other, err := ioutil.TempDir("", "notify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(other)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("hello\n"), 0644))
+	assert.NoError(t, os.Rename(path, filepath.Join(other, "test.log")))
+	event = nextEvent(t, w)
+	for event == (notifyEvent{path: path}) {
+		event = nextEvent(t, w)
+	}
+	assert.Equal(t, notifyEvent{path: path, removed: true}, event)
+}
+
+func TestWatcherClose(t *testing.T) {
+	w, err := newWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Close()
+
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}
+
+func nextEvent(t *testing.T, w *watcher) notifyEvent {
+	select {
+	case event := <-w.Events():
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+	return notifyEvent{}
+}
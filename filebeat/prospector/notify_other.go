@@ -0,0 +1,16 @@
+// +build !linux
+
+package prospector
+
+import "errors"
+
+// watcher is only supported on linux. On all other platforms, the file system is polled.
+type watcher struct{}
+
+func newWatcher() (*watcher, error) {
+	return nil, errors.New("file system notifications are only supported on linux")
+}
+
+func (w *watcher) Watch(dir string) error     { return nil }
+func (w *watcher) Events() <-chan notifyEvent { return nil }
+func (w *watcher) Close()                     {}
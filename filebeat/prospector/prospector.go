@@ -44,6 +44,7 @@ type Prospector struct {
 type Prospectorer interface {
 	LoadStates(states []file.State) error
 	Run()
+	Stop()
 }
 
 type Outlet interface {
@@ -96,6 +97,14 @@ func (p *Prospector) LoadStates(states []file.State) error {
 		prospectorer, err = NewProspectorStdin(p)
 	case cfg.LogInputType:
 		prospectorer, err = NewProspectorLog(p)
+	case cfg.DockerInputType:
+		prospectorer, err = NewProspectorDocker(p)
+	case cfg.UDPInputType:
+		prospectorer, err = NewProspectorUDP(p)
+	case cfg.TCPInputType:
+		prospectorer, err = NewProspectorTCP(p)
+	case cfg.SyslogInputType:
+		prospectorer, err = NewProspectorSyslog(p)
 	default:
 		return fmt.Errorf("Invalid input type: %v", p.config.InputType)
 	}
@@ -206,7 +215,10 @@ func (p *Prospector) updateState(event *input.Event) error {
 		return errors.New("prospector outlet closed")
 	}
 
-	p.states.Update(event.State)
+	// Stateless inputs do not track any state
+	if _, ok := cfg.StatelessInputType[event.InputType]; !ok {
+		p.states.Update(event.State)
+	}
 	return nil
 }
 
@@ -240,6 +252,9 @@ func (p *Prospector) stop() {
 	// This ensure no new harvesters are added.
 	p.runWg.Wait()
 
+	// Stop prospector specific harvesters which are not part of the registry
+	p.prospectorer.Stop()
+
 	// Stop all harvesters
 	// In case the beatDone channel is closed, this will not wait for completion
 	// Otherwise Stop will wait until output is complete
@@ -0,0 +1,22 @@
+package prospector
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// NewProspectorDocker creates a new docker prospector
+// The docker prospector is a log prospector reading the log files of the configured
+// containers. Decoding the files is done by the harvester.
+func NewProspectorDocker(p *Prospector) (*ProspectorLog, error) {
+
+	if len(p.config.Paths) > 0 {
+		return nil, fmt.Errorf("paths cannot be used with the docker prospector, use containers.ids instead")
+	}
+
+	for _, id := range p.config.Containers.IDs {
+		p.config.Paths = append(p.config.Paths, filepath.Join(p.config.Containers.Path, id, "*.log"))
+	}
+
+	return NewProspectorLog(p)
+}
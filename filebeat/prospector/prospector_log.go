@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/elastic/beats/filebeat/harvester"
+	"github.com/elastic/beats/filebeat/harvester/source"
 	"github.com/elastic/beats/filebeat/input"
 	"github.com/elastic/beats/filebeat/input/file"
 	"github.com/elastic/beats/libbeat/logp"
@@ -21,6 +24,10 @@ var (
 type ProspectorLog struct {
 	Prospector *Prospector
 	config     prospectorConfig
+	identifier file.StateIdentifier
+	scanLock   sync.Mutex // scans and file system notifications are handled one at a time
+	watcher    *watcher
+	watcherWg  sync.WaitGroup
 }
 
 func NewProspectorLog(p *Prospector) (*ProspectorLog, error) {
@@ -34,6 +41,12 @@ func NewProspectorLog(p *Prospector) (*ProspectorLog, error) {
 		return nil, fmt.Errorf("each prospector must have at least one path defined")
 	}
 
+	var err error
+	prospectorer.identifier, err = file.NewStateIdentifier(p.config.FileIdentity, p.config.FingerprintLength)
+	if err != nil {
+		return nil, err
+	}
+
 	return prospectorer, nil
 }
 
@@ -53,6 +66,10 @@ func (p *ProspectorLog) LoadStates(states []file.State) error {
 				return fmt.Errorf("Can only start a prospector when all related states are finished: %+v", state)
 			}
 
+			if !file.HasIdentity(state, p.identifier) {
+				state = p.migrateState(state)
+			}
+
 			// Update prospector states and send new states to registry
 			err := p.Prospector.updateState(input.NewEvent(state))
 			if err != nil {
@@ -66,7 +83,49 @@ func (p *ProspectorLog) LoadStates(states []file.State) error {
 	return nil
 }
 
+// migrateState generates the ID of a state written with another file_identity or by a version
+// without file identities. The previous state is removed from the registry, as it cannot be
+// found based on the new ID. The native ID only depends on the stored FileStateOS. Other IDs
+// are based on the current file under the path of the state, so they are only generated if the
+// file still exists and is the file the state was written for. Otherwise the state is kept
+// unchanged and can only be found by states without ID.
+func (p *ProspectorLog) migrateState(state file.State) file.State {
+	migrated := state
+
+	if p.identifier.Name() != file.NativeIdentity {
+		info, err := os.Stat(state.Source)
+		if err != nil {
+			logp.Debug("prospector", "State of %s not migrated to file_identity %s: %v", state.Source, p.identifier.Name(), err)
+			return state
+		}
+		if !state.FileStateOS.IsSame(file.GetOSState(info)) {
+			logp.Debug("prospector", "State of %s not migrated to file_identity %s: file was replaced", state.Source, p.identifier.Name())
+			return state
+		}
+		migrated.Fileinfo = info
+	}
+
+	var err error
+	migrated.ID, err = p.identifier.ID(migrated)
+	if err != nil {
+		logp.Debug("prospector", "State of %s not migrated to file_identity %s: %v", state.Source, p.identifier.Name(), err)
+		return state
+	}
+	migrated.Fileinfo = state.Fileinfo
+
+	logp.Debug("prospector", "Migrate state of %s to file_identity %s", state.Source, p.identifier.Name())
+
+	// The previous state is removed from the registry only, the prospector states are not updated yet
+	state.TTL = 0
+	p.Prospector.outlet.OnEvent(input.NewEvent(state))
+
+	return migrated
+}
+
 func (p *ProspectorLog) Run() {
+	p.scanLock.Lock()
+	defer p.scanLock.Unlock()
+
 	logp.Debug("prospector", "Start next scan")
 
 	// TailFiles is like ignore_older = 1ns and only on startup
@@ -94,25 +153,48 @@ func (p *ProspectorLog) Run() {
 	// Marking removed files to be cleaned up. Cleanup happens after next scan to make sure all states are updated first
 	if p.config.CleanRemoved {
 		for _, state := range p.Prospector.states.GetStates() {
-			// os.Stat will return an error in case the file does not exist
-			_, err := os.Stat(state.Source)
+			p.cleanRemovedState(state)
+		}
+	}
+
+	// Watches are refreshed on every scan to pick up new directories
+	if p.config.ScanMode == notifyScanMode && !p.Prospector.Once {
+		p.updateWatches()
+	}
+}
+
+// cleanRemovedState marks the state of a removed file to be cleaned up
+func (p *ProspectorLog) cleanRemovedState(state file.State) {
+	// os.Stat will return an error in case the file does not exist
+	_, err := os.Stat(state.Source)
+	if err != nil {
+		// Only clean up files where state is Finished
+		if state.Finished {
+			state.TTL = 0
+			err := p.Prospector.updateState(input.NewEvent(state))
 			if err != nil {
-				// Only clean up files where state is Finished
-				if state.Finished {
-					state.TTL = 0
-					err := p.Prospector.updateState(input.NewEvent(state))
-					if err != nil {
-						logp.Err("File cleanup state update error: %s", err)
-					}
-					logp.Debug("prospector", "Remove state for file as file removed: %s", state.Source)
-				} else {
-					logp.Debug("prospector", "State for file not removed because not finished: %s", state.Source)
-				}
+				logp.Err("File cleanup state update error: %s", err)
 			}
+			logp.Debug("prospector", "Remove state for file as file removed: %s", state.Source)
+		} else {
+			logp.Debug("prospector", "State for file not removed because not finished: %s", state.Source)
 		}
 	}
 }
 
+// Stop stops watching for file system notifications. The harvesters of the log prospector
+// are stopped through the registry.
+func (p *ProspectorLog) Stop() {
+	p.scanLock.Lock()
+	watcher := p.watcher
+	p.scanLock.Unlock()
+
+	if watcher != nil {
+		watcher.Close()
+		p.watcherWg.Wait()
+	}
+}
+
 // getFiles returns all files which have to be harvested
 // All globs are expanded and then directory and excluded files are removed
 func (p *ProspectorLog) getFiles() map[string]os.FileInfo {
@@ -137,28 +219,8 @@ func (p *ProspectorLog) getFiles() map[string]os.FileInfo {
 				continue
 			}
 
-			// Fetch Lstat File info to detected also symlinks
-			fileInfo, err := os.Lstat(file)
-			if err != nil {
-				logp.Debug("prospector", "lstat(%s) failed: %s", file, err)
-				continue
-			}
-
-			if fileInfo.IsDir() {
-				logp.Debug("prospector", "Skipping directory: %s", file)
-				continue
-			}
-
-			isSymlink := fileInfo.Mode()&os.ModeSymlink > 0
-			if isSymlink && !p.config.Symlinks {
-				logp.Debug("prospector", "File %s skipped as it is a symlink.", file)
-				continue
-			}
-
-			// Fetch Stat file info which fetches the inode. In case of a symlink, the original inode is fetched
-			fileInfo, err = os.Stat(file)
-			if err != nil {
-				logp.Debug("prospector", "stat(%s) failed: %s", file, err)
+			fileInfo, _, ok := p.getFileInfo(file)
+			if !ok {
 				continue
 			}
 
@@ -180,6 +242,38 @@ func (p *ProspectorLog) getFiles() map[string]os.FileInfo {
 	return paths
 }
 
+// getFileInfo returns the file info of a file which can be harvested and if the file is
+// a symlink. false is returned for directories and files which cannot be harvested.
+func (p *ProspectorLog) getFileInfo(file string) (os.FileInfo, bool, bool) {
+
+	// Fetch Lstat File info to detected also symlinks
+	fileInfo, err := os.Lstat(file)
+	if err != nil {
+		logp.Debug("prospector", "lstat(%s) failed: %s", file, err)
+		return nil, false, false
+	}
+
+	if fileInfo.IsDir() {
+		logp.Debug("prospector", "Skipping directory: %s", file)
+		return nil, false, false
+	}
+
+	isSymlink := fileInfo.Mode()&os.ModeSymlink > 0
+	if isSymlink && !p.config.Symlinks {
+		logp.Debug("prospector", "File %s skipped as it is a symlink.", file)
+		return nil, false, false
+	}
+
+	// Fetch Stat file info which fetches the inode. In case of a symlink, the original inode is fetched
+	fileInfo, err = os.Stat(file)
+	if err != nil {
+		logp.Debug("prospector", "stat(%s) failed: %s", file, err)
+		return nil, false, false
+	}
+
+	return fileInfo, isSymlink, true
+}
+
 // matchesFile returns true in case the given filePath is part of this prospector, means matches its glob patterns
 func (p *ProspectorLog) matchesFile(filePath string) bool {
 
@@ -218,38 +312,54 @@ func (p *ProspectorLog) scan() {
 		default:
 		}
 
-		var err error
-		path, err = filepath.Abs(path)
-		if err != nil {
-			logp.Err("could not fetch abs path for file %s: %s", path, err)
-		}
-		logp.Debug("prospector", "Check file for harvesting: %s", path)
+		p.harvestFile(path, info)
+	}
+}
 
-		// Create new state for comparison
-		newState := file.NewState(info, path)
+// harvestFile starts or resumes harvesting of a file based on its previous state
+func (p *ProspectorLog) harvestFile(path string, info os.FileInfo) {
+	var err error
+	path, err = filepath.Abs(path)
+	if err != nil {
+		logp.Err("could not fetch abs path for file %s: %s", path, err)
+	}
+	logp.Debug("prospector", "Check file for harvesting: %s", path)
 
-		// Load last state
-		lastState := p.Prospector.states.FindPrevious(newState)
+	// Create new state for comparison
+	newState := file.NewState(info, path)
+	newState.ID, err = p.identifier.ID(newState)
+	if err == file.ErrFileTooSmall {
+		logp.Debug("prospector", "File is skipped until it is big enough to be fingerprinted: %s", path)
+		return
+	}
+	if err != nil {
+		logp.Err("Failed to identify file %s: %s", path, err)
+		return
+	}
 
-		// Ignores all files which fall under ignore_older
-		if p.isIgnoreOlder(newState) {
-			err := p.handleIgnoreOlder(lastState, newState)
-			if err != nil {
-				logp.Err("Updating ignore_older state error: %s", err)
-			}
-			continue
+	// Load last state
+	lastState := p.Prospector.states.FindPrevious(newState)
+
+	// Ignores all files which fall under ignore_older
+	if p.isIgnoreOlder(newState) {
+		err := p.handleIgnoreOlder(lastState, newState)
+		if err != nil {
+			logp.Err("Updating ignore_older state error: %s", err)
 		}
+		return
+	}
 
-		// Decides if previous state exists
-		if lastState.IsEmpty() {
-			logp.Debug("prospector", "Start harvester for new file: %s", newState.Source)
-			err := p.Prospector.startHarvester(newState, 0)
-			if err != nil {
-				logp.Err("Harvester could not be started on new file: %s, Err: %s", newState.Source, err)
-			}
-		} else {
-			p.harvestExistingFile(newState, lastState)
+	// Decides if previous state exists
+	if lastState.IsEmpty() && source.IsGzipFile(newState.Source) {
+		p.harvestNewCompressedFile(newState)
+	} else if lastState.IsEmpty() {
+		logp.Debug("prospector", "Start harvester for new file: %s", newState.Source)
+		err := p.Prospector.startHarvester(newState, 0)
+		if err != nil {
+			logp.Err("Harvester could not be started on new file: %s, Err: %s", newState.Source, err)
 		}
+	} else {
+		p.harvestExistingFile(newState, lastState)
 	}
 }
 
@@ -258,10 +368,22 @@ func (p *ProspectorLog) harvestExistingFile(newState file.State, oldState file.S
 
 	logp.Debug("prospector", "Update existing file for harvesting: %s, offset: %v", newState.Source, oldState.Offset)
 
+	// The offset of compressed files is based on the decompressed data and cannot be compared
+	// to the file size. They are only read again if the end of the file was not reached before.
+	compressed := source.IsGzipFile(newState.Source)
+	if oldState.Finished && compressed && !oldState.EOF {
+		logp.Debug("prospector", "Resuming harvesting of compressed file: %s, offset: %v", newState.Source, oldState.Offset)
+		err := p.Prospector.startHarvester(newState, oldState.Offset)
+		if err != nil {
+			logp.Err("Harvester could not be started on existing file: %s, Err: %s", newState.Source, err)
+		}
+		return
+	}
+
 	// No harvester is running for the file, start a new harvester
 	// It is important here that only the size is checked and not modification time, as modification time could be incorrect on windows
 	// https://blogs.technet.microsoft.com/asiasupp/2010/12/14/file-date-modified-property-are-not-updating-while-modifying-a-file-without-closing-it/
-	if oldState.Finished && newState.Fileinfo.Size() > oldState.Offset {
+	if oldState.Finished && !compressed && newState.Fileinfo.Size() > oldState.Offset {
 		// Resume harvesting of an old file we've stopped harvesting from
 		// This could also be an issue with force_close_older that a new harvester is started after each scan but not needed?
 		// One problem with comparing modTime is that it is in seconds, and scans can happen more then once a second
@@ -274,7 +396,7 @@ func (p *ProspectorLog) harvestExistingFile(newState file.State, oldState file.S
 	}
 
 	// File size was reduced -> truncated file
-	if oldState.Finished && newState.Fileinfo.Size() < oldState.Offset {
+	if oldState.Finished && !compressed && newState.Fileinfo.Size() < oldState.Offset {
 		logp.Debug("prospector", "Old file was truncated. Starting from the beginning: %s", newState.Source)
 		err := p.Prospector.startHarvester(newState, 0)
 		if err != nil {
@@ -314,6 +436,40 @@ func (p *ProspectorLog) harvestExistingFile(newState file.State, oldState file.S
 	}
 }
 
+// harvestNewCompressedFile starts a harvester for a compressed file without a state.
+// If the file is the compressed copy of an already harvested file, for example app.log.1
+// compressed to app.log.1.gz by logrotate, harvesting continues at the offset of the
+// uncompressed file instead of reading it again.
+func (p *ProspectorLog) harvestNewCompressedFile(newState file.State) {
+	var offset int64
+
+	uncompressed := p.findStateBySource(strings.TrimSuffix(newState.Source, ".gz"))
+	if !uncompressed.IsEmpty() {
+		if !uncompressed.Finished {
+			logp.Debug("prospector", "Harvester for uncompressed file is still running, compressed file is checked again on next scan: %s", newState.Source)
+			return
+		}
+		offset = uncompressed.Offset
+	}
+
+	logp.Debug("prospector", "Start harvester for new compressed file: %s, offset: %v", newState.Source, offset)
+	err := p.Prospector.startHarvester(newState, offset)
+	if err != nil {
+		logp.Err("Harvester could not be started on new file: %s, Err: %s", newState.Source, err)
+	}
+}
+
+// findStateBySource returns the state with the given source. An empty state is returned
+// if none exists.
+func (p *ProspectorLog) findStateBySource(path string) file.State {
+	for _, state := range p.Prospector.states.GetStates() {
+		if state.Source == path {
+			return state
+		}
+	}
+	return file.State{}
+}
+
 // handleIgnoreOlder handles states which fall under ignore older
 // Based on the state information it is decided if the state information has to be updated or not
 func (p *ProspectorLog) handleIgnoreOlder(lastState, newState file.State) error {
@@ -337,6 +493,9 @@ func (p *ProspectorLog) handleIgnoreOlder(lastState, newState file.State) error
 	// See https://github.com/elastic/beats/pull/2907
 	newState.Offset = newState.Fileinfo.Size()
 
+	// Compressed files are treated as completely read
+	newState.EOF = source.IsGzipFile(newState.Source)
+
 	// Write state for ignore_older file as none exists yet
 	newState.Finished = true
 	err := p.Prospector.updateState(input.NewEvent(newState))
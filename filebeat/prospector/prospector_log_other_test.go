@@ -3,6 +3,9 @@
 package prospector
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/elastic/beats/filebeat/input"
@@ -128,6 +131,9 @@ var initStateTests = []struct {
 // This means only the ones that match the glob and not exclude files
 func TestInit(t *testing.T) {
 
+	identifier, err := file.NewStateIdentifier(file.NativeIdentity, 0)
+	assert.NoError(t, err)
+
 	for _, test := range initStateTests {
 		p := ProspectorLog{
 			Prospector: &Prospector{
@@ -137,6 +143,7 @@ func TestInit(t *testing.T) {
 			config: prospectorConfig{
 				Paths: test.paths,
 			},
+			identifier: identifier,
 		}
 		states := file.NewStates()
 		// Set states to finished
@@ -152,6 +159,47 @@ func TestInit(t *testing.T) {
 
 }
 
+// TestMigrateState checks that only states of existing files which were not replaced get an ID
+func TestMigrateState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	if err := ioutil.WriteFile(path, []byte("line\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identifier, err := file.NewStateIdentifier(file.PathIdentity, 0)
+	assert.NoError(t, err)
+
+	p := ProspectorLog{
+		Prospector: &Prospector{
+			states: &file.States{},
+			outlet: TestOutlet{},
+		},
+		identifier: identifier,
+	}
+
+	state := p.migrateState(file.State{Source: path, FileStateOS: file.GetOSState(info)})
+	assert.Equal(t, "path::"+path, state.ID)
+
+	// The file under the path is not the file the state was written for
+	replaced := file.GetOSState(info)
+	replaced.Inode++
+	state = p.migrateState(file.State{Source: path, FileStateOS: replaced})
+	assert.Equal(t, "", state.ID)
+
+	state = p.migrateState(file.State{Source: filepath.Join(dir, "missing.log"), FileStateOS: file.GetOSState(info)})
+	assert.Equal(t, "", state.ID)
+}
+
 // TestOutlet is an empty outlet for testing
 type TestOutlet struct{}
 
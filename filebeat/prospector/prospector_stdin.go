@@ -48,3 +48,5 @@ func (p *ProspectorStdin) Run() {
 		p.started = true
 	}
 }
+
+func (p *ProspectorStdin) Stop() {}
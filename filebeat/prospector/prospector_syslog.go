@@ -0,0 +1,54 @@
+package prospector
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/harvester/syslog"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+type ProspectorSyslog struct {
+	harvester *syslog.Harvester
+	started   bool
+}
+
+// NewProspectorSyslog creates a new syslog prospector
+// This prospector contains one harvester which is listening on the configured protocols
+func NewProspectorSyslog(p *Prospector) (*ProspectorSyslog, error) {
+
+	outlet := channel.NewOutlet(p.beatDone, p.harvesterChan, p.eventCounter)
+	harvester, err := syslog.NewHarvester(p.cfg, outlet)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing syslog harvester: %v", err)
+	}
+
+	return &ProspectorSyslog{
+		harvester: harvester,
+		started:   false,
+	}, nil
+}
+
+func (p *ProspectorSyslog) LoadStates(states []file.State) error {
+	return nil
+}
+
+func (p *ProspectorSyslog) Run() {
+
+	// Make sure the syslog harvester is only started once
+	if !p.started {
+		err := p.harvester.Start()
+		if err != nil {
+			logp.Err("Error starting syslog harvester: %s", err)
+			return
+		}
+		p.started = true
+	}
+}
+
+func (p *ProspectorSyslog) Stop() {
+	if p.started {
+		p.harvester.Stop()
+	}
+}
@@ -0,0 +1,54 @@
+package prospector
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/harvester/tcp"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+type ProspectorTCP struct {
+	harvester *tcp.Harvester
+	started   bool
+}
+
+// NewProspectorTCP creates a new tcp prospector
+// This prospector contains one harvester which is accepting connections on the configured host
+func NewProspectorTCP(p *Prospector) (*ProspectorTCP, error) {
+
+	outlet := channel.NewOutlet(p.beatDone, p.harvesterChan, p.eventCounter)
+	harvester, err := tcp.NewHarvester(p.cfg, outlet)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing tcp harvester: %v", err)
+	}
+
+	return &ProspectorTCP{
+		harvester: harvester,
+		started:   false,
+	}, nil
+}
+
+func (p *ProspectorTCP) LoadStates(states []file.State) error {
+	return nil
+}
+
+func (p *ProspectorTCP) Run() {
+
+	// Make sure the tcp harvester is only started once
+	if !p.started {
+		err := p.harvester.Start()
+		if err != nil {
+			logp.Err("Error starting tcp harvester: %s", err)
+			return
+		}
+		p.started = true
+	}
+}
+
+func (p *ProspectorTCP) Stop() {
+	if p.started {
+		p.harvester.Stop()
+	}
+}
@@ -3,6 +3,7 @@
 package prospector
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,30 @@ func TestProspectorFileExclude(t *testing.T) {
 	assert.True(t, p.isFileExcluded("/tmp/log/logw.gz"))
 	assert.False(t, p.isFileExcluded("/tmp/log/logw.log"))
 }
+
+func TestProspectorDockerPaths(t *testing.T) {
+
+	prospector := Prospector{
+		config: prospectorConfig{
+			Containers: containersConfig{
+				IDs:  []string{"abc", "*"},
+				Path: "/var/lib/docker/containers",
+			},
+		},
+	}
+
+	p, err := NewProspectorDocker(&prospector)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("/var/lib/docker/containers", "abc", "*.log"),
+		filepath.Join("/var/lib/docker/containers", "*", "*.log"),
+	}, p.config.Paths)
+
+	assert.True(t, p.matchesFile(filepath.Join("/var/lib/docker/containers", "abc", "abc-json.log")))
+	assert.False(t, p.matchesFile(filepath.Join("/var/lib/docker/containers", "abc", "config.v2.json")))
+
+	// paths cannot be combined with containers.ids
+	prospector.config.Paths = []string{"/var/log/*.log"}
+	_, err = NewProspectorDocker(&prospector)
+	assert.Error(t, err)
+}
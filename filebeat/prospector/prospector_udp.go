@@ -0,0 +1,54 @@
+package prospector
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/filebeat/channel"
+	"github.com/elastic/beats/filebeat/harvester/udp"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+type ProspectorUDP struct {
+	harvester *udp.Harvester
+	started   bool
+}
+
+// NewProspectorUDP creates a new udp prospector
+// This prospector contains one harvester which is listening on the configured host
+func NewProspectorUDP(p *Prospector) (*ProspectorUDP, error) {
+
+	outlet := channel.NewOutlet(p.beatDone, p.harvesterChan, p.eventCounter)
+	harvester, err := udp.NewHarvester(p.cfg, outlet)
+	if err != nil {
+		return nil, fmt.Errorf("Error initializing udp harvester: %v", err)
+	}
+
+	return &ProspectorUDP{
+		harvester: harvester,
+		started:   false,
+	}, nil
+}
+
+func (p *ProspectorUDP) LoadStates(states []file.State) error {
+	return nil
+}
+
+func (p *ProspectorUDP) Run() {
+
+	// Make sure the udp harvester is only started once
+	if !p.started {
+		err := p.harvester.Start()
+		if err != nil {
+			logp.Err("Error starting udp harvester: %s", err)
+			return
+		}
+		p.started = true
+	}
+}
+
+func (p *ProspectorUDP) Stop() {
+	if p.started {
+		p.harvester.Stop()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/elastic/beats/filebeat/ingest"
 	"github.com/elastic/beats/filebeat/input"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/publisher"
@@ -16,6 +17,9 @@ type asyncLogPublisher struct {
 	in     chan []*input.Event
 	out    SuccessLogger
 
+	// pipelines run by the beat instead of Elasticsearch, can be nil
+	pipelines *ingest.Runner
+
 	// list of in-flight batches
 	active   batchList
 	stopping bool
@@ -53,12 +57,14 @@ func newAsyncLogPublisher(
 	in chan []*input.Event,
 	out SuccessLogger,
 	pub publisher.Publisher,
+	pipelines *ingest.Runner,
 ) *asyncLogPublisher {
 	return &asyncLogPublisher{
-		in:   in,
-		out:  out,
-		pub:  pub,
-		done: make(chan struct{}),
+		in:        in,
+		out:       out,
+		pub:       pub,
+		pipelines: pipelines,
+		done:      make(chan struct{}),
 	}
 }
 
@@ -84,7 +90,7 @@ func (p *asyncLogPublisher) Start() {
 					flag:   0,
 					events: events,
 				}
-				dataEvents, meta := getDataEvents(events)
+				dataEvents, meta := getDataEvents(events, p.pipelines)
 				p.client.PublishEvents(
 					dataEvents,
 					publisher.Signal(batch),
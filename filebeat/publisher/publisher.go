@@ -4,6 +4,7 @@ import (
 	"errors"
 	"expvar"
 
+	"github.com/elastic/beats/filebeat/ingest"
 	"github.com/elastic/beats/filebeat/input"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
@@ -32,12 +33,13 @@ func New(
 	in chan []*input.Event,
 	out SuccessLogger,
 	pub publisher.Publisher,
+	pipelines *ingest.Runner,
 ) LogPublisher {
 	if async {
 		logp.Warn("publish_async is experimental and will be removed in a future version!")
-		return newAsyncLogPublisher(in, out, pub)
+		return newAsyncLogPublisher(in, out, pub, pipelines)
 	}
-	return newSyncLogPublisher(in, out, pub)
+	return newSyncLogPublisher(in, out, pub, pipelines)
 }
 
 var (
@@ -45,15 +47,29 @@ var (
 )
 
 // getDataEvents returns all events which contain data (not only state updates)
-// together with their associated metadata
-func getDataEvents(events []*input.Event) (dataEvents []common.MapStr, meta []common.MapStr) {
+// together with their associated metadata. Events of pipelines run by the beat
+// are processed here and sent without the pipeline in the metadata.
+func getDataEvents(events []*input.Event, pipelines *ingest.Runner) (dataEvents []common.MapStr, meta []common.MapStr) {
 	dataEvents = make([]common.MapStr, 0, len(events))
 	meta = make([]common.MapStr, 0, len(events))
 	for _, event := range events {
-		if event.HasData() {
-			dataEvents = append(dataEvents, event.ToMapStr())
-			meta = append(meta, event.Metadata())
+		if !event.HasData() {
+			continue
 		}
+
+		data := event.ToMapStr()
+		if pipelines.Has(event.Pipeline) {
+			if err := pipelines.Run(event.Pipeline, data); err != nil {
+				logp.Debug("publish", "Error running ingest pipeline: %v", err)
+				data["error"] = err.Error()
+			}
+			dataEvents = append(dataEvents, data)
+			meta = append(meta, nil)
+			continue
+		}
+
+		dataEvents = append(dataEvents, data)
+		meta = append(meta, event.Metadata())
 	}
 	return dataEvents, meta
 }
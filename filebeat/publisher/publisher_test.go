@@ -60,7 +60,7 @@ func TestPublisherModes(t *testing.T) {
 		client := pubtest.NewChanClient(0)
 
 		pub := New(test.async, pubChan, collector,
-			pubtest.PublisherWithClient(client))
+			pubtest.PublisherWithClient(client), nil)
 		pub.Start()
 
 		var events [][]*input.Event
@@ -3,6 +3,7 @@ package publisher
 import (
 	"sync"
 
+	"github.com/elastic/beats/filebeat/ingest"
 	"github.com/elastic/beats/filebeat/input"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/publisher"
@@ -14,6 +15,9 @@ type syncLogPublisher struct {
 	in     chan []*input.Event
 	out    SuccessLogger
 
+	// pipelines run by the beat instead of Elasticsearch, can be nil
+	pipelines *ingest.Runner
+
 	done chan struct{}
 	wg   sync.WaitGroup
 }
@@ -22,12 +26,14 @@ func newSyncLogPublisher(
 	in chan []*input.Event,
 	out SuccessLogger,
 	pub publisher.Publisher,
+	pipelines *ingest.Runner,
 ) *syncLogPublisher {
 	return &syncLogPublisher{
-		in:   in,
-		out:  out,
-		pub:  pub,
-		done: make(chan struct{}),
+		in:        in,
+		out:       out,
+		pub:       pub,
+		pipelines: pipelines,
+		done:      make(chan struct{}),
 	}
 }
 
@@ -58,7 +64,7 @@ func (p *syncLogPublisher) Publish() error {
 	case events = <-p.in:
 	}
 
-	dataEvents, meta := getDataEvents(events)
+	dataEvents, meta := getDataEvents(events, p.pipelines)
 	ok := p.client.PublishEvents(dataEvents, publisher.Sync, publisher.Guaranteed,
 		publisher.MetadataBatch(meta))
 	if !ok {
@@ -0,0 +1,290 @@
+package registrar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	cfg "github.com/elastic/beats/filebeat/config"
+	"github.com/elastic/beats/filebeat/harvester/source"
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/paths"
+)
+
+// editFunc modifies the states of the files matching the given patterns. The number
+// of modified states is returned.
+type editFunc func(states []file.State, patterns []string) ([]file.State, int, error)
+
+// RegisterCommands adds the `registry` commands to the beat
+func RegisterCommands() {
+	beat.RegisterCommand("registry list", beat.BeatCommand{
+		Usage: "List the states of all files in the registry",
+		Run: func(b *beat.Beat, args []string) error {
+			return viewRegistry(b, func(states []file.State) error {
+				return listStates(os.Stdout, states, time.Now())
+			})
+		},
+	})
+	beat.RegisterCommand("registry show", beat.BeatCommand{
+		Usage: "Print the states of the given file paths",
+		Args:  true,
+		Run: func(b *beat.Beat, args []string) error {
+			return viewRegistry(b, func(states []file.State) error {
+				return showStates(os.Stdout, states, args)
+			})
+		},
+	})
+	beat.RegisterCommand("registry reset", beat.BeatCommand{
+		Usage: "Read the files matching the given globs again from the start",
+		Args:  true,
+		Run: func(b *beat.Beat, args []string) error {
+			return editRegistry(b, args, resetFiles)
+		},
+	})
+	beat.RegisterCommand("registry delete", beat.BeatCommand{
+		Usage: "Remove the states of the files matching the given globs",
+		Args:  true,
+		Run: func(b *beat.Beat, args []string) error {
+			return editRegistry(b, args, deleteFiles)
+		},
+	})
+	beat.RegisterCommand("registry mark-read", beat.BeatCommand{
+		Usage: "Mark the files matching the given globs as completely read",
+		Args:  true,
+		Run: func(b *beat.Beat, args []string) error {
+			return editRegistry(b, args, markFilesRead)
+		},
+	})
+}
+
+// openRegistry locks the data path and opens the registry configured for the beat.
+// The lock must be closed after the registry was used.
+func openRegistry(b *beat.Beat) (*store, io.Closer, error) {
+	lock, err := b.LockDataPath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := cfg.DefaultConfig
+	if b.RawConfig.HasField("filebeat") {
+		sub, err := b.RawConfig.Child("filebeat", -1)
+		if err == nil {
+			err = sub.Unpack(&config)
+		}
+		if err != nil {
+			lock.Close()
+			return nil, nil, fmt.Errorf("Error reading config file: %v", err)
+		}
+	}
+
+	// Changes made by the commands are always synced to disk
+	registryFile := paths.Resolve(paths.Data, config.RegistryFile)
+	s := newStore(registryFile, StoreConfig{Fsync: FsyncAlways})
+	return s, lock, nil
+}
+
+// viewRegistry passes the current states of the registry to view
+func viewRegistry(b *beat.Beat, view func([]file.State) error) error {
+	s, lock, err := openRegistry(b)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	states, err := readRegistry(s)
+	if err != nil {
+		return err
+	}
+	return view(states)
+}
+
+// editRegistry applies the edit to the states of the registry. The changes are
+// written as a new checkpoint.
+func editRegistry(b *beat.Beat, patterns []string, edit editFunc) error {
+	if len(patterns) == 0 {
+		return errors.New("at least one glob is required")
+	}
+
+	s, lock, err := openRegistry(b)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	states, err := readRegistry(s)
+	if err != nil {
+		return err
+	}
+
+	states, count, err := edit(states, patterns)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("no states match the given globs")
+	}
+
+	if err := s.Checkpoint(states); err != nil {
+		return fmt.Errorf("Error writing registry file %s: %v", s.path, err)
+	}
+	fmt.Printf("Updated %d states in %s\n", count, s.path)
+	return nil
+}
+
+// readRegistry reads the states of the registry file and applies the registry log
+func readRegistry(s *store) ([]file.State, error) {
+	states := []file.State{}
+
+	f, err := os.Open(s.path)
+	if err == nil {
+		states, err = s.Load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding states of %s: %s", s.path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return s.Replay(states)
+}
+
+func listStates(w io.Writer, states []file.State, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tOFFSET\tINODE\tAGE\tTTL")
+	for _, state := range states {
+		ttl := "-"
+		if state.TTL >= 0 {
+			ttl = state.TTL.String()
+		}
+		age := (now.Sub(state.Timestamp) / time.Second) * time.Second
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", state.Source, state.Offset, state.FileStateOS.String(), age, ttl)
+	}
+	return tw.Flush()
+}
+
+func showStates(w io.Writer, states []file.State, sources []string) error {
+	if len(sources) == 0 {
+		return errors.New("at least one path is required")
+	}
+
+	var found []file.State
+	for _, path := range sources {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		// Rotated files can have multiple states for the same path
+		for _, state := range states {
+			if state.Source == path {
+				found = append(found, state)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return errors.New("no states found for the given paths")
+	}
+
+	data, err := json.MarshalIndent(found, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// matchState returns true if the source of the state matches one of the patterns
+func matchState(state file.State, patterns []string) (bool, error) {
+	for _, pattern := range patterns {
+		pattern, err := filepath.Abs(pattern)
+		if err != nil {
+			return false, err
+		}
+
+		match, err := filepath.Match(pattern, state.Source)
+		if err != nil {
+			return false, fmt.Errorf("invalid glob %s: %v", pattern, err)
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func resetFiles(states []file.State, patterns []string) ([]file.State, int, error) {
+	return updateStates(states, patterns, func(state *file.State) bool {
+		state.Offset = 0
+		state.EOF = false
+		return true
+	})
+}
+
+func deleteFiles(states []file.State, patterns []string) ([]file.State, int, error) {
+	result := make([]file.State, 0, len(states))
+	for _, state := range states {
+		match, err := matchState(state, patterns)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !match {
+			result = append(result, state)
+		}
+	}
+	return result, len(states) - len(result), nil
+}
+
+// markFilesRead sets the offsets to the current size of the files. States of files
+// which were removed or replaced are not changed.
+func markFilesRead(states []file.State, patterns []string) ([]file.State, int, error) {
+	return updateStates(states, patterns, func(state *file.State) bool {
+		info, err := os.Stat(state.Source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", state.Source, err)
+			return false
+		}
+		if !state.FileStateOS.IsSame(file.GetOSState(info)) {
+			fmt.Fprintf(os.Stderr, "Skipping %s: the file was replaced\n", state.Source)
+			return false
+		}
+
+		// The offset of compressed files is based on the uncompressed content
+		if source.IsGzipFile(state.Source) {
+			state.EOF = true
+			return true
+		}
+
+		state.Offset = info.Size()
+		return true
+	})
+}
+
+// updateStates applies update to all states matching the patterns. Updated
+// states get a new timestamp, so they take precedence over older records in the
+// registry log.
+func updateStates(states []file.State, patterns []string, update func(*file.State) bool) ([]file.State, int, error) {
+	now := time.Now()
+	count := 0
+	for i := range states {
+		match, err := matchState(states[i], patterns)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !match {
+			continue
+		}
+
+		if update(&states[i]) {
+			states[i].Timestamp = now
+			count++
+		}
+	}
+	return states, count, nil
+}
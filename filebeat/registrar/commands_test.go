@@ -0,0 +1,120 @@
+// +build !integration
+
+package registrar
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/filebeat/input/file"
+)
+
+func TestListStates(t *testing.T) {
+	now := time.Now()
+	states := []file.State{
+		{Source: "/var/log/a.log", Offset: 10, Timestamp: now.Add(-time.Minute), TTL: -1},
+		{Source: "/var/log/b.log", Offset: 20, Timestamp: now.Add(-time.Hour), TTL: 24 * time.Hour},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, listStates(&buf, states, now))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, []string{"SOURCE", "OFFSET", "INODE", "AGE", "TTL"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"/var/log/a.log", "10", states[0].FileStateOS.String(), "1m0s", "-"}, strings.Fields(lines[1]))
+		assert.Equal(t, []string{"/var/log/b.log", "20", states[1].FileStateOS.String(), "1h0m0s", "24h0m0s"}, strings.Fields(lines[2]))
+	}
+}
+
+func TestShowStates(t *testing.T) {
+	states := []file.State{
+		{Source: "/var/log/a.log", Offset: 10},
+		{Source: "/var/log/b.log", Offset: 20},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, showStates(&buf, states, []string{"/var/log/b.log"}))
+	assert.Contains(t, buf.String(), `"offset": 20`)
+	assert.NotContains(t, buf.String(), "a.log")
+
+	assert.Error(t, showStates(&buf, states, []string{"/var/log/c.log"}))
+}
+
+func TestEditStates(t *testing.T) {
+	states := func() []file.State {
+		return []file.State{
+			{Source: "/var/log/a.log", Offset: 10},
+			{Source: "/var/log/b.log", Offset: 20},
+			{Source: "/var/log/app/c.log", Offset: 30},
+		}
+	}
+
+	result, count, err := resetFiles(states(), []string{"/var/log/*.log"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(0), result[0].Offset)
+	assert.Equal(t, int64(0), result[1].Offset)
+	assert.Equal(t, int64(30), result[2].Offset)
+	assert.False(t, result[0].Timestamp.IsZero())
+
+	result, count, err = deleteFiles(states(), []string{"/var/log/a.log", "/var/log/app/*"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	if assert.Len(t, result, 1) {
+		assert.Equal(t, "/var/log/b.log", result[0].Source)
+	}
+
+	_, _, err = deleteFiles(states(), []string{"/var/log/["})
+	assert.Error(t, err)
+}
+
+func TestMarkFilesRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("first\nsecond\n"), 0644))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	states := []file.State{
+		file.NewState(info, path),
+		{Source: filepath.Join(dir, "missing.log")},
+	}
+
+	result, count, err := markFilesRead(states, []string{filepath.Join(dir, "*")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(13), result[0].Offset)
+	assert.Equal(t, int64(0), result[1].Offset)
+}
+
+func TestReadRegistry(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncNever, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	// A missing registry has no states
+	states, err := readRegistry(s)
+	assert.NoError(t, err)
+	assert.Len(t, states, 0)
+
+	first := testState("/var/log/first.log", "first", 10)
+	assert.NoError(t, s.Checkpoint([]file.State{first}))
+	second := testState("/var/log/second.log", "second", 20)
+	assert.NoError(t, s.Flush([]file.State{first, second}))
+
+	states, err = readRegistry(s)
+	assert.NoError(t, err)
+	assert.Len(t, states, 2)
+}
@@ -25,6 +25,10 @@ type Registrar struct {
 	registryFile string       // Path to the Registry File
 	states       *file.States // Map with all file paths inside and the corresponding state
 	wg           sync.WaitGroup
+
+	store       *store
+	storeConfig StoreConfig
+	flush       time.Duration // Interval in which state changes are written to disk
 }
 
 var (
@@ -34,7 +38,13 @@ var (
 	registryWrites = expvar.NewInt("registrar.writes")
 )
 
-func New(registryFile string, out publisher.SuccessLogger) (*Registrar, error) {
+func New(registryFile string, flush time.Duration, storeConfig StoreConfig, out publisher.SuccessLogger) (*Registrar, error) {
+
+	switch storeConfig.Fsync {
+	case FsyncAlways, FsyncCheckpoint, FsyncNever:
+	default:
+		return nil, fmt.Errorf("Invalid registry_fsync setting: %s", storeConfig.Fsync)
+	}
 
 	r := &Registrar{
 		registryFile: registryFile,
@@ -43,6 +53,8 @@ func New(registryFile string, out publisher.SuccessLogger) (*Registrar, error) {
 		Channel:      make(chan []*input.Event, 1),
 		out:          out,
 		wg:           sync.WaitGroup{},
+		storeConfig:  storeConfig,
+		flush:        flush,
 	}
 	err := r.Init()
 
@@ -54,6 +66,7 @@ func (r *Registrar) Init() error {
 
 	// The registry file is opened in the data path
 	r.registryFile = paths.Resolve(paths.Data, r.registryFile)
+	r.store = newStore(r.registryFile, r.storeConfig)
 
 	// Create directory if it does not already exist.
 	registryPath := filepath.Dir(r.registryFile)
@@ -94,11 +107,28 @@ func (r *Registrar) GetStates() []file.State {
 
 // loadStates fetches the previous reading state from the configure RegistryFile file
 // The default file is `registry` in the data path.
+// The registry file is closed before the loaded states are written to a new
+// checkpoint, as open files can not be replaced on Windows.
 func (r *Registrar) loadStates() error {
+	states, err := r.readStates()
+	if err != nil {
+		return err
+	}
+
+	states = resetStates(states)
+	r.states.SetStates(states)
+	logp.Info("States Loaded from registrar: %+v", len(states))
+
+	// Compact the replayed log into a new checkpoint
+	return r.writeRegistry()
+}
 
+// readStates reads the states from the registry file and applies the changes
+// written after the last checkpoint.
+func (r *Registrar) readStates() ([]file.State, error) {
 	f, err := os.Open(r.registryFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	defer f.Close()
@@ -106,77 +136,65 @@ func (r *Registrar) loadStates() error {
 	logp.Info("Loading registrar data from %s", r.registryFile)
 
 	// DEPRECATED: This should be removed in 6.0
-	oldStates := r.loadAndConvertOldState(f)
-	if oldStates {
-		return nil
+	if states, ok := r.loadAndConvertOldState(f); ok {
+		return states, nil
 	}
 
-	decoder := json.NewDecoder(f)
-	states := []file.State{}
-	err = decoder.Decode(&states)
+	states, err := r.store.Load(f)
 	if err != nil {
-		return fmt.Errorf("Error decoding states: %s", err)
+		return nil, fmt.Errorf("Error decoding states: %s", err)
 	}
 
-	states = resetStates(states)
-	r.states.SetStates(states)
-	logp.Info("States Loaded from registrar: %+v", len(states))
-
-	return nil
+	// Apply the changes written after the last checkpoint
+	return r.store.Replay(states)
 }
 
 // loadAndConvertOldState loads the old state file and converts it to the new state
 // This is designed so it can be easily removed in later versions
-func (r *Registrar) loadAndConvertOldState(f *os.File) bool {
+func (r *Registrar) loadAndConvertOldState(f *os.File) ([]file.State, bool) {
 	// Make sure file reader is reset afterwards
 	defer f.Seek(0, 0)
 
 	stat, err := f.Stat()
 	if err != nil {
 		logp.Err("Error getting stat for old state: %+v", err)
-		return false
+		return nil, false
 	}
 
 	// Empty state does not have to be transformed ({} + newline)
 	if stat.Size() <= 4 {
-		return false
+		return nil, false
 	}
 
 	// Check if already new state format
-	decoder := json.NewDecoder(f)
-	newState := []file.State{}
-	err = decoder.Decode(&newState)
+	_, err = decodeCheckpoint(f)
 	// No error means registry is already in new format
 	if err == nil {
-		return false
+		return nil, false
 	}
 
 	// Reset file offset
 	f.Seek(0, 0)
+	decoder := json.NewDecoder(f)
 	oldStates := map[string]file.State{}
 	err = decoder.Decode(&oldStates)
 	if err != nil {
 		logp.Err("Error decoding old state: %+v", err)
-		return false
+		return nil, false
 	}
 
 	// No old states found -> probably already new format
 	if oldStates == nil {
-		return false
+		return nil, false
 	}
 
-	// Convert old states to new states
+	// Convert old states to new states. They are written to the registry in
+	// the new format by loadStates.
 	logp.Info("Old registry states found: %v", len(oldStates))
 	states := convertOldStates(oldStates)
-	states = resetStates(states)
-	r.states.SetStates(states)
-
-	// Rewrite registry in new format
-	r.writeRegistry()
+	logp.Info("Old states converted to new states: %v", len(oldStates))
 
-	logp.Info("Old states converted to new states and written to registrar: %v", len(oldStates))
-
-	return true
+	return states, true
 }
 
 // resetStates sets all states to finished and disable TTL on restart
@@ -234,9 +252,17 @@ func (r *Registrar) Start() error {
 
 func (r *Registrar) Run() {
 	logp.Info("Starting Registrar")
+	// Events are only acknowledged after their states were written to disk
+	var pending []*input.Event
+	var flushC <-chan time.Time
+
 	// Writes registry on shutdown
 	defer func() {
 		r.writeRegistry()
+		r.store.Close()
+		if r.out != nil && len(pending) > 0 {
+			r.out.Published(pending)
+		}
 		r.wg.Done()
 	}()
 
@@ -247,6 +273,11 @@ func (r *Registrar) Run() {
 		case <-r.done:
 			logp.Info("Ending Registrar")
 			return
+		case <-flushC:
+			flushC = nil
+			r.flushRegistry(pending)
+			pending = nil
+			continue
 		case events = <-r.Channel:
 		}
 
@@ -260,13 +291,30 @@ func (r *Registrar) Run() {
 			"Registrar states cleaned up. Before: %d, After: %d",
 			beforeCount, beforeCount-cleanedStates)
 
-		if err := r.writeRegistry(); err != nil {
-			logp.Err("Writing of registry returned error: %v. Continuing...", err)
+		pending = append(pending, events...)
+		if r.flush <= 0 {
+			r.flushRegistry(pending)
+			pending = nil
+		} else if flushC == nil {
+			flushC = time.After(r.flush)
 		}
+	}
+}
 
-		if r.out != nil {
-			r.out.Published(events)
-		}
+// flushRegistry writes the changed states to disk and acknowledges the events
+func (r *Registrar) flushRegistry(events []*input.Event) {
+	states := r.states.GetStates()
+
+	logp.Debug("registrar", "Flush registry log: %s", r.store.logPath)
+	if err := r.store.Flush(states); err != nil {
+		logp.Err("Writing of registry returned error: %v. Continuing...", err)
+	} else {
+		registryWrites.Add(1)
+		statesCurrent.Set(int64(len(states)))
+	}
+
+	if r.out != nil {
+		r.out.Published(events)
 	}
 }
 
@@ -277,8 +325,8 @@ func (r *Registrar) processEventStates(events []*input.Event) {
 	// Take the last event found for each file source
 	for _, event := range events {
 
-		// skip stdin
-		if event.InputType == cfg.StdinInputType {
+		// skip stateless inputs like stdin
+		if _, ok := cfg.StatelessInputType[event.InputType]; ok {
 			continue
 		}
 		r.states.Update(event.State)
@@ -293,36 +341,19 @@ func (r *Registrar) Stop() {
 	r.wg.Wait()
 }
 
-// writeRegistry writes the new json registry file to disk.
+// writeRegistry writes all states to a new checkpoint of the registry file.
 func (r *Registrar) writeRegistry() error {
 	logp.Debug("registrar", "Write registry file: %s", r.registryFile)
 
-	tempfile := r.registryFile + ".new"
-	f, err := os.OpenFile(tempfile, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_SYNC, 0600)
-	if err != nil {
-		logp.Err("Failed to create tempfile (%s) for writing: %s", tempfile, err)
-		return err
-	}
-
-	// First clean up states
 	states := r.states.GetStates()
-
-	encoder := json.NewEncoder(f)
-	err = encoder.Encode(states)
+	err := r.store.Checkpoint(states)
 	if err != nil {
-		f.Close()
-		logp.Err("Error when encoding the states: %s", err)
 		return err
 	}
 
-	// Directly close file because of windows
-	f.Close()
-
-	err = file.SafeFileRotate(r.registryFile, tempfile)
-
 	logp.Debug("registrar", "Registry file updated. %d states written.", len(states))
 	registryWrites.Add(1)
 	statesCurrent.Set(int64(len(states)))
 
-	return err
+	return nil
 }
@@ -0,0 +1,374 @@
+package registrar
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/elastic/beats/filebeat/input/file"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// Supported fsync policies
+const (
+	FsyncAlways     = "always"     // fsync the log on every flush and every checkpoint
+	FsyncCheckpoint = "checkpoint" // only fsync checkpoints
+	FsyncNever      = "never"      // leave flushing to disk to the operating system
+)
+
+const (
+	opSet    = "set"
+	opRemove = "remove"
+)
+
+// StoreConfig configures how the registry is written to disk
+type StoreConfig struct {
+	Fsync          string
+	CheckpointSize int64
+}
+
+// store persists the states of the registrar.
+//
+// The registry file is the checkpoint and contains all states. Changes since the last checkpoint
+// are appended to an operation log next to the registry file, one JSON record per line. As soon
+// as the log grows beyond the checkpoint size, all states are written to a new checkpoint and
+// the log is truncated.
+//
+// Each checkpoint has a generation, which is incremented by every checkpoint. The records of the
+// log are written with the generation of the next checkpoint. On load, the log is replayed on top
+// of the checkpoint. Records at or below the generation of the checkpoint are already part of it
+// and are skipped, which happens if the process stopped after a checkpoint was written but before
+// the log was truncated. A torn record at the end of the log, caused for example by a crash during
+// a write, is ignored.
+type store struct {
+	path       string
+	logPath    string
+	config     StoreConfig
+	generation uint64 // generation of the last checkpoint
+
+	log     *os.File
+	logSize int64
+	broken  bool // a write to the log failed, it might end with an incomplete record
+
+	// last written version of each state, used to only write the changed states
+	written map[string]file.State
+}
+
+// checkpoint is the content of the registry file. Registry files written before generations
+// were introduced only contain the states and have generation 0.
+type checkpoint struct {
+	Generation uint64       `json:"generation"`
+	States     []file.State `json:"states"`
+}
+
+type logRecord struct {
+	Op         string      `json:"op"`
+	Generation uint64      `json:"generation"`
+	Key        string      `json:"key,omitempty"`
+	State      *file.State `json:"state,omitempty"`
+	Timestamp  *time.Time  `json:"timestamp,omitempty"` // time of removal
+}
+
+func newStore(path string, config StoreConfig) *store {
+	return &store{
+		path:    path,
+		logPath: path + ".log",
+		config:  config,
+		written: map[string]file.State{},
+	}
+}
+
+// stateKey returns the key under which a state is stored in the log. States without an ID
+// were written by versions without file identities and use the native identifiers.
+func stateKey(state file.State) string {
+	if state.ID != "" {
+		return state.ID
+	}
+	return file.NativeIdentity + "::" + state.FileStateOS.String()
+}
+
+// decodeCheckpoint decodes the content of a registry file
+func decodeCheckpoint(r io.Reader) (checkpoint, error) {
+	var data json.RawMessage
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return checkpoint{}, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		cp := checkpoint{}
+		err := json.Unmarshal(data, &cp.States)
+		return cp, err
+	}
+
+	cp := checkpoint{}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return checkpoint{}, err
+	}
+	if cp.States == nil {
+		return checkpoint{}, errors.New("registry file contains no states")
+	}
+	return cp, nil
+}
+
+// Load reads the states of the checkpoint from the registry file content
+func (s *store) Load(r io.Reader) ([]file.State, error) {
+	cp, err := decodeCheckpoint(r)
+	if err != nil {
+		return nil, err
+	}
+	s.generation = cp.Generation
+	return cp.States, nil
+}
+
+// Replay applies the operation log to the states of the checkpoint which was loaded last
+func (s *store) Replay(states []file.State) ([]file.State, error) {
+	f, err := os.Open(s.logPath)
+	if os.IsNotExist(err) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []string
+	current := map[string]file.State{}
+	for _, state := range states {
+		key := stateKey(state)
+		if _, found := current[key]; !found {
+			keys = append(keys, key)
+		}
+		current[key] = state
+	}
+
+	reader := bufio.NewReader(f)
+	count, skipped := 0, 0
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(data)) > 0 {
+				logp.Warn("Ignoring incomplete last record in registry log %s", s.logPath)
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var record logRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			// Only the last record can be incomplete
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				logp.Warn("Ignoring invalid last record in registry log %s: %v", s.logPath, err)
+				break
+			}
+			return nil, fmt.Errorf("Registry log %s is corrupted at line %d: %v", s.logPath, line, err)
+		}
+
+		// The record was written before the checkpoint, which already contains it
+		if record.Generation <= s.generation {
+			skipped++
+			continue
+		}
+
+		switch record.Op {
+		case opSet:
+			if record.State == nil {
+				return nil, fmt.Errorf("Registry log %s is corrupted at line %d: missing state", s.logPath, line)
+			}
+			key := stateKey(*record.State)
+			old, found := current[key]
+			if found && old.Timestamp.After(record.State.Timestamp) {
+				continue
+			}
+			if !found {
+				keys = append(keys, key)
+			}
+			current[key] = *record.State
+		case opRemove:
+			if record.Timestamp == nil {
+				return nil, fmt.Errorf("Registry log %s is corrupted at line %d: missing timestamp", s.logPath, line)
+			}
+			old, found := current[record.Key]
+			if found && !old.Timestamp.After(*record.Timestamp) {
+				delete(current, record.Key)
+			}
+		default:
+			return nil, fmt.Errorf("Registry log %s is corrupted at line %d: unknown operation %s", s.logPath, line, record.Op)
+		}
+		count++
+	}
+
+	logp.Info("Replayed %d records from registry log %s, %d records were part of the checkpoint", count, s.logPath, skipped)
+
+	states = make([]file.State, 0, len(current))
+	for _, key := range keys {
+		if state, found := current[key]; found {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// Flush appends all states which changed since the last flush to the log. A checkpoint
+// is written instead in case the log reached the checkpoint size.
+func (s *store) Flush(states []file.State) error {
+	if s.broken || s.logSize >= s.config.CheckpointSize {
+		return s.Checkpoint(states)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	current := make(map[string]file.State, len(states))
+	for _, state := range states {
+		key := stateKey(state)
+		current[key] = state
+
+		if old, found := s.written[key]; found && isSameVersion(old, state) {
+			continue
+		}
+
+		state := state
+		if err := encoder.Encode(logRecord{Op: opSet, Generation: s.generation + 1, State: &state}); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for key := range s.written {
+		if _, found := current[key]; !found {
+			if err := encoder.Encode(logRecord{Op: opRemove, Generation: s.generation + 1, Key: key, Timestamp: &now}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	if err := s.appendLog(buf.Bytes()); err != nil {
+		// Further records cannot be appended after an incomplete one
+		s.broken = true
+		s.Close()
+		return err
+	}
+
+	s.written = current
+	return nil
+}
+
+// Checkpoint writes all states to the registry file as the next generation and truncates the log
+func (s *store) Checkpoint(states []file.State) error {
+	tempfile := s.path + ".new"
+
+	if states == nil {
+		states = []file.State{}
+	}
+	cp := checkpoint{Generation: s.generation + 1, States: states}
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if s.config.Fsync != FsyncNever {
+		flags |= os.O_SYNC
+	}
+
+	f, err := os.OpenFile(tempfile, flags, 0600)
+	if err != nil {
+		logp.Err("Failed to create tempfile (%s) for writing: %s", tempfile, err)
+		return err
+	}
+
+	encoder := json.NewEncoder(f)
+	err = encoder.Encode(cp)
+	if err != nil {
+		f.Close()
+		logp.Err("Error when encoding the states: %s", err)
+		return err
+	}
+
+	// Directly close file because of windows
+	f.Close()
+
+	err = file.SafeFileRotate(s.path, tempfile)
+	if err != nil {
+		return err
+	}
+	s.generation = cp.Generation
+
+	// Records in the log which are already part of the checkpoint are skipped on
+	// replay based on their generation, so a failure to truncate the log neither
+	// loses states nor brings back removed ones
+	if err := s.truncateLog(); err != nil {
+		logp.Err("Failed to truncate registry log %s: %v", s.logPath, err)
+	}
+
+	s.written = make(map[string]file.State, len(states))
+	for _, state := range states {
+		s.written[stateKey(state)] = state
+	}
+	return nil
+}
+
+// Close closes the log
+func (s *store) Close() error {
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
+
+func (s *store) appendLog(data []byte) error {
+	if s.log == nil {
+		f, err := os.OpenFile(s.logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		s.log = f
+		s.logSize = info.Size()
+	}
+
+	n, err := s.log.Write(data)
+	s.logSize += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if s.config.Fsync == FsyncAlways {
+		return s.log.Sync()
+	}
+	return nil
+}
+
+func (s *store) truncateLog() error {
+	s.Close()
+	s.logSize = 0
+	s.broken = false
+
+	err := os.Remove(s.logPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// isSameVersion returns true if the state was not updated. Every update of a state
+// sets a new timestamp.
+func isSameVersion(a, b file.State) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.Offset == b.Offset && a.TTL == b.TTL &&
+		a.Source == b.Source && a.EOF == b.EOF && a.FileStateOS == b.FileStateOS
+}
@@ -0,0 +1,253 @@
+// +build !integration
+
+package registrar
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/filebeat/input/file"
+)
+
+func setupStore(t *testing.T, config StoreConfig) (*store, func()) {
+	dir, err := ioutil.TempDir("", "registrar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newStore(filepath.Join(dir, "registry"), config)
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func testState(source string, id string, offset int64) file.State {
+	return file.State{
+		ID:        "path::" + id,
+		Source:    source,
+		Offset:    offset,
+		Timestamp: time.Now(),
+	}
+}
+
+func TestStoreFlushAndReplay(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncAlways, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	first := testState("/var/log/first.log", "first", 10)
+	second := testState("/var/log/second.log", "second", 20)
+
+	assert.NoError(t, s.Checkpoint([]file.State{first, second}))
+	_, err := os.Stat(s.logPath)
+	assert.True(t, os.IsNotExist(err))
+
+	// Unchanged states are not written again
+	assert.NoError(t, s.Flush([]file.State{first, second}))
+	_, err = os.Stat(s.logPath)
+	assert.True(t, os.IsNotExist(err))
+
+	first.Offset = 15
+	first.Timestamp = time.Now()
+	third := testState("/var/log/third.log", "third", 30)
+	assert.NoError(t, s.Flush([]file.State{first, third}))
+
+	data, err := ioutil.ReadFile(s.logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, countLines(data))
+
+	states, err := s.Replay([]file.State{first, second})
+	assert.NoError(t, err)
+	if assert.Len(t, states, 2) {
+		assert.Equal(t, "/var/log/first.log", states[0].Source)
+		assert.Equal(t, int64(15), states[0].Offset)
+		assert.Equal(t, "/var/log/third.log", states[1].Source)
+	}
+}
+
+func TestStoreReplayIgnoresTornRecord(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncNever, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	state := testState("/var/log/test.log", "test", 10)
+	assert.NoError(t, s.Flush([]file.State{state}))
+
+	f, err := os.OpenFile(s.logPath, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NoError(t, err)
+	f.WriteString(`{"op":"set","state":{"source":"/var/log/test.log","off`)
+	f.Close()
+
+	states, err := s.Replay(nil)
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, int64(10), states[0].Offset)
+	}
+}
+
+func TestStoreReplayCorrupted(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncNever, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	data := "{\"op\":\"set\",\"sta\n" + `{"op":"remove","key":"path::test","timestamp":"2017-01-01T00:00:00Z"}` + "\n"
+	assert.NoError(t, ioutil.WriteFile(s.logPath, []byte(data), 0600))
+
+	_, err := s.Replay(nil)
+	assert.Error(t, err)
+}
+
+func TestStoreReplaySkipsOlderRecords(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncNever, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	old := testState("/var/log/test.log", "test", 10)
+	assert.NoError(t, s.Flush([]file.State{old}))
+	assert.NoError(t, s.Flush(nil))
+
+	// The checkpoint was written after the log, but the log was not truncated
+	current := testState("/var/log/test.log", "test", 20)
+	states, err := s.Replay([]file.State{current})
+	assert.NoError(t, err)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, int64(20), states[0].Offset)
+	}
+}
+
+func TestStoreReplayAfterCrashBeforeTruncate(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncAlways, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	first := testState("/var/log/first.log", "first", 10)
+	second := testState("/var/log/second.log", "second", 20)
+	assert.NoError(t, s.Checkpoint([]file.State{first}))
+	assert.NoError(t, s.Flush([]file.State{first, second}))
+
+	// The process stops after the checkpoint without second was written, but
+	// before the log was truncated
+	data, err := ioutil.ReadFile(s.logPath)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Checkpoint([]file.State{first}))
+	assert.NoError(t, ioutil.WriteFile(s.logPath, data, 0600))
+
+	states := loadStore(t, newStore(s.path, s.config))
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, "/var/log/first.log", states[0].Source)
+	}
+}
+
+func TestStoreLoadGenerations(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncAlways, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	// Registry files written before generations only contain the states
+	data := `[{"id":"path::test","source":"/var/log/test.log","offset":10,"FileStateOS":{},"timestamp":"2017-01-01T00:00:00Z","ttl":-1}]`
+	assert.NoError(t, ioutil.WriteFile(s.path, []byte(data), 0600))
+
+	states := loadStore(t, s)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, int64(10), states[0].Offset)
+	}
+	assert.Equal(t, uint64(0), s.generation)
+
+	assert.NoError(t, s.Checkpoint(states))
+	assert.NoError(t, s.Checkpoint(states))
+	assert.Equal(t, uint64(2), s.generation)
+
+	// Records written after the last checkpoint are replayed
+	state := testState("/var/log/test.log", "test", 20)
+	assert.NoError(t, s.Flush([]file.State{state}))
+
+	other := newStore(s.path, s.config)
+	states = loadStore(t, other)
+	assert.Equal(t, uint64(2), other.generation)
+	if assert.Len(t, states, 1) {
+		assert.Equal(t, int64(20), states[0].Offset)
+	}
+}
+
+func TestStoreCheckpointOnSize(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncCheckpoint, CheckpointSize: 1})
+	defer teardown()
+
+	state := testState("/var/log/test.log", "test", 10)
+	assert.NoError(t, s.Flush([]file.State{state}))
+	_, err := os.Stat(s.logPath)
+	assert.NoError(t, err)
+
+	// The log reached the checkpoint size, all states are written to the registry file
+	state.Offset = 20
+	state.Timestamp = time.Now()
+	assert.NoError(t, s.Flush([]file.State{state}))
+	_, err = os.Stat(s.logPath)
+	assert.True(t, os.IsNotExist(err))
+
+	data, err := ioutil.ReadFile(s.path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"offset":20`)
+}
+
+func TestRegistrarRestart(t *testing.T) {
+	s, teardown := setupStore(t, StoreConfig{Fsync: FsyncAlways, CheckpointSize: 1024 * 1024})
+	defer teardown()
+
+	// Registry left by a previous run, which stopped after flushing the log
+	assert.NoError(t, s.Checkpoint([]file.State{testState("/var/log/a.log", "a", 10)}))
+	assert.NoError(t, s.Flush([]file.State{
+		testState("/var/log/a.log", "a", 20),
+		testState("/var/log/b.log", "b", 30),
+	}))
+	assert.NoError(t, s.Close())
+
+	for run := 0; run < 2; run++ {
+		r, err := New(s.path, 0, s.config, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		offsets := map[string]int64{}
+		for _, state := range r.GetStates() {
+			offsets[state.Source] = state.Offset
+		}
+		assert.Equal(t, map[string]int64{"/var/log/a.log": 20, "/var/log/b.log": 30}, offsets)
+		r.Stop()
+
+		// The replayed log has been compacted into the registry file
+		_, err = os.Stat(s.logPath)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func loadStore(t *testing.T, s *store) []file.State {
+	f, err := os.Open(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	states, err := s.Load(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states, err = s.Replay(states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return states
+}
+
+func countLines(data []byte) int {
+	count := 0
+	for _, b := range data {
+		if b == '\n' {
+			count++
+		}
+	}
+	return count
+}
@@ -15,12 +15,64 @@ class BaseTest(TestCase):
         super(BaseTest, self).setUpClass()
 
     def get_registry(self):
-        # Returns content of the registry file
+        # Returns content of the registry file with the registry log applied
         dotFilebeat = self.working_dir + '/registry'
         assert os.path.isfile(dotFilebeat) is True
 
         with open(dotFilebeat) as file:
-            return json.load(file)
+            checkpoint = json.load(file)
+
+        # Registry files written before generations only contain the states
+        generation = 0
+        states = checkpoint
+        if isinstance(checkpoint, dict):
+            generation = checkpoint["generation"]
+            states = checkpoint["states"]
+
+        registryLog = dotFilebeat + '.log'
+        if not os.path.isfile(registryLog):
+            return states
+
+        def state_key(state):
+            if state.get("id"):
+                return state["id"]
+            fs = state["FileStateOS"]
+            if "inode" in fs:
+                return "native::%d-%d" % (fs["inode"], fs["device"])
+            return "native::%d-%d-%d" % (fs["idxhi"], fs["idxlo"], fs["vol"])
+
+        keys = []
+        current = {}
+        for state in states:
+            key = state_key(state)
+            if key not in current:
+                keys.append(key)
+            current[key] = state
+
+        with open(registryLog) as file:
+            for line in file:
+                try:
+                    record = json.loads(line)
+                except ValueError:
+                    # Incomplete last record
+                    break
+
+                # Records at or below the generation are part of the checkpoint
+                if record.get("generation", 0) <= generation:
+                    continue
+
+                if record["op"] == "set":
+                    key = state_key(record["state"])
+                    old = current.get(key)
+                    if old is not None and old["timestamp"] > record["state"]["timestamp"]:
+                        continue
+                    if key not in current:
+                        keys.append(key)
+                    current[key] = record["state"]
+                elif record["op"] == "remove":
+                    current.pop(record["key"], None)
+
+        return [current[key] for key in keys if key in current]
 
     def get_registry_entry_by_path(self, path):
         """
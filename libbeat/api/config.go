@@ -0,0 +1,35 @@
+package api
+
+import "github.com/elastic/beats/libbeat/common"
+
+// Config configures the HTTP endpoint exposing the beat's stats and state.
+type Config struct {
+	Enabled bool   `config:"enabled"`
+	Host    string `config:"host"`
+	Port    int    `config:"port" validate:"min=0, max=65535"`
+	Pprof   struct {
+		Enabled bool `config:"enabled"`
+	} `config:"pprof"`
+}
+
+var defaultConfig = Config{
+	Enabled: false,
+	Host:    "localhost",
+	Port:    5066,
+}
+
+// Enabled returns true if the HTTP endpoint is enabled in cfg. Unlike most
+// settings sections the endpoint must be enabled explicitly.
+func Enabled(cfg *common.Config) bool {
+	if cfg == nil {
+		return false
+	}
+
+	config := struct {
+		Enabled bool `config:"enabled"`
+	}{defaultConfig.Enabled}
+	if err := cfg.Unpack(&config); err != nil {
+		return false
+	}
+	return config.Enabled
+}
@@ -0,0 +1,157 @@
+// Package api provides the HTTP endpoint exposing information about a
+// running beat:
+//
+//   /       beat name, version and instance information
+//   /stats  all metrics of the libbeat/monitoring registry
+//   /state  the state derived from the configuration, like outputs and modules
+//
+// The pprof handlers are served under /debug/pprof/ if enabled.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+const unixSocketPrefix = "unix://"
+
+// Info is the beat information reported by the root endpoint.
+type Info struct {
+	Beat     string `json:"beat"`
+	Hostname string `json:"hostname"`
+	Name     string `json:"name"`
+	UUID     string `json:"uuid"`
+	Version  string `json:"version"`
+}
+
+// Server serves the HTTP endpoint.
+type Server struct {
+	mux      *http.ServeMux
+	listener net.Listener
+	config   Config
+}
+
+// New creates the server configured by cfg. The server starts listening on
+// Start.
+func New(info Info, cfg *common.Config) (*Server, error) {
+	config := defaultConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", infoHandler(info))
+	mux.HandleFunc("/stats", registryHandler(monitoring.Default, true))
+	mux.HandleFunc("/state", registryHandler(monitoring.State, false))
+
+	if config.Pprof.Enabled {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return &Server{mux: mux, config: config}, nil
+}
+
+// Start listens on the configured host and port or unix socket and serves
+// requests in the background.
+func (s *Server) Start() error {
+	l, err := listen(s.config.Host, s.config.Port)
+	if err != nil {
+		return fmt.Errorf("failed to start HTTP endpoint: %v", err)
+	}
+	s.listener = l
+
+	logp.Info("Starting stats endpoint on %v", l.Addr())
+	go func() {
+		err := http.Serve(l, s.mux)
+		logp.Debug("api", "Stats endpoint stopped: %v", err)
+	}()
+	return nil
+}
+
+// Stop closes the listener.
+func (s *Server) Stop() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func listen(host string, port int) (net.Listener, error) {
+	if strings.HasPrefix(host, unixSocketPrefix) {
+		path := strings.TrimPrefix(host, unixSocketPrefix)
+
+		// remove the socket left by a previous run
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(path)
+		}
+
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			l.Close()
+			return nil, err
+		}
+		return l, nil
+	}
+
+	return net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+}
+
+func infoHandler(info Info) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, r, info)
+	}
+}
+
+func registryHandler(registry *monitoring.Registry, expvar bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, monitoring.CollectStructSnapshot(registry, monitoring.Full, expvar))
+	}
+}
+
+// writeJSON writes data as JSON, indented if the pretty query parameter is
+// set.
+func writeJSON(w http.ResponseWriter, r *http.Request, data interface{}) {
+	var content []byte
+	var err error
+	if _, pretty := r.URL.Query()["pretty"]; pretty {
+		content, err = json.MarshalIndent(data, "", "  ")
+	} else {
+		content, err = json.Marshal(data)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(content)
+	w.Write([]byte("\n"))
+}
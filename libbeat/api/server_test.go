@@ -0,0 +1,135 @@
+// +build !integration
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+var testInfo = Info{
+	Beat:     "testbeat",
+	Hostname: "localhost",
+	Name:     "test",
+	UUID:     "1234",
+	Version:  "1.0.0",
+}
+
+func startServer(t *testing.T, settings map[string]interface{}) *Server {
+	cfg, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+
+	s, err := New(testInfo, cfg)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	return s
+}
+
+func getJSON(t *testing.T, client *http.Client, url string) (int, map[string]interface{}) {
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var data map[string]interface{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.Unmarshal(body, &data))
+	}
+	return resp.StatusCode, data
+}
+
+func TestServerEndpoints(t *testing.T) {
+	reg := monitoring.State.NewRegistry("test_output")
+	defer monitoring.State.Remove("test_output")
+	monitoring.NewInt(reg, "hosts").Set(2)
+
+	s := startServer(t, map[string]interface{}{"port": 0})
+	defer s.Stop()
+
+	url := "http://" + s.Addr().String()
+	client := &http.Client{}
+
+	status, info := getJSON(t, client, url+"/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "testbeat", info["beat"])
+	assert.Equal(t, "1.0.0", info["version"])
+
+	status, state := getJSON(t, client, url+"/state?pretty")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"hosts": float64(2)}, state["test_output"])
+
+	status, _ = getJSON(t, client, url+"/stats")
+	assert.Equal(t, http.StatusOK, status)
+
+	status, _ = getJSON(t, client, url+"/debug/pprof/")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	status, _ = getJSON(t, client, url+"/unknown")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServerPprof(t *testing.T) {
+	s := startServer(t, map[string]interface{}{
+		"port":          0,
+		"pprof.enabled": true,
+	})
+	defer s.Stop()
+
+	resp, err := http.Get("http://" + s.Addr().String() + "/debug/pprof/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets are not supported on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "api")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "beat.sock")
+	s := startServer(t, map[string]interface{}{"host": "unix://" + path})
+	defer s.Stop()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) {
+				return net.Dial("unix", path)
+			},
+		},
+	}
+
+	status, info := getJSON(t, client, "http://unix/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "testbeat", info["beat"])
+}
+
+func TestEnabled(t *testing.T) {
+	assert.False(t, Enabled(nil))
+
+	for settings, expected := range map[string]bool{
+		"port: 5067":    false,
+		"enabled: true": true,
+	} {
+		cfg, err := common.NewConfigWithYAML([]byte(settings), "test")
+		require.NoError(t, err)
+		assert.Equal(t, expected, Enabled(cfg), settings)
+	}
+}
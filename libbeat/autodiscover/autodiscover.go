@@ -0,0 +1,193 @@
+package autodiscover
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var (
+	debugf = logp.MakeDebug("autodiscover")
+
+	runnerStarts  = expvar.NewInt("libbeat.autodiscover.runner.starts")
+	runnerStops   = expvar.NewInt("libbeat.autodiscover.runner.stops")
+	runnerRunning = expvar.NewInt("libbeat.autodiscover.runner.running")
+)
+
+// Event is published by providers when a discovered target is started or
+// stopped.
+type Event struct {
+	// ID of the target, e.g. the container ID.
+	ID string
+
+	// Stop is set when the target is gone. All runners started for the
+	// target are stopped.
+	Stop bool
+
+	// Configs of the runners to start for the target.
+	Configs []*common.Config
+}
+
+// Autodiscover starts and stops runners created by the runner factory for
+// the targets discovered by its providers.
+type Autodiscover struct {
+	factory   cfgfile.RunnerFactory
+	providers []Provider
+	events    chan Event
+
+	// runners by runner ID, the same runner can be shared by several targets
+	runners map[uint64]cfgfile.Runner
+	refs    map[uint64]int
+	targets map[string][]uint64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewAutodiscover creates an Autodiscover for the providers configured in
+// config, creating the runners with factory.
+func NewAutodiscover(factory cfgfile.RunnerFactory, config *Config) (*Autodiscover, error) {
+	a := &Autodiscover{
+		factory: factory,
+		events:  make(chan Event),
+		runners: map[uint64]cfgfile.Runner{},
+		refs:    map[uint64]int{},
+		targets: map[string][]uint64{},
+		done:    make(chan struct{}),
+	}
+
+	for _, c := range config.Providers {
+		provider, err := Registry.BuildProvider(c, a.events)
+		if err != nil {
+			return nil, err
+		}
+		a.providers = append(a.providers, provider)
+	}
+	return a, nil
+}
+
+// Start starts the providers, handling their events in the background.
+func (a *Autodiscover) Start() error {
+	logp.Info("Starting autodiscover with %v providers", len(a.providers))
+
+	a.wg.Add(1)
+	go a.run()
+
+	for i, provider := range a.providers {
+		if err := provider.Start(); err != nil {
+			for _, started := range a.providers[:i] {
+				started.Stop()
+			}
+			a.stop()
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops the providers and all runners started by autodiscover.
+func (a *Autodiscover) Stop() {
+	for _, provider := range a.providers {
+		provider.Stop()
+	}
+	a.stop()
+	logp.Info("Autodiscover stopped")
+}
+
+func (a *Autodiscover) stop() {
+	close(a.done)
+	a.wg.Wait()
+
+	wg := sync.WaitGroup{}
+	for id, runner := range a.runners {
+		wg.Add(1)
+		go func(id uint64, runner cfgfile.Runner) {
+			defer wg.Done()
+			a.stopRunner(id, runner)
+		}(id, runner)
+	}
+	wg.Wait()
+}
+
+func (a *Autodiscover) run() {
+	defer a.wg.Done()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case event := <-a.events:
+			if event.Stop {
+				a.handleStop(event)
+			} else {
+				a.handleStart(event)
+			}
+		}
+	}
+}
+
+func (a *Autodiscover) handleStart(event Event) {
+	if _, exists := a.targets[event.ID]; exists {
+		debugf("Runners already started for %v", event.ID)
+		return
+	}
+
+	ids := []uint64{}
+	for _, config := range event.Configs {
+		if !config.Enabled() {
+			continue
+		}
+
+		runner, err := a.factory.Create(config)
+		if err != nil {
+			logp.Err("Error creating runner for %v: %v", event.ID, err)
+			continue
+		}
+
+		id := runner.ID()
+		if a.refs[id] == 0 {
+			runner.Start()
+			a.runners[id] = runner
+			runnerStarts.Add(1)
+			runnerRunning.Add(1)
+			debugf("Runner %v started for %v", id, event.ID)
+		} else {
+			debugf("Runner %v already running, shared with %v", id, event.ID)
+		}
+
+		a.refs[id]++
+		ids = append(ids, id)
+	}
+	a.targets[event.ID] = ids
+}
+
+func (a *Autodiscover) handleStop(event Event) {
+	ids, exists := a.targets[event.ID]
+	if !exists {
+		debugf("No runners started for %v", event.ID)
+		return
+	}
+	delete(a.targets, event.ID)
+
+	for _, id := range ids {
+		a.refs[id]--
+		if a.refs[id] > 0 {
+			continue
+		}
+
+		delete(a.refs, id)
+		runner := a.runners[id]
+		delete(a.runners, id)
+		a.stopRunner(id, runner)
+	}
+}
+
+func (a *Autodiscover) stopRunner(id uint64, runner cfgfile.Runner) {
+	runner.Stop()
+	runnerStops.Add(1)
+	runnerRunning.Add(-1)
+	debugf("Runner %v stopped", id)
+}
@@ -0,0 +1,168 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+type mockRunner struct {
+	id      uint64
+	mutex   sync.Mutex
+	started bool
+	stopped bool
+}
+
+func (r *mockRunner) Start() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.started = true
+}
+
+func (r *mockRunner) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.stopped = true
+}
+
+func (r *mockRunner) ID() uint64 { return r.id }
+
+func (r *mockRunner) state() (bool, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.started, r.stopped
+}
+
+// mockFactory creates runners identified by the id setting.
+type mockFactory struct {
+	mutex   sync.Mutex
+	runners []*mockRunner
+}
+
+func (f *mockFactory) Create(c *common.Config) (cfgfile.Runner, error) {
+	id, err := c.Int("id", -1)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	runner := &mockRunner{id: uint64(id)}
+	f.runners = append(f.runners, runner)
+	return runner, nil
+}
+
+func (f *mockFactory) created() []*mockRunner {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*mockRunner{}, f.runners...)
+}
+
+// mockProvider publishes the events written to its channel.
+type mockProvider struct {
+	in     chan Event
+	events chan<- Event
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+var testEvents = make(chan Event)
+
+func init() {
+	Registry.MustAddProvider("mock", func(_ *common.Config, events chan<- Event) (Provider, error) {
+		return &mockProvider{in: testEvents, events: events, done: make(chan struct{})}, nil
+	})
+}
+
+func (p *mockProvider) Start() error {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			select {
+			case <-p.done:
+				return
+			case e := <-p.in:
+				p.events <- e
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *mockProvider) Stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+func testConfigs(t *testing.T, ids ...int) []*common.Config {
+	var configs []*common.Config
+	for _, id := range ids {
+		c, err := common.NewConfigFrom(map[string]interface{}{"id": id})
+		require.NoError(t, err)
+		configs = append(configs, c)
+	}
+	return configs
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not reached")
+}
+
+func TestAutodiscover(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"type": "mock"})
+	require.NoError(t, err)
+
+	factory := &mockFactory{}
+	autodiscover, err := NewAutodiscover(factory, &Config{Providers: []*common.Config{cfg}})
+	require.NoError(t, err)
+	require.NoError(t, autodiscover.Start())
+
+	// Both targets share runner 2
+	testEvents <- Event{ID: "a", Configs: testConfigs(t, 1, 2)}
+	testEvents <- Event{ID: "b", Configs: testConfigs(t, 2, 3)}
+	testEvents <- Event{ID: "a", Configs: testConfigs(t, 1, 2)}
+	waitFor(t, func() bool { return len(factory.created()) == 4 })
+
+	runners := factory.created()
+	for i, expected := range []bool{true, true, false, true} {
+		started, _ := runners[i].state()
+		assert.Equal(t, expected, started, "runner %v", i)
+	}
+
+	testEvents <- Event{ID: "a", Stop: true}
+	waitFor(t, func() bool {
+		_, stopped := runners[0].state()
+		return stopped
+	})
+	_, stopped := runners[1].state()
+	assert.False(t, stopped, "runner shared with target b stopped")
+
+	autodiscover.Stop()
+	for i, runner := range []*mockRunner{runners[1], runners[3]} {
+		_, stopped := runner.state()
+		assert.True(t, stopped, "runner %v", i)
+	}
+}
+
+func TestAutodiscoverUnknownProvider(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"type": "unknown"})
+	require.NoError(t, err)
+
+	_, err = NewAutodiscover(&mockFactory{}, &Config{Providers: []*common.Config{cfg}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package autodiscover
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// HintsBuilder is the name of the builder creating configs from the hints
+// found in the data of discovered targets. Each beat supporting hints
+// registers its own builder under this name.
+const HintsBuilder = "hints"
+
+// Builder creates the configs of the runners to start for a discovered
+// target from its data.
+type Builder interface {
+	CreateConfig(data common.MapStr) []*common.Config
+}
+
+// BuilderConstructor creates a builder from its config.
+type BuilderConstructor func(config *common.Config) (Builder, error)
+
+// Builders is a list of builders, all applied to the discovered targets.
+type Builders []Builder
+
+// AddBuilder registers a builder constructor under the given name.
+func (r *registry) AddBuilder(name string, constructor BuilderConstructor) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if name == "" {
+		return fmt.Errorf("builder name is required")
+	}
+	if constructor == nil {
+		return fmt.Errorf("builder '%s' cannot be registered with a nil constructor", name)
+	}
+	if _, exists := r.builders[name]; exists {
+		return fmt.Errorf("builder '%s' is already registered", name)
+	}
+
+	logp.Debug("autodiscover", "Builder registered: %s", name)
+	r.builders[name] = constructor
+	return nil
+}
+
+// MustAddBuilder registers a builder constructor, panicking on error.
+func (r *registry) MustAddBuilder(name string, constructor BuilderConstructor) {
+	if err := r.AddBuilder(name, constructor); err != nil {
+		panic(err)
+	}
+}
+
+// GetBuilder returns the builder constructor registered under name, or nil
+// if there is none.
+func (r *registry) GetBuilder(name string) BuilderConstructor {
+	r.RLock()
+	defer r.RUnlock()
+	return r.builders[name]
+}
+
+// NewHintsBuilders creates the hints builder of the beat if hints are enabled
+// in config. The hints settings are passed to the builder.
+func NewHintsBuilders(config *common.Config) (Builders, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	settings := struct {
+		Enabled bool `config:"enabled"`
+	}{}
+	if err := config.Unpack(&settings); err != nil {
+		return nil, err
+	}
+	if !settings.Enabled {
+		return nil, nil
+	}
+
+	constructor := Registry.GetBuilder(HintsBuilder)
+	if constructor == nil {
+		return nil, fmt.Errorf("hints are not supported by this beat")
+	}
+
+	builder, err := constructor(config)
+	if err != nil {
+		return nil, err
+	}
+	return Builders{builder}, nil
+}
+
+// GetConfigs returns the configs created by all builders for data, with the
+// ${data.*} variables resolved. Configs referring to missing values are
+// skipped.
+func (b Builders) GetConfigs(data common.MapStr) []*common.Config {
+	var configs []*common.Config
+	for _, builder := range b {
+		for _, c := range builder.CreateConfig(data) {
+			config, err := render(c, data)
+			if err != nil {
+				logp.Err("Error rendering autodiscover config: %v", err)
+				continue
+			}
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
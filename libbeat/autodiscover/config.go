@@ -0,0 +1,20 @@
+package autodiscover
+
+import (
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+// Config settings of the autodiscover subsystem.
+type Config struct {
+	// Providers lists the providers to discover targets with, each selected
+	// by its type setting.
+	Providers []*common.Config `config:"providers"`
+}
+
+// TemplateConfig maps the targets matching the condition to the configs of
+// the runners to start for them.
+type TemplateConfig struct {
+	Condition *processors.ConditionConfig `config:"condition"`
+	Configs   []*common.Config            `config:"config"`
+}
@@ -0,0 +1,61 @@
+package autodiscover
+
+import (
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// GenerateHints collects the hints found in labels, e.g. container labels.
+// A hint is a label named <prefix><category>/<setting>, for example
+// co.elastic.logs/multiline.pattern. The hints are returned by category,
+// with the dotted setting names expanded into nested keys.
+func GenerateHints(labels map[string]string, prefix string) common.MapStr {
+	hints := common.MapStr{}
+	for key, value := range labels {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+		hints.Put(parts[0]+"."+parts[1], value)
+	}
+	return hints
+}
+
+// GetHintString returns the value of the setting in the given category of
+// the hints found in data, or an empty string if the hint is not set.
+func GetHintString(data common.MapStr, category, setting string) string {
+	value, err := data.GetValue("hints." + category + "." + setting)
+	if err != nil {
+		return ""
+	}
+	s, _ := value.(string)
+	return strings.TrimSpace(s)
+}
+
+// GetHintList returns the comma separated values of the setting in the given
+// category of the hints found in data.
+func GetHintList(data common.MapStr, category, setting string) []string {
+	value := GetHintString(data, category, setting)
+	if value == "" {
+		return nil
+	}
+
+	var list []string
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// HasHints checks if hints of the given category are found in data.
+func HasHints(data common.MapStr, category string) bool {
+	has, _ := data.HasKey("hints." + category)
+	return has
+}
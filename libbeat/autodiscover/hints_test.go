@@ -0,0 +1,56 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestGenerateHints(t *testing.T) {
+	hints := GenerateHints(map[string]string{
+		"co.elastic.logs/multiline.pattern": "^\\[",
+		"co.elastic.logs/exclude_lines":     "^DBG, ^TRACE",
+		"co.elastic.metrics/module":         "redis",
+		"co.elastic.invalid":                "ignored",
+		"co.elastic./empty":                 "ignored",
+		"com.example.app":                   "ignored",
+	}, "co.elastic.")
+
+	assert.Equal(t, common.MapStr{
+		"logs": common.MapStr{
+			"multiline":     common.MapStr{"pattern": "^\\["},
+			"exclude_lines": "^DBG, ^TRACE",
+		},
+		"metrics": common.MapStr{"module": "redis"},
+	}, hints)
+
+	data := common.MapStr{"hints": hints}
+	assert.True(t, HasHints(data, "logs"))
+	assert.False(t, HasHints(data, "other"))
+	assert.Equal(t, "^\\[", GetHintString(data, "logs", "multiline.pattern"))
+	assert.Equal(t, "", GetHintString(data, "logs", "multiline.match"))
+	assert.Equal(t, []string{"^DBG", "^TRACE"}, GetHintList(data, "logs", "exclude_lines"))
+	assert.Nil(t, GetHintList(data, "logs", "include_lines"))
+}
+
+func TestNewHintsBuilders(t *testing.T) {
+	builders, err := NewHintsBuilders(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, builders)
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"enabled": false})
+	assert.NoError(t, err)
+	builders, err = NewHintsBuilders(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, builders)
+
+	// No hints builder registered by the tests of this package
+	cfg, err = common.NewConfigFrom(map[string]interface{}{"enabled": true})
+	assert.NoError(t, err)
+	_, err = NewHintsBuilders(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package autodiscover
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// Provider discovers targets, publishing an event whenever a target is
+// started or stopped.
+type Provider interface {
+	Start() error
+	Stop()
+}
+
+// ProviderBuilder creates a provider from its config. The provider publishes
+// its events to the events channel.
+type ProviderBuilder func(config *common.Config, events chan<- Event) (Provider, error)
+
+type registry struct {
+	sync.RWMutex
+	providers map[string]ProviderBuilder
+	builders  map[string]BuilderConstructor
+}
+
+// Registry holds the providers and builders available to autodiscover.
+var Registry = &registry{
+	providers: map[string]ProviderBuilder{},
+	builders:  map[string]BuilderConstructor{},
+}
+
+// AddProvider registers a provider builder under the given name.
+func (r *registry) AddProvider(name string, builder ProviderBuilder) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if name == "" {
+		return fmt.Errorf("provider name is required")
+	}
+	if builder == nil {
+		return fmt.Errorf("provider '%s' cannot be registered with a nil builder", name)
+	}
+	if _, exists := r.providers[name]; exists {
+		return fmt.Errorf("provider '%s' is already registered", name)
+	}
+
+	logp.Debug("autodiscover", "Provider registered: %s", name)
+	r.providers[name] = builder
+	return nil
+}
+
+// MustAddProvider registers a provider builder, panicking on error.
+func (r *registry) MustAddProvider(name string, builder ProviderBuilder) {
+	if err := r.AddProvider(name, builder); err != nil {
+		panic(err)
+	}
+}
+
+// GetProvider returns the provider builder registered under name, or nil if
+// there is none.
+func (r *registry) GetProvider(name string) ProviderBuilder {
+	r.RLock()
+	defer r.RUnlock()
+	return r.providers[name]
+}
+
+// BuildProvider creates the provider selected by the type setting of config.
+func (r *registry) BuildProvider(config *common.Config, events chan<- Event) (Provider, error) {
+	settings := struct {
+		Type string `config:"type" validate:"required"`
+	}{}
+	if err := config.Unpack(&settings); err != nil {
+		return nil, err
+	}
+
+	builder := r.GetProvider(settings.Type)
+	if builder == nil {
+		return nil, fmt.Errorf("unknown autodiscover provider type: %s", settings.Type)
+	}
+	return builder(config, events)
+}
@@ -0,0 +1,182 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// client is a minimal client of the Docker Engine API, supporting only the
+// requests required to discover containers.
+type client struct {
+	http *http.Client
+	base string
+}
+
+// container holds the details of a container used by the templates.
+type container struct {
+	ID     string
+	Name   string
+	Image  string
+	Labels map[string]string
+	IP     string
+}
+
+// event is a container event of the Docker events stream.
+type event struct {
+	Action string `json:"Action"`
+	Actor  struct {
+		ID string `json:"ID"`
+	} `json:"Actor"`
+
+	// Fields used by API versions older than 1.22
+	Status string `json:"status"`
+	ID     string `json:"id"`
+}
+
+func newClient(host string) (*client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{}
+	base := ""
+	switch u.Scheme {
+	case "unix":
+		path := u.Path
+		transport.Dial = func(_, _ string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		}
+		base = "http://docker"
+	case "tcp", "http":
+		base = "http://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme: %v", host)
+	}
+
+	return &client{
+		http: &http.Client{Transport: transport},
+		base: base,
+	}, nil
+}
+
+// listContainers returns the IDs of the running containers.
+func (c *client) listContainers(ctx context.Context) ([]string, error) {
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := c.get(ctx, "/containers/json", &containers); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	return ids, nil
+}
+
+// inspectContainer returns the details of the container with the given ID. IDs
+// reported by Docker are hex strings and need no escaping in the path.
+func (c *client) inspectContainer(ctx context.Context, id string) (*container, error) {
+	var details struct {
+		ID     string `json:"Id"`
+		Name   string `json:"Name"`
+		Config struct {
+			Image  string            `json:"Image"`
+			Labels map[string]string `json:"Labels"`
+		} `json:"Config"`
+		NetworkSettings struct {
+			IPAddress string `json:"IPAddress"`
+			Networks  map[string]struct {
+				IPAddress string `json:"IPAddress"`
+			} `json:"Networks"`
+		} `json:"NetworkSettings"`
+	}
+	if err := c.get(ctx, "/containers/"+id+"/json", &details); err != nil {
+		return nil, err
+	}
+
+	// Containers not attached to the default bridge network report their
+	// address per network only.
+	ip := details.NetworkSettings.IPAddress
+	if ip == "" {
+		var names []string
+		for name := range details.NetworkSettings.Networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ip = details.NetworkSettings.Networks[name].IPAddress; ip != "" {
+				break
+			}
+		}
+	}
+
+	return &container{
+		ID:     details.ID,
+		Name:   strings.TrimPrefix(details.Name, "/"),
+		Image:  details.Config.Image,
+		Labels: details.Config.Labels,
+		IP:     ip,
+	}, nil
+}
+
+// events opens the stream of container start and die events. The stream is
+// closed when ctx is cancelled.
+func (c *client) events(ctx context.Context) (*json.Decoder, io.Closer, error) {
+	filters := `{"type":["container"],"event":["start","die"]}`
+	resp, err := c.do(ctx, "/events?filters="+url.QueryEscape(filters))
+	if err != nil {
+		return nil, nil, err
+	}
+	return json.NewDecoder(resp.Body), resp.Body, nil
+}
+
+func (c *client) get(ctx context.Context, path string, to interface{}) error {
+	resp, err := c.do(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(to)
+}
+
+func (c *client) do(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("docker API request %v failed: %v", path, resp.Status)
+	}
+	return resp, nil
+}
+
+func (e *event) containerID() string {
+	if e.Actor.ID != "" {
+		return e.Actor.ID
+	}
+	return e.ID
+}
+
+func (e *event) action() string {
+	if e.Action != "" {
+		return e.Action
+	}
+	return e.Status
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"errors"
+	"time"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// Config settings of the docker autodiscover provider.
+type Config struct {
+	Host      string                        `config:"host" validate:"nonzero"`
+	Templates []autodiscover.TemplateConfig `config:"templates"`
+	Hints     *common.Config                `config:"hints"`
+	Backoff   Backoff                       `config:"backoff"`
+}
+
+// Backoff settings used when reconnecting to the Docker daemon.
+type Backoff struct {
+	Init time.Duration `config:"init" validate:"nonzero"`
+	Max  time.Duration `config:"max" validate:"nonzero"`
+}
+
+var defaultConfig = Config{
+	Host: "unix:///var/run/docker.sock",
+	Backoff: Backoff{
+		Init: 1 * time.Second,
+		Max:  60 * time.Second,
+	},
+}
+
+// Validate checks the backoff settings.
+func (c *Config) Validate() error {
+	if c.Backoff.Max < c.Backoff.Init {
+		return errors.New("backoff.max must be greater than or equal to backoff.init")
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+var debugf = logp.MakeDebug("autodiscover.docker")
+
+// hintsPrefix is the prefix of the container labels holding hints, e.g.
+// co.elastic.logs/multiline.pattern.
+const hintsPrefix = "co.elastic."
+
+func init() {
+	autodiscover.Registry.MustAddProvider("docker", New)
+}
+
+// Provider discovers the running containers of a Docker daemon, watching the
+// container start and die events.
+type Provider struct {
+	config   Config
+	client   *client
+	mapper   autodiscover.Mapper
+	builders autodiscover.Builders
+	events   chan<- autodiscover.Event
+
+	// running containers runners have been requested for
+	containers map[string]struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a docker autodiscover provider.
+func New(cfg *common.Config, events chan<- autodiscover.Event) (autodiscover.Provider, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	client, err := newClient(config.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, err := autodiscover.NewMapper(config.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	builders, err := autodiscover.NewHintsBuilders(config.Hints)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Provider{
+		config:     config,
+		client:     client,
+		mapper:     mapper,
+		builders:   builders,
+		events:     events,
+		containers: map[string]struct{}{},
+		ctx:        ctx,
+		cancel:     cancel,
+	}, nil
+}
+
+// Start starts watching the containers in the background.
+func (p *Provider) Start() error {
+	logp.Info("Docker autodiscover provider watching %v", p.config.Host)
+
+	p.wg.Add(1)
+	go p.run()
+	return nil
+}
+
+// Stop stops watching the containers.
+func (p *Provider) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Provider) run() {
+	defer p.wg.Done()
+
+	backoff := common.NewBackoff(p.ctx.Done(), p.config.Backoff.Init, p.config.Backoff.Max)
+	for {
+		err := p.watch()
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		logp.Err("Error watching docker containers: %v", err)
+		if !backoff.Wait() {
+			return
+		}
+	}
+}
+
+// watch syncs the running containers and then handles the container events
+// until the events stream fails.
+func (p *Provider) watch() error {
+	// The events stream is opened before listing the containers, so no
+	// container is missed. Containers started in between are reported twice
+	// and ignored the second time.
+	decoder, stream, err := p.client.events(p.ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if err := p.sync(); err != nil {
+		return err
+	}
+
+	for {
+		var e event
+		if err := decoder.Decode(&e); err != nil {
+			return err
+		}
+
+		id := e.containerID()
+		switch e.action() {
+		case "start":
+			p.start(id)
+		case "die":
+			p.stop(id)
+		}
+	}
+}
+
+// sync publishes events for the containers started or stopped while the
+// events stream was not being watched.
+func (p *Provider) sync() error {
+	ids, err := p.client.listContainers(p.ctx)
+	if err != nil {
+		return err
+	}
+
+	running := map[string]struct{}{}
+	for _, id := range ids {
+		running[id] = struct{}{}
+		p.start(id)
+	}
+
+	for id := range p.containers {
+		if _, ok := running[id]; !ok {
+			p.stop(id)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) start(id string) {
+	if _, exists := p.containers[id]; exists {
+		return
+	}
+
+	container, err := p.client.inspectContainer(p.ctx, id)
+	if err != nil {
+		logp.Err("Error inspecting docker container %v: %v", id, err)
+		return
+	}
+
+	data := common.MapStr{
+		"container": common.MapStr{
+			"id":     container.ID,
+			"name":   container.Name,
+			"image":  container.Image,
+			"labels": dedotLabels(container.Labels),
+		},
+	}
+	if container.IP != "" {
+		data["host"] = container.IP
+	}
+	if hints := autodiscover.GenerateHints(container.Labels, hintsPrefix); len(hints) > 0 {
+		data["hints"] = hints
+	}
+
+	configs := p.mapper.GetConfigs(data)
+	configs = append(configs, p.builders.GetConfigs(data)...)
+	debugf("Container %v started, %v configs matched", container.Name, len(configs))
+
+	p.containers[id] = struct{}{}
+	p.publish(autodiscover.Event{ID: id, Configs: configs})
+}
+
+func (p *Provider) stop(id string) {
+	if _, exists := p.containers[id]; !exists {
+		return
+	}
+
+	debugf("Container %v stopped", id)
+	delete(p.containers, id)
+	p.publish(autodiscover.Event{ID: id, Stop: true})
+}
+
+func (p *Provider) publish(event autodiscover.Event) {
+	select {
+	case <-p.ctx.Done():
+	case p.events <- event:
+	}
+}
+
+// dedotLabels replaces the dots in the label names by underscores, so labels
+// can be referred to in conditions and variables.
+func dedotLabels(labels map[string]string) common.MapStr {
+	m := common.MapStr{}
+	for k, v := range labels {
+		m[strings.Replace(k, ".", "_", -1)] = v
+	}
+	return m
+}
@@ -0,0 +1,302 @@
+// +build !integration
+
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// fakeDocker serves the Docker API requests used by the provider on a unix
+// socket.
+type fakeDocker struct {
+	server *httptest.Server
+	host   string
+
+	mutex      sync.Mutex
+	containers map[string]map[string]interface{}
+	events     chan map[string]interface{}
+}
+
+func newFakeDocker(t *testing.T, socket string) *fakeDocker {
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	d := &fakeDocker{
+		host:       "unix://" + socket,
+		containers: map[string]map[string]interface{}{},
+		events:     make(chan map[string]interface{}),
+	}
+	d.server = &httptest.Server{
+		Listener: listener,
+		Config:   &http.Server{Handler: http.HandlerFunc(d.handle)},
+	}
+	d.server.Start()
+	return d
+}
+
+func (d *fakeDocker) Close() {
+	d.server.CloseClientConnections()
+	d.server.Close()
+}
+
+func socketPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "autodiscover_docker")
+	require.NoError(t, err)
+	return filepath.Join(dir, "docker.sock"), func() { os.RemoveAll(dir) }
+}
+
+func (d *fakeDocker) addContainer(id, name, image string, labels map[string]string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.containers[id] = map[string]interface{}{
+		"Id":     id,
+		"Name":   "/" + name,
+		"Config": map[string]interface{}{"Image": image, "Labels": labels},
+		"NetworkSettings": map[string]interface{}{
+			"Networks": map[string]interface{}{
+				"custom": map[string]interface{}{"IPAddress": "172.18.0.2"},
+			},
+		},
+	}
+}
+
+func (d *fakeDocker) removeContainer(id string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.containers, id)
+}
+
+func (d *fakeDocker) sendEvent(action, id string) {
+	d.events <- map[string]interface{}{
+		"Type":   "container",
+		"Action": action,
+		"Actor":  map[string]interface{}{"ID": id},
+	}
+}
+
+func (d *fakeDocker) handle(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/events":
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e := <-d.events:
+				json.NewEncoder(w).Encode(e)
+				w.(http.Flusher).Flush()
+			}
+		}
+
+	case r.URL.Path == "/containers/json":
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		var list []map[string]interface{}
+		for id := range d.containers {
+			list = append(list, map[string]interface{}{"Id": id})
+		}
+		json.NewEncoder(w).Encode(list)
+
+	case strings.HasPrefix(r.URL.Path, "/containers/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/containers/"), "/json")
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		container, found := d.containers[id]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(container)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// testHintsBuilder creates a config holding the module hint and the
+// address of the container.
+type testHintsBuilder struct{}
+
+func init() {
+	autodiscover.Registry.MustAddBuilder(autodiscover.HintsBuilder, func(*common.Config) (autodiscover.Builder, error) {
+		return testHintsBuilder{}, nil
+	})
+}
+
+func (testHintsBuilder) CreateConfig(data common.MapStr) []*common.Config {
+	module := autodiscover.GetHintString(data, "metrics", "module")
+	if module == "" {
+		return nil
+	}
+	config, err := common.NewConfigFrom(map[string]interface{}{
+		"module": module,
+		"hosts":  []string{"${data.host}"},
+	})
+	if err != nil {
+		return nil
+	}
+	return []*common.Config{config}
+}
+
+func newTestProvider(t *testing.T, host string, events chan autodiscover.Event) autodiscover.Provider {
+	cfg, err := common.NewConfigWithYAML([]byte(fmt.Sprintf(`
+host: %v
+templates:
+  - condition:
+      equals.container.labels.com_example_app: web
+    config:
+      - paths: ["/var/lib/docker/containers/${data.container.id}/*.log"]
+  - condition:
+      equals.container.image: redis
+    config:
+      - module: redis
+        hosts: ["${data.container.name}:6379"]
+`, host)), "test")
+	require.NoError(t, err)
+
+	provider, err := New(cfg, events)
+	require.NoError(t, err)
+	return provider
+}
+
+func nextEvent(t *testing.T, events chan autodiscover.Event) autodiscover.Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no autodiscover event received")
+	}
+	return autodiscover.Event{}
+}
+
+func TestProvider(t *testing.T) {
+	socket, cleanup := socketPath(t)
+	defer cleanup()
+	docker := newFakeDocker(t, socket)
+	defer docker.Close()
+	docker.addContainer("abc", "web1", "nginx", map[string]string{"com.example.app": "web"})
+
+	events := make(chan autodiscover.Event)
+	provider := newTestProvider(t, docker.host, events)
+	require.NoError(t, provider.Start())
+	defer provider.Stop()
+
+	// Running container found on startup
+	e := nextEvent(t, events)
+	assert.Equal(t, "abc", e.ID)
+	assert.False(t, e.Stop)
+	require.Len(t, e.Configs, 1)
+	path, err := e.Configs[0].String("paths", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/docker/containers/abc/*.log", path)
+
+	// Container started
+	docker.addContainer("def", "cache", "redis", nil)
+	docker.sendEvent("start", "def")
+	e = nextEvent(t, events)
+	assert.Equal(t, "def", e.ID)
+	require.Len(t, e.Configs, 1)
+	host, err := e.Configs[0].String("hosts", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "cache:6379", host)
+
+	// Container stopped
+	docker.removeContainer("abc")
+	docker.sendEvent("die", "abc")
+	e = nextEvent(t, events)
+	assert.Equal(t, "abc", e.ID)
+	assert.True(t, e.Stop)
+
+	// Events of unknown containers are ignored
+	docker.sendEvent("die", "unknown")
+	docker.addContainer("ghi", "other", "busybox", nil)
+	docker.sendEvent("start", "ghi")
+	e = nextEvent(t, events)
+	assert.Equal(t, "ghi", e.ID)
+	assert.Len(t, e.Configs, 0)
+}
+
+func TestProviderReconnect(t *testing.T) {
+	socket, cleanup := socketPath(t)
+	defer cleanup()
+	docker := newFakeDocker(t, socket)
+	docker.addContainer("abc", "web1", "nginx", nil)
+
+	events := make(chan autodiscover.Event)
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"host":         docker.host,
+		"backoff.init": "10ms",
+		"backoff.max":  "10ms",
+	})
+	require.NoError(t, err)
+	provider, err := New(cfg, events)
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+	defer provider.Stop()
+
+	e := nextEvent(t, events)
+	assert.Equal(t, "abc", e.ID)
+
+	// Containers stopped while the daemon is unreachable are stopped on
+	// reconnect.
+	docker.Close()
+	restarted := newFakeDocker(t, socket)
+	defer restarted.Close()
+	restarted.addContainer("def", "web2", "nginx", nil)
+
+	received := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		e := nextEvent(t, events)
+		received[e.ID] = e.Stop
+	}
+	assert.Equal(t, map[string]bool{"abc": true, "def": false}, received)
+}
+
+func TestProviderHints(t *testing.T) {
+	socket, cleanup := socketPath(t)
+	defer cleanup()
+	docker := newFakeDocker(t, socket)
+	defer docker.Close()
+	docker.addContainer("abc", "cache", "custom-redis", map[string]string{
+		"co.elastic.metrics/module": "redis",
+	})
+
+	events := make(chan autodiscover.Event)
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"host":          docker.host,
+		"hints.enabled": true,
+	})
+	require.NoError(t, err)
+	provider, err := New(cfg, events)
+	require.NoError(t, err)
+	require.NoError(t, provider.Start())
+	defer provider.Stop()
+
+	e := nextEvent(t, events)
+	require.Len(t, e.Configs, 1)
+	config := struct {
+		Module string   `config:"module"`
+		Hosts  []string `config:"hosts"`
+	}{}
+	require.NoError(t, e.Configs[0].Unpack(&config))
+	assert.Equal(t, "redis", config.Module)
+	assert.Equal(t, []string{"172.18.0.2"}, config.Hosts)
+}
@@ -0,0 +1,76 @@
+package autodiscover
+
+import (
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+// Mapper maps the data of discovered targets to the configs of the runners
+// to start for them.
+type Mapper []*template
+
+type template struct {
+	condition *processors.Condition
+	configs   []*common.Config
+}
+
+// NewMapper creates a Mapper from a list of templates.
+func NewMapper(configs []TemplateConfig) (Mapper, error) {
+	var mapper Mapper
+	for _, c := range configs {
+		condition, err := processors.NewCondition(c.Condition)
+		if err != nil {
+			return nil, err
+		}
+		mapper = append(mapper, &template{
+			condition: condition,
+			configs:   c.Configs,
+		})
+	}
+	return mapper, nil
+}
+
+// GetConfigs returns the configs of all templates matching data. The
+// variables in the configs referring to ${data.*} are replaced by the
+// values found in data. Configs referring to missing values are skipped.
+func (m Mapper) GetConfigs(data common.MapStr) []*common.Config {
+	var configs []*common.Config
+	for _, t := range m {
+		if t.condition != nil && !t.condition.Check(data) {
+			continue
+		}
+
+		for _, c := range t.configs {
+			config, err := render(c, data)
+			if err != nil {
+				logp.Err("Error rendering autodiscover template: %v", err)
+				continue
+			}
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// render resolves the ${data.*} variables in config by merging the config
+// with the data before unpacking it.
+func render(config *common.Config, data common.MapStr) (*common.Config, error) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"data": data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Merge(config); err != nil {
+		return nil, err
+	}
+
+	var rendered map[string]interface{}
+	if err := c.Unpack(&rendered); err != nil {
+		return nil, err
+	}
+	delete(rendered, "data")
+
+	return common.NewConfigFrom(rendered)
+}
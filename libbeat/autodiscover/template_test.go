@@ -0,0 +1,93 @@
+// +build !integration
+
+package autodiscover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func newTestMapper(t *testing.T, yaml string) Mapper {
+	cfg, err := common.NewConfigWithYAML([]byte(yaml), "test")
+	require.NoError(t, err)
+
+	config := struct {
+		Templates []TemplateConfig `config:"templates"`
+	}{}
+	require.NoError(t, cfg.Unpack(&config))
+
+	mapper, err := NewMapper(config.Templates)
+	require.NoError(t, err)
+	return mapper
+}
+
+func TestMapperGetConfigs(t *testing.T) {
+	mapper := newTestMapper(t, `
+templates:
+  - condition:
+      equals.container.image: redis
+    config:
+      - module: redis
+        hosts: ["${data.container.name}:6379"]
+  - condition:
+      contains.container.labels.app: web
+    config:
+      - paths: ["/var/lib/docker/containers/${data.container.id}/*.log"]
+        fields.app: ${data.container.labels.app}
+`)
+
+	data := func(image, app string) common.MapStr {
+		return common.MapStr{
+			"container": common.MapStr{
+				"id":     "abc",
+				"name":   "container1",
+				"image":  image,
+				"labels": common.MapStr{"app": app},
+			},
+		}
+	}
+
+	configs := mapper.GetConfigs(data("redis", "none"))
+	require.Len(t, configs, 1)
+	hosts := struct {
+		Module string   `config:"module"`
+		Hosts  []string `config:"hosts"`
+	}{}
+	require.NoError(t, configs[0].Unpack(&hosts))
+	assert.Equal(t, "redis", hosts.Module)
+	assert.Equal(t, []string{"container1:6379"}, hosts.Hosts)
+	assert.False(t, configs[0].HasField("data"))
+
+	configs = mapper.GetConfigs(data("nginx", "web"))
+	require.Len(t, configs, 1)
+	paths := struct {
+		Paths []string `config:"paths"`
+		App   string   `config:"fields.app"`
+	}{}
+	require.NoError(t, configs[0].Unpack(&paths))
+	assert.Equal(t, []string{"/var/lib/docker/containers/abc/*.log"}, paths.Paths)
+	assert.Equal(t, "web", paths.App)
+
+	assert.Len(t, mapper.GetConfigs(data("nginx", "none")), 0)
+}
+
+func TestMapperMissingVariable(t *testing.T) {
+	mapper := newTestMapper(t, `
+templates:
+  - config:
+      - hosts: ["${data.container.missing}"]
+      - hosts: ["${data.container.name}"]
+`)
+
+	configs := mapper.GetConfigs(common.MapStr{
+		"container": common.MapStr{"name": "container1"},
+	})
+	require.Len(t, configs, 1)
+	hosts, err := configs[0].String("hosts", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "container1", hosts)
+}
@@ -42,17 +42,25 @@ import (
 	"strings"
 	"time"
 
+	"github.com/elastic/beats/libbeat/api"
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/dashboards/dashboards"
+	"github.com/elastic/beats/libbeat/keystore"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	report "github.com/elastic/beats/libbeat/monitoring/report/elasticsearch"
 	"github.com/elastic/beats/libbeat/paths"
 	"github.com/elastic/beats/libbeat/plugin"
 	"github.com/elastic/beats/libbeat/processors"
 	"github.com/elastic/beats/libbeat/publisher"
 	svc "github.com/elastic/beats/libbeat/service"
+	"github.com/elastic/beats/libbeat/version"
 	"github.com/satori/go.uuid"
 
+	// Register default autodiscover providers.
+	_ "github.com/elastic/beats/libbeat/autodiscover/providers/docker"
+
 	// Register default processors.
 	_ "github.com/elastic/beats/libbeat/processors/actions"
 	_ "github.com/elastic/beats/libbeat/processors/add_cloud_metadata"
@@ -99,6 +107,10 @@ type Beat struct {
 
 	SetupMLCallback SetupMLCallback // setup callback for ML job configs
 	InSetupCmd      bool            // this is set to true when the `setup` command is called
+
+	command     command  // command given on the command line
+	commandArgs []string // arguments of the command
+	keystore    keystore.Keystore
 }
 
 // BeatConfig struct contains the basic configuration of every beat
@@ -109,10 +121,15 @@ type BeatConfig struct {
 	Processors processors.PluginConfig   `config:"processors"`
 	Path       paths.Path                `config:"path"`
 	Dashboards *common.Config            `config:"dashboards"`
+	HTTP       *common.Config            `config:"http"`
+	Monitoring *common.Config            `config:"monitoring"`
+
+	// OutputReload configures the file holding the reloadable output settings.
+	OutputReload *common.Config `config:"config.outputs"`
 }
 
 var (
-	printVersion = flag.Bool("version", false, "Print the version and exit")
+	printVersion = flag.Bool("version", false, "Print the version and exit (deprecated, use the version command)")
 	setup        = flag.Bool("setup", false, "Load the sample Kibana dashboards")
 )
 
@@ -137,6 +154,8 @@ func init() {
 	}
 
 	rand.Seed(seed)
+
+	flag.Usage = usage
 }
 
 // Run initializes and runs a Beater implementation. name is the name of the
@@ -150,7 +169,7 @@ func Run(name, version string, bt Creator) error {
 // newBeat creates a new beat instance
 func newBeat(name, version string) *Beat {
 	if version == "" {
-		version = defaultBeatVersion
+		version = GetDefaultVersion()
 	}
 
 	return &Beat{
@@ -177,6 +196,11 @@ func (b *Beat) launch(bt Creator) error {
 		return err
 	}
 
+	// commands not requiring the beater exit here
+	if err := b.runCommand(b.command); err != nil {
+		return err
+	}
+
 	// load the beats config section
 	var sub *common.Config
 	configName := strings.ToLower(b.Name)
@@ -206,20 +230,59 @@ func (b *Beat) launch(bt Creator) error {
 	// defer publisher.Stop()
 
 	b.Publisher = publisher
+	b.InSetupCmd = b.command == setupCmd
 	beater, err := bt(b, sub)
 	if err != nil {
 		return err
 	}
 
-	// If -configtest was specified, exit now prior to run.
-	if cfgfile.IsTestConfig() {
+	switch b.command {
+	case testConfigCmd:
+		// The configuration is valid if the beater could be created.
 		fmt.Println("Config OK")
 		return GracefulExit
+	case setupCmd:
+		if err := b.runSetup(); err != nil {
+			return err
+		}
+		return GracefulExit
+	}
+
+	// Only one beat can use the data path at a time
+	lock, err := b.LockDataPath()
+	if err != nil {
+		return err
 	}
+	defer lock.Close()
 
 	svc.HandleSignals(beater.Stop)
 
-	err = b.loadDashboards()
+	b.registerOutputState(b.Config.Output)
+	reloader, err := b.startOutputReloader(publisher)
+	if err != nil {
+		return err
+	}
+	if reloader != nil {
+		defer reloader.Stop()
+	}
+
+	server, err := b.startAPI()
+	if err != nil {
+		return err
+	}
+	if server != nil {
+		defer server.Stop()
+	}
+
+	reporter, err := b.startMonitoring()
+	if err != nil {
+		return err
+	}
+	if reporter != nil {
+		defer reporter.Stop()
+	}
+
+	err = b.loadDashboards(*setup)
 	if err != nil {
 		return err
 	}
@@ -237,8 +300,9 @@ func (b *Beat) launch(bt Creator) error {
 	return beater.Run(b)
 }
 
-// handleFlags parses the command line flags. It handles the '-version' flag
-// and invokes the HandleFlags callback if implemented by the Beat.
+// handleFlags parses the command line flags and the command. It handles the
+// version command and invokes the HandleFlags callback if implemented by the
+// Beat.
 func (b *Beat) handleFlags() error {
 	// Due to a dependence upon the beat name, the default config file path
 	// must be updated prior to CLI flag handling.
@@ -248,9 +312,16 @@ func (b *Beat) handleFlags() error {
 	}
 	flag.Parse()
 
-	if *printVersion {
-		fmt.Printf("%s version %s (%s), libbeat %s\n",
-			b.Name, b.Version, runtime.GOARCH, defaultBeatVersion)
+	cmd, args, err := parseCommand(flag.CommandLine)
+	if err != nil {
+		flag.Usage()
+		return err
+	}
+	b.command = setLegacyCommand(cmd)
+	b.commandArgs = args
+
+	if b.command == versionCmd {
+		b.writeVersion(os.Stdout)
 		return GracefulExit
 	}
 
@@ -276,6 +347,13 @@ func (b *Beat) configure() error {
 	}
 
 	b.RawConfig = cfg
+
+	// The keystore must be opened before the config is unpacked, so settings
+	// referencing secrets can be resolved.
+	if err := b.initKeystore(cfg); err != nil {
+		return err
+	}
+
 	err = cfg.Unpack(&b.Config)
 	if err != nil {
 		return fmt.Errorf("error unpacking config data: %v", err)
@@ -286,6 +364,19 @@ func (b *Beat) configure() error {
 		return fmt.Errorf("error setting default paths: %v", err)
 	}
 
+	// The outputs configured in the reloadable outputs file replace the
+	// output section.
+	path, reload, err := publisher.OutputReloadPath(b.Config.OutputReload)
+	if err != nil {
+		return fmt.Errorf("error reading config.outputs: %v", err)
+	}
+	if reload {
+		b.Config.Output, err = publisher.LoadOutputConfigs(path)
+		if err != nil {
+			return fmt.Errorf("error loading outputs: %v", err)
+		}
+	}
+
 	err = logp.Init(b.Name, &b.Config.Logging)
 	if err != nil {
 		return fmt.Errorf("error initializing logging: %v", err)
@@ -306,9 +397,11 @@ func (b *Beat) configure() error {
 	return nil
 }
 
-func (b *Beat) loadDashboards() error {
-	if *setup {
-		// -setup implies dashboards.enabled=true
+// loadDashboards loads the Kibana dashboards if enabled in the configuration
+// or if force is set.
+func (b *Beat) loadDashboards(force bool) error {
+	if force {
+		// setup implies dashboards.enabled=true
 		if b.Config.Dashboards == nil {
 			b.Config.Dashboards = common.NewConfig()
 		}
@@ -334,6 +427,119 @@ func (b *Beat) loadDashboards() error {
 	return nil
 }
 
+// registerOutputState adds the configured outputs to the state registry.
+func (b *Beat) registerOutputState(configs map[string]*common.Config) {
+	monitoring.State.Remove("output")
+	outputs := monitoring.State.NewRegistry("output")
+	for name, config := range configs {
+		if !config.Enabled() {
+			continue
+		}
+
+		reg := outputs.NewRegistry(name)
+		if hosts, err := config.CountField("hosts"); err == nil {
+			monitoring.NewInt(reg, "hosts").Set(int64(hosts))
+		}
+	}
+}
+
+// startOutputReloader starts watching the outputs config file for changes,
+// if reloading the outputs is enabled.
+func (b *Beat) startOutputReloader(pub *publisher.BeatPublisher) (*publisher.OutputReloader, error) {
+	_, reload, err := publisher.OutputReloadPath(b.Config.OutputReload)
+	if err != nil || !reload {
+		return nil, err
+	}
+
+	reloader, err := publisher.NewOutputReloader(pub, b.Config.OutputReload, b.registerOutputState)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring output reloading: %v", err)
+	}
+
+	logp.Warn("BETA: feature output configuration reloading is enabled.")
+	reloader.Start()
+	return reloader, nil
+}
+
+// info returns the information identifying this beat instance.
+func (b *Beat) info() (api.Info, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return api.Info{}, fmt.Errorf("error getting hostname: %v", err)
+	}
+
+	name := b.Config.Shipper.Name
+	if name == "" {
+		name = hostname
+	}
+
+	return api.Info{
+		Beat:     b.Name,
+		Hostname: hostname,
+		Name:     name,
+		UUID:     b.UUID.String(),
+		Version:  b.Version,
+	}, nil
+}
+
+// startAPI starts the HTTP endpoint exposing the stats and state of the beat,
+// if enabled.
+func (b *Beat) startAPI() (*api.Server, error) {
+	if !api.Enabled(b.Config.HTTP) {
+		return nil, nil
+	}
+
+	info, err := b.info()
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := api.New(info, b.Config.HTTP)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring HTTP endpoint: %v", err)
+	}
+
+	if err := server.Start(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// startMonitoring starts reporting the internal metrics to the monitoring
+// cluster, if enabled.
+func (b *Beat) startMonitoring() (*report.Reporter, error) {
+	if b.Config.Monitoring == nil {
+		return nil, nil
+	}
+
+	config := struct {
+		Enabled       bool           `config:"enabled"`
+		Elasticsearch *common.Config `config:"elasticsearch"`
+	}{}
+	if err := b.Config.Monitoring.Unpack(&config); err != nil {
+		return nil, fmt.Errorf("error reading monitoring config: %v", err)
+	}
+	if !config.Enabled {
+		return nil, nil
+	}
+	if config.Elasticsearch == nil {
+		return nil, errors.New("monitoring.elasticsearch must be configured if monitoring is enabled")
+	}
+
+	info, err := b.info()
+	if err != nil {
+		return nil, err
+	}
+
+	reporter, err := report.New(info, config.Elasticsearch)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring monitoring: %v", err)
+	}
+
+	reporter.Start()
+	return reporter, nil
+}
+
 // handleError handles the given error by logging it and then returning the
 // error. If the err is nil or is a GracefulExit error then the method will
 // return nil without logging anything.
@@ -350,5 +556,5 @@ func handleError(err error) error {
 
 // GetDefaultVersion returns the current libbeat version.
 func GetDefaultVersion() string {
-	return defaultBeatVersion
+	return version.GetDefaultVersion()
 }
@@ -0,0 +1,358 @@
+package beat
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common/file"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/elasticsearch"
+	"github.com/elastic/beats/libbeat/paths"
+	"github.com/elastic/beats/libbeat/testing"
+	"github.com/elastic/beats/libbeat/version"
+)
+
+// command is a subcommand given on the command line after the global flags,
+// for example `metricbeat test output`.
+type command int
+
+const (
+	runCmd command = iota
+	setupCmd
+	testConfigCmd
+	testOutputCmd
+	exportConfigCmd
+	exportTemplateCmd
+	versionCmd
+	keystoreCreateCmd
+	keystoreAddCmd
+	keystoreRemoveCmd
+	keystoreListCmd
+)
+
+var commands = map[string]command{
+	"run":             runCmd,
+	"setup":           setupCmd,
+	"test config":     testConfigCmd,
+	"test output":     testOutputCmd,
+	"export config":   exportConfigCmd,
+	"export template": exportTemplateCmd,
+	"version":         versionCmd,
+	"keystore create": keystoreCreateCmd,
+	"keystore add":    keystoreAddCmd,
+	"keystore remove": keystoreRemoveCmd,
+	"keystore list":   keystoreListCmd,
+}
+
+// commandGroups are the commands requiring a subcommand.
+var commandGroups = map[string]bool{
+	"test":     true,
+	"export":   true,
+	"keystore": true,
+}
+
+// commandArgs are the commands accepting arguments.
+var commandArgs = map[command]bool{
+	keystoreAddCmd:    true,
+	keystoreRemoveCmd: true,
+}
+
+// commandFlags registers the flags only available to a command.
+var commandFlags = map[command]func(*flag.FlagSet){
+	keystoreCreateCmd: keystoreCreateFlags,
+	keystoreAddCmd:    keystoreAddFlags,
+}
+
+// BeatCommand is a command implemented by a beat, for example `filebeat registry list`.
+// Beat commands are run after the configuration has been loaded, without creating the
+// beater.
+type BeatCommand struct {
+	Usage string              // description shown in the usage
+	Args  bool                // the command accepts arguments
+	Flags func(*flag.FlagSet) // registers the flags of the command, optional
+	Run   func(b *Beat, args []string) error
+}
+
+// beatCommands are the commands registered with RegisterCommand
+var (
+	beatCommands     = map[command]BeatCommand{}
+	beatCommandNames []string
+	nextBeatCommand  = keystoreListCmd + 1
+)
+
+// RegisterCommand adds a command to the beat. It must be called before Run. A name
+// with two words, like `registry list`, adds a subcommand to the group named by
+// the first word.
+func RegisterCommand(name string, cmd BeatCommand) {
+	if _, exists := commands[name]; exists {
+		panic(fmt.Sprintf("command '%v' is already registered", name))
+	}
+
+	id := nextBeatCommand
+	nextBeatCommand++
+
+	commands[name] = id
+	beatCommands[id] = cmd
+	beatCommandNames = append(beatCommandNames, name)
+	if parts := strings.Fields(name); len(parts) > 1 {
+		commandGroups[parts[0]] = true
+	}
+	if cmd.Args {
+		commandArgs[id] = true
+	}
+	if cmd.Flags != nil {
+		commandFlags[id] = cmd.Flags
+	}
+}
+
+var esVersion = flag.String("es.version", version.GetDefaultVersion(), "Elasticsearch version used by 'export template'")
+
+// maskedValue replaces the values of secret settings in 'export config'.
+const maskedValue = "xxxxx"
+
+// parseCommand reads the command from the arguments left after parsing the
+// flags of fs. Flags following the command are parsed into fs too, and can
+// be mixed with the arguments of the command.
+func parseCommand(fs *flag.FlagSet) (command, []string, error) {
+	args := fs.Args()
+	if len(args) == 0 {
+		return runCmd, nil, nil
+	}
+
+	name := args[0]
+	n := 1
+	if commandGroups[name] {
+		if len(args) < 2 {
+			return runCmd, nil, fmt.Errorf("command '%v' requires a subcommand", name)
+		}
+		name = name + " " + args[1]
+		n = 2
+	}
+
+	cmd, found := commands[name]
+	if !found {
+		return runCmd, nil, fmt.Errorf("unknown command '%v'", name)
+	}
+
+	if register := commandFlags[cmd]; register != nil {
+		register(fs)
+	}
+
+	var cmdArgs []string
+	rest := args[n:]
+	for {
+		if err := fs.Parse(rest); err != nil {
+			return runCmd, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		cmdArgs = append(cmdArgs, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+
+	if len(cmdArgs) > 0 && !commandArgs[cmd] {
+		return runCmd, nil, fmt.Errorf("unexpected arguments %v", cmdArgs)
+	}
+	return cmd, cmdArgs, nil
+}
+
+// usage prints the commands and flags supported by all beats.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] [command] [flags]\n\n", os.Args[0])
+	fmt.Fprint(os.Stderr, `Commands:
+  run              Run the beat (default)
+  setup            Load the index template, Kibana dashboards and ML jobs
+  test config      Test the configuration
+  test output      Test the connection to the configured outputs
+  export config    Print the configuration with secrets masked
+  export template  Print the index template for -es.version
+  version          Print the version
+  keystore create  Create the keystore, -force replaces an existing keystore
+  keystore add     Add a secret to the keystore, reading the value from
+                   stdin with -stdin, -force replaces an existing secret
+  keystore remove  Remove secrets from the keystore
+  keystore list    List the keys of all secrets in the keystore
+`)
+	for _, name := range beatCommandNames {
+		// long names get their own line
+		format := "  %-16s %s\n"
+		if len(name) > 16 {
+			format = "  %s\n                   %s\n"
+		}
+		fmt.Fprintf(os.Stderr, format, name, beatCommands[commands[name]].Usage)
+	}
+	fmt.Fprint(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func (b *Beat) writeVersion(w io.Writer) {
+	fmt.Fprintf(w, "%s version %s (%s), libbeat %s\n",
+		b.Name, b.Version, runtime.GOARCH, GetDefaultVersion())
+}
+
+// testOutput tests the connection to all configured outputs. An error is
+// returned if any of the tests failed.
+func (b *Beat) testOutput() error {
+	d := testing.NewConsoleDriver(os.Stdout)
+	outputs.TestOutputs(b.Name, b.Config.Output, d)
+	if d.Failed() {
+		return errors.New("output test failed")
+	}
+	return nil
+}
+
+// exportConfig prints the configuration after merging all config files and
+// command line settings. The values of secret settings are masked.
+func (b *Beat) exportConfig(w io.Writer) error {
+	var config map[string]interface{}
+	if err := b.RawConfig.Unpack(&config); err != nil {
+		return fmt.Errorf("error unpacking config: %v", err)
+	}
+	maskSecrets(config)
+
+	content, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error converting config to YAML: %v", err)
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// exportTemplate prints the index template loaded by the Elasticsearch output
+// for the version given by -es.version.
+func (b *Beat) exportTemplate(w io.Writer) error {
+	tmpl, err := elasticsearch.ExportTemplate(b.Name, b.Config.Output["elasticsearch"], *esVersion)
+	if err != nil {
+		return fmt.Errorf("error generating template: %v", err)
+	}
+
+	content, err := json.MarshalIndent(tmpl, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
+
+// LockDataPath takes the lock on the data path held by a running beat. Commands
+// modifying the data of the beat use it to make sure the beat is not running.
+func (b *Beat) LockDataPath() (io.Closer, error) {
+	path := paths.Resolve(paths.Data, b.Name+".lock")
+	lock, err := file.Lock(path)
+	if err == file.ErrLocked {
+		return nil, fmt.Errorf("data path %s is in use by another %s process", paths.Resolve(paths.Data, ""), b.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error locking data path: %v", err)
+	}
+	return lock, nil
+}
+
+// runSetup loads the index template, the Kibana dashboards and the machine
+// learning jobs of the beat.
+func (b *Beat) runSetup() error {
+	esConfig := b.Config.Output["elasticsearch"]
+	if esConfig == nil || !esConfig.Enabled() {
+		return errors.New("setup requires the Elasticsearch output to be configured and enabled")
+	}
+
+	if err := elasticsearch.SetupTemplate(b.Name, esConfig); err != nil {
+		return fmt.Errorf("error loading template: %v", err)
+	}
+	fmt.Println("Loaded index template")
+
+	if err := b.loadDashboards(true); err != nil {
+		return err
+	}
+	fmt.Println("Loaded dashboards")
+
+	if b.SetupMLCallback != nil {
+		if err := b.SetupMLCallback(b); err != nil {
+			return err
+		}
+		fmt.Println("Loaded machine learning jobs")
+	}
+	return nil
+}
+
+// maskSecrets replaces the values of all settings holding passwords, keys or
+// tokens.
+func maskSecrets(config map[string]interface{}) {
+	for key, value := range config {
+		if isSecret(key) {
+			if value != nil {
+				config[key] = maskedValue
+			}
+			continue
+		}
+		maskSecretValue(value)
+	}
+}
+
+func maskSecretValue(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		maskSecrets(v)
+	case []interface{}:
+		for _, elem := range v {
+			maskSecretValue(elem)
+		}
+	}
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") ||
+		strings.Contains(key, "passphrase") ||
+		strings.Contains(key, "secret") ||
+		strings.HasSuffix(key, "token") ||
+		key == "api_key"
+}
+
+// runCommand executes the commands not requiring the beater to be created.
+// GracefulExit is returned if the command has been executed successfully.
+func (b *Beat) runCommand(cmd command) error {
+	var err error
+	switch cmd {
+	case testOutputCmd:
+		err = b.testOutput()
+	case exportConfigCmd:
+		err = b.exportConfig(os.Stdout)
+	case exportTemplateCmd:
+		err = b.exportTemplate(os.Stdout)
+	case keystoreCreateCmd, keystoreAddCmd, keystoreRemoveCmd, keystoreListCmd:
+		err = b.runKeystoreCommand(cmd)
+	default:
+		beatCmd, found := beatCommands[cmd]
+		if !found {
+			return nil
+		}
+		err = beatCmd.Run(b, b.commandArgs)
+	}
+
+	if err != nil {
+		return err
+	}
+	return GracefulExit
+}
+
+// setLegacyCommand selects the command given by the deprecated flags.
+func setLegacyCommand(cmd command) command {
+	switch {
+	case *printVersion:
+		return versionCmd
+	case cfgfile.IsTestConfig():
+		return testConfigCmd
+	}
+	return cmd
+}
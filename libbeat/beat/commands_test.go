@@ -0,0 +1,156 @@
+// +build !integration
+
+package beat
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected command
+	}{
+		{nil, runCmd},
+		{[]string{"-v"}, runCmd},
+		{[]string{"run"}, runCmd},
+		{[]string{"setup", "-v"}, setupCmd},
+		{[]string{"test", "config"}, testConfigCmd},
+		{[]string{"-v", "test", "output", "-v"}, testOutputCmd},
+		{[]string{"export", "config"}, exportConfigCmd},
+		{[]string{"export", "template"}, exportTemplateCmd},
+		{[]string{"version"}, versionCmd},
+	}
+
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		verbose := fs.Bool("v", false, "")
+		if assert.NoError(t, fs.Parse(test.args)) {
+			cmd, _, err := parseCommand(fs)
+			if assert.NoError(t, err, "%v", test.args) {
+				assert.Equal(t, test.expected, cmd, "%v", test.args)
+			}
+		}
+		if len(test.args) > 0 && test.args[len(test.args)-1] == "-v" {
+			assert.True(t, *verbose, "%v", test.args)
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	tests := [][]string{
+		{"unknown"},
+		{"test"},
+		{"test", "unknown"},
+		{"export", "config", "extra"},
+		{"setup", "-unknown"},
+		{"keystore", "list", "key"},
+		{"keystore", "remove", "-stdin"},
+	}
+
+	for _, args := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		if assert.NoError(t, fs.Parse(args)) {
+			_, _, err := parseCommand(fs)
+			assert.Error(t, err, "%v", args)
+		}
+	}
+}
+
+func TestParseCommandArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := fs.Bool("v", false, "")
+	if !assert.NoError(t, fs.Parse([]string{"keystore", "add", "-v", "es.password", "-stdin"})) {
+		return
+	}
+
+	cmd, args, err := parseCommand(fs)
+	if assert.NoError(t, err) {
+		assert.Equal(t, keystoreAddCmd, cmd)
+		assert.Equal(t, []string{"es.password"}, args)
+		assert.True(t, *verbose)
+		assert.True(t, *keystoreStdin)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	if !assert.NoError(t, fs.Parse([]string{"keystore", "remove", "a", "b"})) {
+		return
+	}
+
+	cmd, args, err = parseCommand(fs)
+	if assert.NoError(t, err) {
+		assert.Equal(t, keystoreRemoveCmd, cmd)
+		assert.Equal(t, []string{"a", "b"}, args)
+	}
+}
+
+func TestRegisterCommand(t *testing.T) {
+	var force bool
+	RegisterCommand("example reset", BeatCommand{
+		Args:  true,
+		Flags: func(fs *flag.FlagSet) { fs.BoolVar(&force, "force", false, "") },
+		Run:   func(b *Beat, args []string) error { return nil },
+	})
+	defer func() {
+		delete(commands, "example reset")
+		delete(commandGroups, "example")
+		beatCommandNames = nil
+	}()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if !assert.NoError(t, fs.Parse([]string{"example", "reset", "a", "-force"})) {
+		return
+	}
+
+	cmd, args, err := parseCommand(fs)
+	if assert.NoError(t, err) {
+		_, found := beatCommands[cmd]
+		assert.True(t, found)
+		assert.Equal(t, []string{"a"}, args)
+		assert.True(t, force)
+	}
+
+	assert.Panics(t, func() { RegisterCommand("example reset", BeatCommand{}) })
+}
+
+func TestMaskSecrets(t *testing.T) {
+	config := map[string]interface{}{
+		"output": map[string]interface{}{
+			"elasticsearch": map[string]interface{}{
+				"hosts":    []interface{}{"localhost:9200"},
+				"username": "elastic",
+				"password": "changeme",
+				"ssl": map[string]interface{}{
+					"key_passphrase": "passphrase",
+				},
+			},
+		},
+		"modules": []interface{}{
+			map[string]interface{}{
+				"module":        "cloud",
+				"access_token":  "token",
+				"api_key":       "key",
+				"client_secret": nil,
+			},
+		},
+	}
+
+	maskSecrets(config)
+
+	es := config["output"].(map[string]interface{})["elasticsearch"].(map[string]interface{})
+	assert.Equal(t, "elastic", es["username"])
+	assert.Equal(t, maskedValue, es["password"])
+	assert.Equal(t, []interface{}{"localhost:9200"}, es["hosts"])
+	assert.Equal(t, maskedValue, es["ssl"].(map[string]interface{})["key_passphrase"])
+
+	module := config["modules"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "cloud", module["module"])
+	assert.Equal(t, maskedValue, module["access_token"])
+	assert.Equal(t, maskedValue, module["api_key"])
+	assert.Nil(t, module["client_secret"])
+}
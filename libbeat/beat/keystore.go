@@ -0,0 +1,162 @@
+package beat
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/keystore"
+	"github.com/elastic/beats/libbeat/paths"
+)
+
+// flags of the keystore commands, registered on demand
+var (
+	keystoreForce = new(bool)
+	keystoreStdin = new(bool)
+)
+
+func keystoreCreateFlags(fs *flag.FlagSet) {
+	fs.BoolVar(keystoreForce, "force", false, "Replace an existing keystore")
+}
+
+func keystoreAddFlags(fs *flag.FlagSet) {
+	fs.BoolVar(keystoreForce, "force", false, "Replace an existing secret")
+	fs.BoolVar(keystoreStdin, "stdin", false, "Read the value from stdin")
+}
+
+// initKeystore opens the keystore and registers it to resolve variables in
+// the configuration. Secrets take precedence over environment variables.
+func (b *Beat) initKeystore(cfg *common.Config) error {
+	// the default location of the keystore depends on the data path
+	pathConfig := struct {
+		Path paths.Path `config:"path"`
+	}{}
+	if err := cfg.Unpack(&pathConfig); err != nil {
+		return fmt.Errorf("error unpacking path config: %v", err)
+	}
+	if err := paths.InitPaths(&pathConfig.Path); err != nil {
+		return fmt.Errorf("error setting default paths: %v", err)
+	}
+
+	var keystoreConfig *common.Config
+	if cfg.HasField("keystore") {
+		var err error
+		keystoreConfig, err = cfg.Child("keystore", -1)
+		if err != nil {
+			return err
+		}
+	}
+
+	store, err := keystore.Factory(keystoreConfig, paths.Resolve(paths.Data, b.Name+".keystore"))
+	if err != nil {
+		return fmt.Errorf("error loading keystore: %v", err)
+	}
+
+	b.keystore = store
+	common.AddConfigResolver(keystore.Resolver(store))
+	return nil
+}
+
+// runKeystoreCommand executes the keystore commands.
+func (b *Beat) runKeystoreCommand(cmd command) error {
+	store := b.keystore
+
+	if cmd == keystoreCreateCmd {
+		if err := store.Create(*keystoreForce); err != nil {
+			return fmt.Errorf("error creating keystore: %v", err)
+		}
+		fmt.Println("Created keystore")
+		return nil
+	}
+
+	if !store.IsPersisted() {
+		return keystore.ErrNotPersisted
+	}
+
+	switch cmd {
+	case keystoreAddCmd:
+		return b.keystoreAdd(store)
+	case keystoreRemoveCmd:
+		return b.keystoreRemove(store)
+	case keystoreListCmd:
+		keys, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+	}
+	return nil
+}
+
+func (b *Beat) keystoreAdd(store keystore.Keystore) error {
+	if len(b.commandArgs) != 1 {
+		return errors.New("keystore add requires exactly one key")
+	}
+	key := b.commandArgs[0]
+
+	if _, err := store.Retrieve(key); err == nil && !*keystoreForce {
+		return fmt.Errorf("key %v already exists, use -force to replace it", key)
+	}
+
+	value, err := readSecret(key, *keystoreStdin)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Store(key, value); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Added %v to the keystore\n", key)
+	return nil
+}
+
+func (b *Beat) keystoreRemove(store keystore.Keystore) error {
+	if len(b.commandArgs) == 0 {
+		return errors.New("keystore remove requires at least one key")
+	}
+
+	for _, key := range b.commandArgs {
+		if err := store.Delete(key); err != nil {
+			return fmt.Errorf("could not remove key %v: %v", key, err)
+		}
+	}
+	if err := store.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %v from the keystore\n", strings.Join(b.commandArgs, ", "))
+	return nil
+}
+
+// readSecret reads the value of key from stdin. Unless fromStdin is set, the
+// user is prompted for the value and only the first line is read.
+func readSecret(key string, fromStdin bool) ([]byte, error) {
+	if fromStdin {
+		value, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("could not read value from stdin: %v", err)
+		}
+		return []byte(strings.TrimRight(string(value), "\r\n")), nil
+	}
+
+	fmt.Printf("Enter value for %v: ", key)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("could not read value: %v", err)
+	}
+
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return nil, errors.New("value can not be empty")
+	}
+	return []byte(value), nil
+}
@@ -50,6 +50,17 @@ var configOpts = []ucfg.Option{
 	ucfg.VarExp,
 }
 
+// AddConfigResolver adds a callback resolving variables not defined in the
+// configuration itself. Resolvers added later take precedence over resolvers
+// added before and over the environment variables.
+func AddConfigResolver(fn func(name string) (string, error)) {
+	configOpts = append(configOpts, ucfg.Resolve(fn))
+}
+
+// ErrMissingConfigVar is returned by config resolvers if a variable is not
+// defined.
+var ErrMissingConfigVar = ucfg.ErrMissing
+
 const (
 	selectorConfig             = "config"
 	selectorConfigWithPassword = "config-with-passwords"
@@ -159,7 +170,7 @@ func NewFlagOverwrite(
 
 func LoadFile(path string) (*Config, error) {
 	if IsStrictPerms() {
-		if err := ownerHasExclusiveWritePerms(path); err != nil {
+		if err := OwnerHasExclusiveWritePerms(path); err != nil {
 			return nil, err
 		}
 	}
@@ -412,10 +423,10 @@ func filterDebugObject(c interface{}) {
 	}
 }
 
-// ownerHasExclusiveWritePerms asserts that the current user or root is the
+// OwnerHasExclusiveWritePerms asserts that the current user or root is the
 // owner of the config file and that the config file is (at most) writable by
 // the owner or root (e.g. group and other cannot have write access).
-func ownerHasExclusiveWritePerms(name string) error {
+func OwnerHasExclusiveWritePerms(name string) error {
 	if runtime.GOOS == "windows" {
 		return nil
 	}
@@ -148,3 +148,37 @@ func TestConfigFilePermissions(t *testing.T) {
 		assert.Contains(t, err.Error(), "writable")
 	}
 }
+
+func TestAddConfigResolver(t *testing.T) {
+	defaultOpts := configOpts
+	defer func() { configOpts = defaultOpts }()
+
+	os.Setenv("TEST_CONFIG_RESOLVER_PASSWORD", "from env")
+	os.Setenv("TEST_CONFIG_RESOLVER_USER", "from env")
+	defer os.Unsetenv("TEST_CONFIG_RESOLVER_PASSWORD")
+	defer os.Unsetenv("TEST_CONFIG_RESOLVER_USER")
+
+	AddConfigResolver(func(name string) (string, error) {
+		if name == "TEST_CONFIG_RESOLVER_PASSWORD" {
+			return "from resolver", nil
+		}
+		return "", ErrMissingConfigVar
+	})
+
+	cfg, err := NewConfigWithYAML([]byte(`
+password: ${TEST_CONFIG_RESOLVER_PASSWORD}
+username: ${TEST_CONFIG_RESOLVER_USER}
+`), "test")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	config := struct {
+		Password string `config:"password"`
+		Username string `config:"username"`
+	}{}
+	if assert.NoError(t, cfg.Unpack(&config)) {
+		assert.Equal(t, "from resolver", config.Password)
+		assert.Equal(t, "from env", config.Username)
+	}
+}
@@ -0,0 +1,16 @@
+package file
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrLocked is returned by Lock if the file is locked by another process.
+var ErrLocked = errors.New("file is locked by another process")
+
+// Lock creates the file if it does not exist and takes an exclusive lock on
+// it. The lock is released when the returned Closer is closed or the process
+// exits. ErrLocked is returned if another process holds the lock.
+func Lock(path string) (io.Closer, error) {
+	return lock(path)
+}
@@ -0,0 +1,36 @@
+// +build !integration
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.lock")
+	l, err := Lock(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = Lock(path)
+	assert.Equal(t, ErrLocked, err)
+
+	assert.NoError(t, l.Close())
+
+	l, err = Lock(path)
+	if assert.NoError(t, err) {
+		l.Close()
+	}
+}
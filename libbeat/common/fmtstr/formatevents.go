@@ -25,6 +25,7 @@ import (
 // Default values are given defined by the colon operator. For example:
 // `%{[field.name]:default value}`.
 type EventFormatString struct {
+	raw       string
 	formatter StringFormatter
 	fields    []fieldInfo
 	timestamp bool
@@ -124,6 +125,7 @@ func CompileEvent(in string) (*EventFormatString, error) {
 
 	ctx.keys = make([]string, len(keys))
 	efs := &EventFormatString{
+		raw:       in,
 		formatter: sf,
 		fields:    keys,
 		timestamp: efComp.timestamp,
@@ -218,6 +220,11 @@ func (fs *EventFormatString) Eval(out *bytes.Buffer, event common.MapStr) error
 	return fs.formatter.Eval(ctx, out)
 }
 
+// String returns the original format string.
+func (fs *EventFormatString) String() string {
+	return fs.raw
+}
+
 // IsConst checks the format string always returning the same constant string
 func (fs *EventFormatString) IsConst() bool {
 	return fs.formatter.IsConst()
@@ -0,0 +1,163 @@
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// unsafeFileChars matches the characters replaced in the names of the
+// exported files.
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ExportDashboardsViaKibana exports the dashboards with the given IDs from
+// Kibana into dir, using the directory layout of the beat dashboards.
+func ExportDashboardsViaKibana(config *common.Config, ids []string, dir string, msgOutputter MessageOutputter) error {
+	loader, err := newKibanaAPILoader(config, msgOutputter)
+	if err != nil {
+		return err
+	}
+	defer loader.Close()
+
+	for _, id := range ids {
+		if err := loader.ExportDashboard(id, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteDashboardsViaKibana deletes the dashboards with the given IDs from
+// Kibana. If references is set, the visualizations and searches used by the
+// dashboards are deleted as well.
+func DeleteDashboardsViaKibana(config *common.Config, ids []string, references bool, msgOutputter MessageOutputter) error {
+	loader, err := newKibanaAPILoader(config, msgOutputter)
+	if err != nil {
+		return err
+	}
+	defer loader.Close()
+
+	for _, id := range ids {
+		if err := loader.DeleteDashboard(id, references); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newKibanaAPILoader(config *common.Config, msgOutputter MessageOutputter) (*KibanaLoader, error) {
+	if config == nil {
+		config = common.NewConfig()
+	}
+
+	loader, err := NewKibanaLoader(config, nil, msgOutputter)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create the Kibana loader: %v", err)
+	}
+	if !isKibanaAPIavailable(loader.version) {
+		loader.Close()
+		return nil, fmt.Errorf("Kibana API is not available in Kibana version %s", loader.version)
+	}
+	return loader, nil
+}
+
+// ExportDashboard exports the dashboard with the given ID into dir. The
+// dashboard is written together with its visualizations and searches to
+// default/dashboard/<id>.json, each index pattern it uses to
+// default/index-pattern/<id>.json.
+func (loader KibanaLoader) ExportDashboard(id, dir string) error {
+	loader.statusMsg("Export dashboard %s", id)
+
+	exported, err := loader.client.ExportDashboard(id)
+	if err != nil {
+		return err
+	}
+
+	version := exported["version"]
+	var objects []common.MapStr
+	for _, obj := range exported["objects"].([]common.MapStr) {
+		if obj["type"] != "index-pattern" {
+			objects = append(objects, obj)
+			continue
+		}
+
+		file := filepath.Join(dir, "default", "index-pattern", exportFileName(obj["id"]))
+		err := writeExport(file, common.MapStr{
+			"version": version,
+			"objects": []common.MapStr{obj},
+		})
+		if err != nil {
+			return err
+		}
+		loader.statusMsg("Exported index pattern %v to %s", obj["id"], file)
+	}
+
+	file := filepath.Join(dir, "default", "dashboard", exportFileName(id))
+	err = writeExport(file, common.MapStr{
+		"version": version,
+		"objects": objects,
+	})
+	if err != nil {
+		return err
+	}
+	loader.statusMsg("Exported dashboard %s with %d objects to %s", id, len(objects), file)
+	return nil
+}
+
+// DeleteDashboard deletes the dashboard with the given ID. If references is
+// set, the visualizations and searches used by the dashboard are deleted
+// too. Index patterns are never deleted, as they are shared by all the
+// dashboards of the beat.
+func (loader KibanaLoader) DeleteDashboard(id string, references bool) error {
+	var objects []common.MapStr
+	if references {
+		exported, err := loader.client.ExportDashboard(id)
+		if err != nil {
+			return err
+		}
+		for _, obj := range exported["objects"].([]common.MapStr) {
+			if obj["type"] != "dashboard" && obj["type"] != "index-pattern" {
+				objects = append(objects, obj)
+			}
+		}
+	}
+
+	loader.statusMsg("Delete dashboard %s", id)
+	if err := loader.client.DeleteObject("dashboard", id); err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		objType, _ := obj["type"].(string)
+		objID, _ := obj["id"].(string)
+		loader.statusMsg("Delete %s %s", objType, objID)
+		if err := loader.client.DeleteObject(objType, objID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportFileName(id interface{}) string {
+	name := strings.TrimSuffix(fmt.Sprint(id), "*")
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "-_")
+	return name + ".json"
+}
+
+func writeExport(file string, content common.MapStr) error {
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("fail to create directory %s: %v", filepath.Dir(file), err)
+	}
+	return ioutil.WriteFile(file, append(data, '\n'), 0644)
+}
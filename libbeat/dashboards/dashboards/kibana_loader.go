@@ -2,6 +2,7 @@ package dashboards
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -48,6 +49,10 @@ func (loader KibanaLoader) ImportIndex(file string) error {
 		return fmt.Errorf("fail to read index-pattern: %v", err)
 	}
 
+	if err := loader.checkVersion(content); err != nil {
+		return err
+	}
+
 	return loader.client.ImportJSON(importAPI, params, bytes.NewBuffer(content))
 }
 
@@ -59,12 +64,46 @@ func (loader KibanaLoader) ImportDashboard(file string) error {
 	// read json file
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
-		return fmt.Errorf("fail to read index-pattern: %v", err)
+		return fmt.Errorf("fail to read dashboard: %v", err)
+	}
+
+	if err := loader.checkVersion(content); err != nil {
+		return err
 	}
 
 	return loader.client.ImportJSON(importAPI, params, bytes.NewBuffer(content))
 }
 
+// checkVersion checks the objects to import have not been exported from a
+// newer Kibana version than the one they are imported into, as Kibana cannot
+// read saved objects of newer versions.
+func (loader KibanaLoader) checkVersion(content []byte) error {
+	var exported struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(content, &exported); err != nil {
+		return fmt.Errorf("fail to parse the objects to import: %v", err)
+	}
+	if exported.Version == "" {
+		return nil
+	}
+
+	major, minor, err := getMajorAndMinorVersion(exported.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version of the objects to import: %v", err)
+	}
+	kibanaMajor, kibanaMinor, err := getMajorAndMinorVersion(loader.version)
+	if err != nil {
+		return fmt.Errorf("invalid Kibana version: %v", err)
+	}
+
+	if major > kibanaMajor || (major == kibanaMajor && minor > kibanaMinor) {
+		return fmt.Errorf("objects exported from Kibana %s cannot be imported into Kibana %s",
+			exported.Version, loader.version)
+	}
+	return nil
+}
+
 func (loader KibanaLoader) Close() error {
 	return loader.client.Close()
 }
@@ -0,0 +1,209 @@
+// +build !integration
+
+package dashboards
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// fakeKibana serves the Kibana APIs used by the Kibana loader.
+type fakeKibana struct {
+	*httptest.Server
+
+	version string
+	objects map[string][]map[string]interface{}
+
+	mutex    sync.Mutex
+	deleted  []string
+	imported int
+}
+
+func newFakeKibana(version string) *fakeKibana {
+	k := &fakeKibana{
+		version: version,
+		objects: map[string][]map[string]interface{}{
+			"dash-1": {
+				{"id": "dash-1", "type": "dashboard", "attributes": map[string]interface{}{"title": "Dash"}},
+				{"id": "vis-1", "type": "visualization", "attributes": map[string]interface{}{"title": "Vis"}},
+				{"id": "search-1", "type": "search", "attributes": map[string]interface{}{"title": "Search"}},
+				{"id": "testbeat-*", "type": "index-pattern", "attributes": map[string]interface{}{"title": "testbeat-*"}},
+			},
+		},
+	}
+	k.Server = httptest.NewServer(http.HandlerFunc(k.handle))
+	return k
+}
+
+func (k *fakeKibana) handle(w http.ResponseWriter, r *http.Request) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	switch {
+	case r.URL.Path == "/api/status":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":    "kibana",
+			"version": map[string]interface{}{"number": k.version},
+		})
+
+	case r.URL.Path == "/api/kibana/dashboards/export":
+		id := r.URL.Query().Get("dashboard")
+		objects, found := k.objects[id]
+		if !found {
+			objects = []map[string]interface{}{{
+				"id":    id,
+				"type":  "dashboard",
+				"error": map[string]interface{}{"statusCode": 404, "message": "Not Found"},
+			}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"version": k.version,
+			"objects": objects,
+		})
+
+	case r.URL.Path == "/api/kibana/dashboards/import" && r.Method == "POST":
+		k.imported++
+		w.Write([]byte("{}"))
+
+	case r.Method == "DELETE":
+		k.deleted = append(k.deleted, r.URL.Path)
+		w.Write([]byte("{}"))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (k *fakeKibana) config(t *testing.T) *common.Config {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{"host": k.URL})
+	require.NoError(t, err)
+	return cfg
+}
+
+func readExport(t *testing.T, file string) map[string]interface{} {
+	content, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+
+	var exported map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &exported))
+	return exported
+}
+
+func TestExportDashboards(t *testing.T) {
+	kibana := newFakeKibana("6.0.0")
+	defer kibana.Close()
+
+	dir, err := ioutil.TempDir("", "export_dashboards")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ExportDashboardsViaKibana(kibana.config(t), []string{"dash-1"}, dir, nil)
+	require.NoError(t, err)
+
+	dashboard := readExport(t, filepath.Join(dir, "default", "dashboard", "dash-1.json"))
+	assert.Equal(t, "6.0.0", dashboard["version"])
+	var ids []interface{}
+	for _, obj := range dashboard["objects"].([]interface{}) {
+		ids = append(ids, obj.(map[string]interface{})["id"])
+	}
+	assert.Equal(t, []interface{}{"dash-1", "vis-1", "search-1"}, ids)
+
+	pattern := readExport(t, filepath.Join(dir, "default", "index-pattern", "testbeat.json"))
+	assert.Equal(t, "6.0.0", pattern["version"])
+	require.Len(t, pattern["objects"], 1)
+	assert.Equal(t, "testbeat-*", pattern["objects"].([]interface{})[0].(map[string]interface{})["id"])
+
+	// The exported dashboard can be imported again
+	loader, err := NewKibanaLoader(kibana.config(t), &Config{}, nil)
+	require.NoError(t, err)
+	require.NoError(t, loader.ImportDashboard(filepath.Join(dir, "default", "dashboard", "dash-1.json")))
+	assert.Equal(t, 1, kibana.imported)
+}
+
+func TestExportDashboardsMissing(t *testing.T) {
+	kibana := newFakeKibana("6.0.0")
+	defer kibana.Close()
+
+	dir, err := ioutil.TempDir("", "export_dashboards")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ExportDashboardsViaKibana(kibana.config(t), []string{"missing"}, dir, nil)
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "default"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDeleteDashboards(t *testing.T) {
+	kibana := newFakeKibana("6.0.0")
+	defer kibana.Close()
+
+	err := DeleteDashboardsViaKibana(kibana.config(t), []string{"dash-1"}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/api/saved_objects/dashboard/dash-1"}, kibana.deleted)
+
+	kibana.deleted = nil
+	err = DeleteDashboardsViaKibana(kibana.config(t), []string{"dash-1"}, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/api/saved_objects/dashboard/dash-1",
+		"/api/saved_objects/visualization/vis-1",
+		"/api/saved_objects/search/search-1",
+	}, kibana.deleted)
+}
+
+func TestKibanaAPINotAvailable(t *testing.T) {
+	kibana := newFakeKibana("5.5.0")
+	defer kibana.Close()
+
+	err := DeleteDashboardsViaKibana(kibana.config(t), []string{"dash-1"}, false, nil)
+	assert.Error(t, err)
+	assert.Empty(t, kibana.deleted)
+}
+
+func TestImportVersionCheck(t *testing.T) {
+	kibana := newFakeKibana("6.1.0")
+	defer kibana.Close()
+
+	loader, err := NewKibanaLoader(kibana.config(t), &Config{}, nil)
+	require.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "import_dashboards")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := map[string]bool{
+		"":                      true,
+		"5.6.3":                 true,
+		"6.0.0-alpha2":          true,
+		"6.1.2":                 true,
+		"6.2.0":                 false,
+		"7.0.0-alpha1-SNAPSHOT": false,
+	}
+	for version, importable := range tests {
+		file := filepath.Join(dir, "dashboard.json")
+		require.NoError(t, writeExport(file, common.MapStr{
+			"version": version,
+			"objects": []common.MapStr{},
+		}))
+
+		err := loader.ImportDashboard(file)
+		if importable {
+			assert.NoError(t, err, version)
+		} else {
+			assert.Error(t, err, version)
+		}
+	}
+	assert.Equal(t, 4, kibana.imported)
+}
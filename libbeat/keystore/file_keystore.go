@@ -0,0 +1,291 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+const (
+	filePermission = 0600
+
+	// Encryption parameters, changing any of them requires a new version of
+	// the file format.
+	version         = "v1"
+	saltLength      = 64
+	iterationsCount = 10000
+	keyLength       = 32
+)
+
+// defaultPassword is used to derive the encryption key. The keystore protects
+// the secrets from being read by accident. Access to the keystore file is
+// restricted by its permissions.
+var defaultPassword = []byte("")
+
+// FileKeystore stores the secrets in a single file encrypted using AES-GCM.
+// The file starts with the version of the format on its own line, followed by
+// the salt used to derive the key, the nonce and the encrypted secrets, all
+// base64 encoded.
+type FileKeystore struct {
+	sync.RWMutex
+	path     string
+	password []byte
+	secrets  map[string][]byte
+	dirty    bool
+}
+
+// NewFileKeystore opens the keystore at path. A missing keystore is not an
+// error, IsPersisted returns false until the keystore is saved.
+func NewFileKeystore(path string) (*FileKeystore, error) {
+	return NewFileKeystoreWithPassword(path, defaultPassword)
+}
+
+// NewFileKeystoreWithPassword opens the keystore at path, deriving the
+// encryption key from password.
+func NewFileKeystoreWithPassword(path string, password []byte) (*FileKeystore, error) {
+	ks := &FileKeystore{
+		path:     path,
+		password: password,
+		secrets:  map[string][]byte{},
+	}
+
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Retrieve returns the value stored for key.
+func (k *FileKeystore) Retrieve(key string) ([]byte, error) {
+	k.RLock()
+	defer k.RUnlock()
+
+	value, found := k.secrets[key]
+	if !found {
+		return nil, ErrKeyDoesntExists
+	}
+	return value, nil
+}
+
+// Store adds or replaces the value of key.
+func (k *FileKeystore) Store(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key can not be empty")
+	}
+
+	k.Lock()
+	defer k.Unlock()
+
+	k.secrets[key] = value
+	k.dirty = true
+	return nil
+}
+
+// Delete removes key from the keystore.
+func (k *FileKeystore) Delete(key string) error {
+	k.Lock()
+	defer k.Unlock()
+
+	if _, found := k.secrets[key]; !found {
+		return ErrKeyDoesntExists
+	}
+	delete(k.secrets, key)
+	k.dirty = true
+	return nil
+}
+
+// List returns the sorted keys of all secrets.
+func (k *FileKeystore) List() ([]string, error) {
+	k.RLock()
+	defer k.RUnlock()
+
+	keys := make([]string, 0, len(k.secrets))
+	for key := range k.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Save encrypts the secrets and writes them to disk. The file is replaced
+// atomically.
+func (k *FileKeystore) Save() error {
+	k.Lock()
+	defer k.Unlock()
+
+	if !k.dirty && exists(k.path) {
+		return nil
+	}
+
+	content, err := k.encrypt()
+	if err != nil {
+		return err
+	}
+
+	tmp := k.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, filePermission); err != nil {
+		return fmt.Errorf("could not write keystore %v: %v", k.path, err)
+	}
+	// make sure the permissions are restricted even if the file existed
+	if err := os.Chmod(tmp, filePermission); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not replace keystore %v: %v", k.path, err)
+	}
+
+	k.dirty = false
+	return nil
+}
+
+// Create creates an empty keystore. An existing keystore is only replaced if
+// override is set.
+func (k *FileKeystore) Create(override bool) error {
+	if exists(k.path) && !override {
+		return fmt.Errorf("keystore %v already exists", k.path)
+	}
+
+	k.Lock()
+	k.secrets = map[string][]byte{}
+	k.dirty = true
+	k.Unlock()
+
+	return k.Save()
+}
+
+// IsPersisted returns true if the keystore file exists.
+func (k *FileKeystore) IsPersisted() bool {
+	return exists(k.path)
+}
+
+// Path returns the location of the keystore file.
+func (k *FileKeystore) Path() string {
+	return k.path
+}
+
+func (k *FileKeystore) load() error {
+	if !exists(k.path) {
+		return nil
+	}
+
+	if common.IsStrictPerms() {
+		if err := common.OwnerHasExclusiveWritePerms(k.path); err != nil {
+			return err
+		}
+	}
+
+	content, err := ioutil.ReadFile(k.path)
+	if err != nil {
+		return fmt.Errorf("could not read keystore %v: %v", k.path, err)
+	}
+
+	secrets, err := k.decrypt(content)
+	if err != nil {
+		return fmt.Errorf("could not decrypt keystore %v: %v", k.path, err)
+	}
+	k.secrets = secrets
+	return nil
+}
+
+func (k *FileKeystore) encrypt() ([]byte, error) {
+	plain, err := json.Marshal(k.secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := randomBytes(saltLength)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := k.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomBytes(aesgcm.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	data := append(salt, nonce...)
+	data = aesgcm.Seal(data, nonce, plain, nil)
+
+	buf := bytes.NewBufferString(version + "\n")
+	enc := base64.NewEncoder(base64.StdEncoding, buf)
+	enc.Write(data)
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+func (k *FileKeystore) decrypt(content []byte) (map[string][]byte, error) {
+	idx := bytes.IndexByte(content, '\n')
+	if idx < 0 || string(content[:idx]) != version {
+		return nil, errors.New("unsupported keystore format")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(content[idx+1:])))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < saltLength {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	salt := data[:saltLength]
+	aesgcm, err := k.cipher(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[saltLength:]
+	if len(data) < aesgcm.NonceSize() {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	nonce := data[:aesgcm.NonceSize()]
+	plain, err := aesgcm.Open(nil, nonce, data[aesgcm.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string][]byte{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (k *FileKeystore) cipher(salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2.Key(k.password, salt, iterationsCount, keyLength, sha512.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,139 @@
+// +build !integration
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func tempKeystorePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	require.NoError(t, err)
+	return filepath.Join(dir, "test.keystore"), func() { os.RemoveAll(dir) }
+}
+
+func TestFileKeystoreStoreAndLoad(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path)
+	require.NoError(t, err)
+	assert.False(t, ks.IsPersisted())
+
+	require.NoError(t, ks.Store("es.password", []byte("changeme")))
+	require.NoError(t, ks.Store("redis.password", []byte("secret")))
+	require.NoError(t, ks.Save())
+	assert.True(t, ks.IsPersisted())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "changeme")
+
+	ks, err = NewFileKeystore(path)
+	require.NoError(t, err)
+
+	value, err := ks.Retrieve("es.password")
+	assert.NoError(t, err)
+	assert.Equal(t, "changeme", string(value))
+
+	keys, err := ks.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"es.password", "redis.password"}, keys)
+}
+
+func TestFileKeystoreDelete(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path)
+	require.NoError(t, err)
+	require.NoError(t, ks.Store("key", []byte("value")))
+
+	assert.NoError(t, ks.Delete("key"))
+	assert.Equal(t, ErrKeyDoesntExists, ks.Delete("key"))
+
+	_, err = ks.Retrieve("key")
+	assert.Equal(t, ErrKeyDoesntExists, err)
+}
+
+func TestFileKeystoreCreate(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path)
+	require.NoError(t, err)
+	require.NoError(t, ks.Store("key", []byte("value")))
+	require.NoError(t, ks.Save())
+
+	assert.Error(t, ks.Create(false))
+	assert.NoError(t, ks.Create(true))
+
+	keys, err := ks.List()
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestFileKeystoreWrongPassword(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystoreWithPassword(path, []byte("password"))
+	require.NoError(t, err)
+	require.NoError(t, ks.Create(false))
+
+	_, err = NewFileKeystoreWithPassword(path, []byte("other"))
+	assert.Error(t, err)
+}
+
+func TestFileKeystoreStrictPerms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on windows")
+	}
+	if !common.IsStrictPerms() {
+		t.Skip("strict permission checking disabled")
+	}
+
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path)
+	require.NoError(t, err)
+	require.NoError(t, ks.Create(false))
+	require.NoError(t, os.Chmod(path, 0666))
+
+	_, err = NewFileKeystore(path)
+	assert.Error(t, err)
+}
+
+func TestResolver(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	ks, err := NewFileKeystore(path)
+	require.NoError(t, err)
+	require.NoError(t, ks.Store("password", []byte("from keystore")))
+
+	resolve := Resolver(ks)
+
+	value, err := resolve("password")
+	assert.NoError(t, err)
+	assert.Equal(t, "from keystore", value)
+
+	_, err = resolve("missing")
+	assert.Equal(t, common.ErrMissingConfigVar, err)
+}
@@ -0,0 +1,83 @@
+// Package keystore stores secrets, like passwords and tokens, in an encrypted
+// file local to the beat. Settings can reference the secrets with
+// `${name}`, the same way environment variables are referenced.
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var (
+	// ErrKeyDoesntExists is returned if the key is not stored in the keystore.
+	ErrKeyDoesntExists = errors.New("cannot retrieve the key")
+
+	// ErrNotPersisted is returned if the keystore has not been created yet.
+	ErrNotPersisted = errors.New("keystore doesn't exist, use the 'keystore create' command to create it")
+)
+
+// Config configures the location of the keystore.
+type Config struct {
+	Path string `config:"path"`
+}
+
+// Keystore stores secrets by key. Changes are only written to disk by Save.
+type Keystore interface {
+	// Retrieve returns the value stored for key.
+	Retrieve(key string) ([]byte, error)
+
+	// Store adds or replaces the value of key.
+	Store(key string, value []byte) error
+
+	// Delete removes key from the keystore.
+	Delete(key string) error
+
+	// List returns the keys of all stored secrets.
+	List() ([]string, error)
+
+	// Save writes the keystore to disk.
+	Save() error
+
+	// Create creates an empty keystore, replacing an existing one only if
+	// override is set.
+	Create(override bool) error
+
+	// IsPersisted returns true if the keystore exists on disk.
+	IsPersisted() bool
+}
+
+// Factory opens the keystore configured by cfg, using defaultPath if no path
+// is configured.
+func Factory(cfg *common.Config, defaultPath string) (Keystore, error) {
+	config := Config{}
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, fmt.Errorf("error unpacking keystore config: %v", err)
+		}
+	}
+
+	path := config.Path
+	if path == "" {
+		path = defaultPath
+	}
+	return NewFileKeystore(path)
+}
+
+// Resolver returns a config resolver looking up variables in the keystore.
+func Resolver(ks Keystore) func(string) (string, error) {
+	return func(key string) (string, error) {
+		value, err := ks.Retrieve(key)
+		if err != nil {
+			return "", common.ErrMissingConfigVar
+		}
+		return string(value), nil
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
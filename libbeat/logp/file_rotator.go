@@ -1,12 +1,15 @@
 package logp
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const RotatorMaxFiles = 1024
@@ -20,9 +23,31 @@ type FileRotator struct {
 	KeepFiles        *int
 	Permissions      *uint32
 
-	current     *os.File
-	currentSize uint64
-	currentLock sync.RWMutex
+	// RotateInterval enables time based rotation in addition to size based
+	// rotation. Files are rotated when the interval the current file was
+	// opened in has passed. Intervals are aligned to the local wall clock,
+	// intervals of 24h or more start at local midnight. Time based rotation
+	// is disabled if unset.
+	RotateInterval time.Duration
+
+	// Compress enables gzip compression of rotated files. Compressed files
+	// get the .gz extension appended. Files are compressed in the background,
+	// Close waits for the compression to finish.
+	Compress bool
+
+	current      *os.File
+	currentSize  uint64
+	currentStart time.Time
+	closed       bool
+	currentLock  sync.RWMutex
+
+	// compressing tracks the compression of the last rotated file. The
+	// error of the compression is only accessed after waiting for it.
+	compressing sync.WaitGroup
+	compressErr error
+
+	// clock used for time based rotation, can be overwritten in tests
+	now func() time.Time
 }
 
 func (rotator *FileRotator) CreateDirectory() error {
@@ -63,10 +88,18 @@ func (rotator *FileRotator) CheckIfConfigSane() error {
 	if rotator.Permissions != nil && (*rotator.Permissions > uint32(os.ModePerm)) {
 		return fmt.Errorf("the permissions mask %d is invalid", *rotator.Permissions)
 	}
+
+	if rotator.RotateInterval < 0 {
+		return fmt.Errorf("the rotate interval %v is invalid", rotator.RotateInterval)
+	}
 	return nil
 }
 
 func (rotator *FileRotator) WriteLine(line []byte) error {
+	if err := rotator.reopen(); err != nil {
+		return err
+	}
+
 	if rotator.shouldRotate() {
 		err := rotator.Rotate()
 		if err != nil {
@@ -103,19 +136,107 @@ func (rotator *FileRotator) shouldRotate() bool {
 		return true
 	}
 
+	if rotator.RotateInterval > 0 {
+		return !rotator.intervalStart(rotator.clock()).Equal(rotator.currentStart)
+	}
+
 	return false
 }
 
+// Close closes the current file and waits for the compression of the last
+// rotated file. The next call to WriteLine reopens the file and appends to
+// it, without forcing a rotation.
+func (rotator *FileRotator) Close() error {
+	rotator.currentLock.Lock()
+	defer rotator.currentLock.Unlock()
+
+	rotator.closed = true
+	rotator.compressing.Wait()
+	err := rotator.compressErr
+	rotator.compressErr = nil
+
+	if rotator.current != nil {
+		if cerr := rotator.current.Close(); err == nil {
+			err = cerr
+		}
+		rotator.current = nil
+	}
+	return err
+}
+
+func (rotator *FileRotator) reopen() error {
+	rotator.currentLock.Lock()
+	defer rotator.currentLock.Unlock()
+
+	if !rotator.closed {
+		return nil
+	}
+	rotator.closed = false
+
+	path := rotator.FilePath(0)
+	current, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, os.FileMode(rotator.getPermissions()))
+	if err != nil {
+		return err
+	}
+
+	info, err := current.Stat()
+	if err != nil {
+		current.Close()
+		return err
+	}
+
+	rotator.current = current
+	rotator.currentSize = uint64(info.Size())
+	rotator.currentStart = rotator.intervalStart(info.ModTime())
+	return nil
+}
+
+// intervalStart returns the start of the rotation interval containing t.
+// Intervals are aligned to the wall clock in the location of t, which is the
+// local time for the clock and for file modification times.
+func (rotator *FileRotator) intervalStart(t time.Time) time.Time {
+	if rotator.RotateInterval <= 0 {
+		return t
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if rotator.RotateInterval >= 24*time.Hour {
+		return midnight
+	}
+
+	sinceMidnight := t.Sub(midnight)
+	return midnight.Add(sinceMidnight - sinceMidnight%rotator.RotateInterval)
+}
+
+func (rotator *FileRotator) clock() time.Time {
+	if rotator.now != nil {
+		return rotator.now()
+	}
+	return time.Now()
+}
+
 func (rotator *FileRotator) FilePath(fileNo int) string {
 	if fileNo == 0 {
 		return filepath.Join(rotator.Path, rotator.Name)
 	}
 	filename := strings.Join([]string{rotator.Name, strconv.Itoa(fileNo)}, ".")
+	if rotator.Compress {
+		filename += ".gz"
+	}
 	return filepath.Join(rotator.Path, filename)
 }
 
 func (rotator *FileRotator) FileExists(fileNo int) bool {
-	path := rotator.FilePath(fileNo)
+	return fileExists(rotator.FilePath(fileNo))
+}
+
+// uncompressedPath returns the path of the rotated file before it is
+// compressed.
+func (rotator *FileRotator) uncompressedPath(fileNo int) string {
+	return strings.TrimSuffix(rotator.FilePath(fileNo), ".gz")
+}
+
+func fileExists(path string) bool {
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		return false
@@ -133,6 +254,19 @@ func (rotator *FileRotator) Rotate() error {
 		}
 	}
 
+	// The last rotated file is shifted below, its compression must be done
+	rotator.compressing.Wait()
+	rotator.compressErr = nil
+	if rotator.Compress {
+		// compress a file left uncompressed by a failed compression or a
+		// previous run
+		if pending := rotator.uncompressedPath(1); fileExists(pending) {
+			if err := rotator.compress(pending, rotator.FilePath(1)); err != nil {
+				return err
+			}
+		}
+	}
+
 	// delete any extra files, normally we shouldn't have any
 	for fileNo := *rotator.KeepFiles; fileNo < RotatorMaxFiles; fileNo++ {
 		if rotator.FileExists(fileNo) {
@@ -156,8 +290,23 @@ func (rotator *FileRotator) Rotate() error {
 			return fmt.Errorf("file %s exists, when rotating would overwrite it", rotator.FilePath(fileNo+1))
 		}
 
-		err := os.Rename(path, rotator.FilePath(fileNo+1))
-		if err != nil {
+		if fileNo == 0 && rotator.Compress {
+			// compress the file in the background, not blocking writes to
+			// the new file
+			pending := rotator.uncompressedPath(fileNo + 1)
+			if err := os.Rename(path, pending); err != nil {
+				return err
+			}
+
+			rotator.compressing.Add(1)
+			go func(src, dst string) {
+				defer rotator.compressing.Done()
+				rotator.compressErr = rotator.compress(src, dst)
+			}(pending, rotator.FilePath(fileNo+1))
+			continue
+		}
+
+		if err := os.Rename(path, rotator.FilePath(fileNo+1)); err != nil {
 			return err
 		}
 	}
@@ -170,6 +319,10 @@ func (rotator *FileRotator) Rotate() error {
 	}
 	rotator.current = current
 	rotator.currentSize = 0
+	rotator.closed = false
+	if rotator.RotateInterval > 0 {
+		rotator.currentStart = rotator.intervalStart(rotator.clock())
+	}
 
 	// delete the extra file, ignore errors here
 	path = rotator.FilePath(*rotator.KeepFiles)
@@ -178,6 +331,35 @@ func (rotator *FileRotator) Rotate() error {
 	return nil
 }
 
+// compress writes a gzip compressed copy of src to dst and removes src.
+func (rotator *FileRotator) compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(rotator.getPermissions()))
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
+
 func (rotator *FileRotator) getPermissions() uint32 {
 	if rotator.Permissions == nil {
 		return 0600
@@ -4,10 +4,12 @@ package logp
 
 import (
 	"bytes"
+	"compress/gzip"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -203,3 +205,184 @@ func TestRaceConditions(t *testing.T) {
 		go rotator.WriteLine([]byte(string(i)))
 	}
 }
+
+func TestRotatorByInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2017, 1, 1, 10, 15, 0, 0, time.Local)
+	keepfiles := 3
+	rotator := FileRotator{
+		Path:           dir,
+		Name:           "testbeat",
+		KeepFiles:      &keepfiles,
+		RotateInterval: time.Hour,
+		now:            func() time.Time { return now },
+	}
+	if err := rotator.CheckIfConfigSane(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, rotator.WriteLine([]byte("1")))
+	now = now.Add(30 * time.Minute)
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	assert.False(t, rotator.FileExists(1))
+
+	now = now.Add(30 * time.Minute)
+	assert.NoError(t, rotator.WriteLine([]byte("3")))
+	assert.True(t, rotator.FileExists(1))
+
+	file0, err := ioutil.ReadFile(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "3\n", string(file0))
+
+	file1, err := ioutil.ReadFile(rotator.FilePath(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n", string(file1))
+}
+
+func TestRotatorDailyAlignsToMidnight(t *testing.T) {
+	rotator := FileRotator{RotateInterval: 24 * time.Hour}
+
+	start := rotator.intervalStart(time.Date(2017, 1, 1, 23, 59, 0, 0, time.Local))
+	assert.Equal(t, time.Date(2017, 1, 1, 0, 0, 0, 0, time.Local), start)
+
+	// not aligned to midnight UTC
+	ist := time.FixedZone("IST", 5*3600+1800)
+	start = rotator.intervalStart(time.Date(2017, 1, 1, 2, 0, 0, 0, ist))
+	assert.Equal(t, time.Date(2017, 1, 1, 0, 0, 0, 0, ist), start)
+}
+
+func TestRotatorHourlyAlignsToLocalHours(t *testing.T) {
+	rotator := FileRotator{RotateInterval: time.Hour}
+
+	ist := time.FixedZone("IST", 5*3600+1800)
+	start := rotator.intervalStart(time.Date(2017, 1, 1, 10, 45, 0, 0, ist))
+	assert.Equal(t, time.Date(2017, 1, 1, 10, 0, 0, 0, ist), start)
+}
+
+func TestRotatorCompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keepfiles := 3
+	rotator := FileRotator{
+		Path:      dir,
+		Name:      "testbeat",
+		KeepFiles: &keepfiles,
+		Compress:  true,
+	}
+	if err := rotator.CheckIfConfigSane(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"1", "2", "3", "4"} {
+		assert.NoError(t, rotator.WriteLine([]byte(line)))
+		assert.NoError(t, rotator.Rotate())
+	}
+	// wait for the compression of the last rotated file
+	assert.NoError(t, rotator.Close())
+
+	assert.Equal(t, filepath.Join(dir, "testbeat.1.gz"), rotator.FilePath(1))
+	assert.True(t, rotator.FileExists(2))
+	assert.False(t, rotator.FileExists(3))
+	_, err = os.Stat(filepath.Join(dir, "testbeat.1"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "4\n", readGzip(t, rotator.FilePath(1)))
+}
+
+func TestRotatorCompressLeftoverFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// rotated file not compressed by a previous run
+	if err := ioutil.WriteFile(filepath.Join(dir, "testbeat.1"), []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keepfiles := 5
+	rotator := FileRotator{
+		Path:      dir,
+		Name:      "testbeat",
+		KeepFiles: &keepfiles,
+		Compress:  true,
+	}
+	if err := rotator.CheckIfConfigSane(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, rotator.WriteLine([]byte("new")))
+	assert.NoError(t, rotator.Rotate())
+	assert.NoError(t, rotator.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "testbeat.1"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "new\n", readGzip(t, rotator.FilePath(1)))
+	// shifted by the rotation on the first write and the explicit rotation
+	assert.Equal(t, "old\n", readGzip(t, rotator.FilePath(3)))
+}
+
+func readGzip(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestRotatorReopenAppends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test_rotator_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rotator := FileRotator{
+		Path: dir,
+		Name: "testbeat",
+	}
+	if err := rotator.CheckIfConfigSane(); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, rotator.WriteLine([]byte("1")))
+	assert.NoError(t, rotator.Close())
+	assert.NoError(t, rotator.WriteLine([]byte("2")))
+	assert.NoError(t, rotator.Close())
+
+	// A new rotator which was closed before the first write appends too
+	other := FileRotator{
+		Path: dir,
+		Name: "testbeat",
+	}
+	if err := other.CheckIfConfigSane(); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, other.Close())
+	assert.NoError(t, other.WriteLine([]byte("3")))
+	assert.NoError(t, other.Close())
+
+	assert.False(t, rotator.FileExists(1))
+	file0, err := ioutil.ReadFile(rotator.FilePath(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n2\n3\n", string(file0))
+}
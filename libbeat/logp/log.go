@@ -1,9 +1,12 @@
 package logp
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 	"runtime/debug"
 	"time"
 )
@@ -23,10 +26,25 @@ const (
 	LOG_DEBUG
 )
 
+var levelNames = [LOG_DEBUG + 1]string{
+	LOG_EMERG:   "emergency",
+	LOG_ALERT:   "alert",
+	LOG_CRIT:    "critical",
+	LOG_ERR:     "error",
+	LOG_WARNING: "warning",
+	LOG_NOTICE:  "notice",
+	LOG_INFO:    "info",
+	LOG_DEBUG:   "debug",
+}
+
+// jsonTimeFormat is the format of the timestamps in JSON mode.
+const jsonTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
 type Logger struct {
 	toSyslog          bool
 	toStderr          bool
 	toFile            bool
+	json              bool
 	level             Priority
 	selectors         map[string]struct{}
 	debugAllSelectors bool
@@ -73,11 +91,23 @@ func parseSelectors(selectors []string) (map[string]struct{}, bool) {
 
 func debugMessage(calldepth int, selector, format string, v ...interface{}) {
 	if _log.level >= LOG_DEBUG && IsDebug(selector) {
-		send(calldepth+1, LOG_DEBUG, "DBG  ", format, v...)
+		send(calldepth+1, LOG_DEBUG, selector, nil, "DBG  ", format, v...)
 	}
 }
 
-func send(calldepth int, level Priority, prefix string, format string, v ...interface{}) {
+func send(
+	calldepth int,
+	level Priority,
+	selector string,
+	fields map[string]interface{},
+	prefix string,
+	format string, v ...interface{},
+) {
+	if _log.json {
+		sendJSON(calldepth+1, level, selector, fields, fmt.Sprintf(format, v...))
+		return
+	}
+
 	if _log.toSyslog {
 		_log.syslog[level].Output(calldepth, fmt.Sprintf(format, v...))
 	}
@@ -91,6 +121,50 @@ func send(calldepth int, level Priority, prefix string, format string, v ...inte
 	}
 }
 
+// sendJSON writes the message as a single line JSON object to all outputs.
+// The fields are added to the object next to the timestamp, level, selector,
+// caller and message.
+func sendJSON(
+	calldepth int,
+	level Priority,
+	selector string,
+	fields map[string]interface{},
+	message string,
+) {
+	obj := make(map[string]interface{}, len(fields)+5)
+	for k, v := range fields {
+		obj[k] = v
+	}
+	obj["@timestamp"] = time.Now().UTC().Format(jsonTimeFormat)
+	obj["level"] = levelNames[level]
+	obj["message"] = message
+	if selector != "" {
+		obj["selector"] = selector
+	}
+	if _, file, line, ok := runtime.Caller(calldepth - 1); ok {
+		obj["caller"] = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	line, err := json.Marshal(obj)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{
+			"@timestamp": obj["@timestamp"],
+			"level":      levelNames[LOG_ERR],
+			"message":    fmt.Sprintf("failed to encode log message '%s': %v", message, err),
+		})
+	}
+
+	if _log.toSyslog {
+		_log.syslog[level].Output(calldepth, string(line))
+	}
+	if _log.toStderr {
+		_log.logger.Output(calldepth, string(line))
+	}
+	if _log.toFile {
+		_log.rotator.WriteLine(line)
+	}
+}
+
 func Debug(selector string, format string, v ...interface{}) {
 	debugMessage(3, selector, format, v...)
 }
@@ -112,7 +186,7 @@ func HasSelector(selector string) bool {
 
 func msg(level Priority, prefix string, format string, v ...interface{}) {
 	if _log.level >= level {
-		send(4, level, prefix, format, v...)
+		send(4, level, "", nil, prefix, format, v...)
 	}
 }
 
@@ -120,6 +194,14 @@ func Info(format string, v ...interface{}) {
 	msg(LOG_INFO, "INFO ", format, v...)
 }
 
+// infoFields logs the message at INFO level with structured key/value
+// fields. The fields are only reported in JSON mode.
+func infoFields(fields map[string]interface{}, format string, v ...interface{}) {
+	if _log.level >= LOG_INFO {
+		send(3, LOG_INFO, "", fields, "INFO ", format, v...)
+	}
+}
+
 func Warn(format string, v ...interface{}) {
 	msg(LOG_WARNING, "WARN ", format, v...)
 }
@@ -149,6 +231,12 @@ func Recover(msg string) {
 func SetToStderr(toStderr bool, prefix string) {
 	_log.toStderr = toStderr
 	if _log.toStderr {
+		if _log.json {
+			// the timestamp and caller are part of the JSON object
+			_log.logger = log.New(os.Stderr, "", 0)
+			return
+		}
+
 		// Add timestamp
 		_log.logger = log.New(os.Stderr, prefix, stderrLogFlags)
 	}
@@ -164,6 +252,9 @@ func SetToSyslog(toSyslog bool, prefix string) {
 				_log.toSyslog = false
 				break
 			}
+			if _log.json {
+				_log.syslog[prio].SetFlags(0)
+			}
 		}
 	}
 }
@@ -0,0 +1,86 @@
+// +build !integration
+
+package logp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withJSONFileLogger configures JSON logging to a file for the duration of
+// the test function, returning the decoded log lines.
+func withJSONFileLogger(t *testing.T, test func()) []map[string]interface{} {
+	dir, err := ioutil.TempDir("", "test_json_log_")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	saved := _log
+	defer func() { _log = saved }()
+
+	_log = Logger{json: true}
+	LogInit(LOG_DEBUG, "", false, false, []string{"test"})
+	rotator := &FileRotator{Path: dir, Name: "test"}
+	require.NoError(t, SetToFile(true, rotator))
+
+	test()
+	require.NoError(t, rotator.Close())
+
+	f, err := os.Open(filepath.Join(dir, "test"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestJSONLogging(t *testing.T) {
+	lines := withJSONFileLogger(t, func() {
+		Info("hello %s", "world")
+		Debug("test", "debug %d", 1)
+		Debug("other", "not logged")
+		Err("failed")
+	})
+	require.Len(t, lines, 3)
+
+	info := lines[0]
+	assert.Equal(t, "info", info["level"])
+	assert.Equal(t, "hello world", info["message"])
+	assert.Regexp(t, `^log_test\.go:\d+$`, info["caller"])
+	assert.NotContains(t, info, "selector")
+	_, err := time.Parse(jsonTimeFormat, info["@timestamp"].(string))
+	assert.NoError(t, err)
+
+	debug := lines[1]
+	assert.Equal(t, "debug", debug["level"])
+	assert.Equal(t, "test", debug["selector"])
+	assert.Equal(t, "debug 1", debug["message"])
+	assert.Regexp(t, `^log_test\.go:\d+$`, debug["caller"])
+
+	assert.Equal(t, "error", lines[2]["level"])
+}
+
+func TestJSONLoggingFields(t *testing.T) {
+	lines := withJSONFileLogger(t, func() {
+		infoFields(map[string]interface{}{
+			"metrics": map[string]interface{}{"libbeat.test": 5},
+		}, "Non-zero metrics in the last %s", "30s")
+	})
+	require.Len(t, lines, 1)
+
+	assert.Equal(t, "Non-zero metrics in the last 30s", lines[0]["message"])
+	assert.Equal(t, map[string]interface{}{"libbeat.test": float64(5)}, lines[0]["metrics"])
+}
@@ -28,6 +28,7 @@ type Logging struct {
 	ToSyslog  *bool `config:"to_syslog"`
 	ToFiles   *bool `config:"to_files"`
 	Level     string
+	JSON      bool                 `config:"json"`
 	Metrics   LoggingMetricsConfig `config:"metrics"`
 }
 
@@ -80,7 +81,7 @@ func HandleFlags(name string) error {
 // line flag with a later SetStderr call.
 func Init(name string, config *Logging) error {
 	// reset settings from HandleFlags
-	_log = Logger{}
+	_log = Logger{json: config.JSON}
 
 	logLevel, err := getLogLevel(config)
 	if err != nil {
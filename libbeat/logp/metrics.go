@@ -35,6 +35,12 @@ func logMetrics(metricsCfg *LoggingMetricsConfig) {
 			continue
 		}
 
+		if _log.json {
+			infoFields(map[string]interface{}{"metrics": delta},
+				"Non-zero metrics in the last %s", metricsCfg.Period)
+			continue
+		}
+
 		metrics := formatMetrics(delta)
 		Info("Non-zero metrics in the last %s:%s", metricsCfg.Period, metrics)
 	}
@@ -47,8 +53,12 @@ func LogTotalExpvars(cfg *Logging) {
 	}
 
 	zero := monitoring.MakeFlatSnapshot()
-	metrics := formatMetrics(snapshotDelta(zero, snapshotMetrics()))
-	Info("Total non-zero values: %s", metrics)
+	delta := snapshotDelta(zero, snapshotMetrics())
+	if _log.json {
+		infoFields(map[string]interface{}{"metrics": delta}, "Total non-zero values")
+	} else {
+		Info("Total non-zero values: %s", formatMetrics(delta))
+	}
 	Info("Uptime: %s", time.Now().Sub(startTime))
 }
 
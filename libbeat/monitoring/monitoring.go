@@ -15,6 +15,10 @@ const (
 // Default is the global default metrics registry provided by the monitoring package.
 var Default = NewRegistry()
 
+// State is the global registry holding the state of the beat derived from its
+// configuration, for example the configured outputs and modules.
+var State = NewRegistry()
+
 var errNotFound = errors.New("Name unknown")
 var errInvalidName = errors.New("Name does not point to a valid variable")
 
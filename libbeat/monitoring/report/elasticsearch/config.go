@@ -0,0 +1,40 @@
+package elasticsearch
+
+import (
+	"errors"
+	"time"
+)
+
+// config holds the reporter settings. The connection settings (hosts,
+// protocol, credentials, TLS, proxy and timeout) are read by the Elasticsearch
+// output from the same section.
+type config struct {
+	Period      time.Duration `config:"period" validate:"nonzero"`
+	Index       string        `config:"index" validate:"nonzero"`
+	BulkMaxSize int           `config:"bulk_max_size" validate:"min=1"`
+	Timeout     time.Duration `config:"timeout" validate:"nonzero"`
+	Backoff     backoffConfig `config:"backoff"`
+}
+
+type backoffConfig struct {
+	Init time.Duration `config:"init" validate:"nonzero"`
+	Max  time.Duration `config:"max" validate:"nonzero"`
+}
+
+var defaultConfig = config{
+	Period:      10 * time.Second,
+	Index:       ".monitoring-beats",
+	BulkMaxSize: 50,
+	Timeout:     60 * time.Second,
+	Backoff: backoffConfig{
+		Init: 1 * time.Second,
+		Max:  60 * time.Second,
+	},
+}
+
+func (c *config) Validate() error {
+	if c.Backoff.Max < c.Backoff.Init {
+		return errors.New("backoff.max must not be less than backoff.init")
+	}
+	return nil
+}
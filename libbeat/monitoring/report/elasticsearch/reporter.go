@@ -0,0 +1,180 @@
+// Package elasticsearch implements a reporter periodically indexing snapshots
+// of the beat's internal metrics into a dedicated Elasticsearch cluster. The
+// cluster is configured independently of the outputs of the beat, such that
+// the health of the publishing pipeline can be monitored even if the outputs
+// are blocked.
+package elasticsearch
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/api"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
+	esout "github.com/elastic/beats/libbeat/outputs/elasticsearch"
+)
+
+const docType = "doc"
+
+var debugf = logp.MakeDebug("monitoring")
+
+// Reporter indexes a document with a snapshot of all metrics of the
+// monitoring.Default registry every period. Documents failing to be indexed
+// are kept and sent with the next bulk request, up to bulk_max_size documents.
+type Reporter struct {
+	config config
+	info   api.Info
+
+	clients   []*esout.Client
+	current   int
+	connected bool
+	pending   []document
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type document struct {
+	index string
+	event common.MapStr
+}
+
+// New creates a reporter for the Elasticsearch cluster configured in cfg.
+// The documents identify the beat instance using info.
+func New(info api.Info, cfg *common.Config) (*Reporter, error) {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	clients, err := esout.NewElasticsearchClients(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reporter{
+		config: config,
+		info:   info,
+		done:   make(chan struct{}),
+	}
+	for i := range clients {
+		r.clients = append(r.clients, &clients[i])
+	}
+	return r, nil
+}
+
+// Start starts reporting the metrics in the background.
+func (r *Reporter) Start() {
+	logp.Info("Start monitoring metrics reporting every %v", r.config.Period)
+
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop stops reporting and waits for the reporter to finish.
+func (r *Reporter) Stop() {
+	close(r.done)
+	r.wg.Wait()
+	logp.Info("Stopped monitoring metrics reporting")
+}
+
+func (r *Reporter) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.Period)
+	defer ticker.Stop()
+
+	backoff := common.NewBackoff(r.done, r.config.Backoff.Init, r.config.Backoff.Max)
+	for {
+		select {
+		case <-r.done:
+			return
+		case ts := <-ticker.C:
+			r.add(r.snapshot(ts))
+		}
+
+		err := r.publish()
+		if err != nil {
+			logp.Err("Failed to report monitoring metrics: %v", err)
+		}
+		if !backoff.WaitOnError(err) {
+			return
+		}
+	}
+}
+
+// snapshot creates the monitoring document for the current metrics.
+func (r *Reporter) snapshot(ts time.Time) document {
+	ts = ts.UTC()
+	return document{
+		index: fmt.Sprintf("%s-%s", r.config.Index, ts.Format("2006.01.02")),
+		event: common.MapStr{
+			"@timestamp": common.Time(ts),
+			"type":       "beats_stats",
+			"beat":       r.info,
+			"metrics":    monitoring.CollectStructSnapshot(monitoring.Default, monitoring.Full, true),
+		},
+	}
+}
+
+// add adds doc to the pending documents, dropping the oldest documents if
+// bulk_max_size is exceeded.
+func (r *Reporter) add(doc document) {
+	r.pending = append(r.pending, doc)
+	if dropped := len(r.pending) - r.config.BulkMaxSize; dropped > 0 {
+		logp.Warn("Dropping %v monitoring documents not reported yet", dropped)
+		r.pending = r.pending[dropped:]
+	}
+}
+
+// publish sends all pending documents in a single bulk request. On
+// connection failures the next host is used by the following request.
+func (r *Reporter) publish() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	client := r.clients[r.current]
+	if !r.connected {
+		if err := client.Connect(r.config.Timeout); err != nil {
+			r.failover()
+			return err
+		}
+		r.connected = true
+	}
+
+	body := make([]interface{}, 0, 2*len(r.pending))
+	for _, doc := range r.pending {
+		meta := common.MapStr{
+			"index": common.MapStr{
+				"_index": doc.index,
+				"_type":  docType,
+			},
+		}
+		body = append(body, meta, doc.event)
+	}
+
+	debugf("Reporting %v monitoring documents to %v", len(r.pending), client.URL)
+	result, err := client.BulkWith("", "", nil, nil, body)
+	if err != nil {
+		r.failover()
+		return err
+	}
+
+	// Documents rejected by Elasticsearch are not retried, as they would be
+	// rejected again.
+	count := len(r.pending)
+	r.pending = nil
+	if result != nil && result.Errors {
+		return fmt.Errorf("failed to index some of %v monitoring documents", count)
+	}
+	return nil
+}
+
+func (r *Reporter) failover() {
+	r.connected = false
+	r.current = (r.current + 1) % len(r.clients)
+}
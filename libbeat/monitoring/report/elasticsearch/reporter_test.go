@@ -0,0 +1,202 @@
+// +build !integration
+
+package elasticsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/api"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var testInfo = api.Info{
+	Beat:    "testbeat",
+	Name:    "test",
+	UUID:    "1234",
+	Version: "1.0.0",
+}
+
+// esServer mocks the Elasticsearch endpoints used by the reporter, recording
+// the lines of all bulk requests.
+type esServer struct {
+	*httptest.Server
+
+	mutex  sync.Mutex
+	fail   bool
+	errors bool
+	lines  []map[string]interface{}
+}
+
+func newESServer() *esServer {
+	s := &esServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *esServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch r.URL.Path {
+	case "/":
+		w.Write([]byte(`{"version": {"number": "5.6.0"}}`))
+	case "/_bulk":
+		if s.fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			json.Unmarshal(scanner.Bytes(), &line)
+			s.lines = append(s.lines, line)
+		}
+
+		if s.errors {
+			w.Write([]byte(`{"errors": true, "items": []}`))
+		} else {
+			w.Write([]byte(`{"errors": false, "items": []}`))
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *esServer) received() []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lines
+}
+
+func newTestReporter(t *testing.T, settings map[string]interface{}) *Reporter {
+	cfg, err := common.NewConfigFrom(settings)
+	require.NoError(t, err)
+
+	r, err := New(testInfo, cfg)
+	require.NoError(t, err)
+	return r
+}
+
+func TestReporterPublish(t *testing.T) {
+	s := newESServer()
+	defer s.Close()
+
+	r := newTestReporter(t, map[string]interface{}{
+		"hosts": []string{s.URL},
+		"index": "monitoring",
+	})
+
+	ts := time.Date(2017, 6, 1, 10, 0, 0, 0, time.UTC)
+	r.add(r.snapshot(ts))
+	require.NoError(t, r.publish())
+	assert.Empty(t, r.pending)
+
+	lines := s.received()
+	require.Len(t, lines, 2)
+	assert.Equal(t, map[string]interface{}{
+		"index": map[string]interface{}{
+			"_index": "monitoring-2017.06.01",
+			"_type":  "doc",
+		},
+	}, lines[0])
+
+	doc := lines[1]
+	assert.Equal(t, "beats_stats", doc["type"])
+	assert.Equal(t, "2017-06-01T10:00:00.000Z", doc["@timestamp"])
+	assert.Equal(t, "1234", doc["beat"].(map[string]interface{})["uuid"])
+	assert.Contains(t, doc, "metrics")
+}
+
+func TestReporterRetry(t *testing.T) {
+	s := newESServer()
+	defer s.Close()
+	s.fail = true
+
+	r := newTestReporter(t, map[string]interface{}{
+		"hosts":         []string{s.URL},
+		"bulk_max_size": 2,
+	})
+
+	for i := 0; i < 3; i++ {
+		r.add(r.snapshot(time.Now()))
+		assert.Error(t, r.publish())
+	}
+	assert.Len(t, r.pending, 2)
+
+	s.mutex.Lock()
+	s.fail = false
+	s.mutex.Unlock()
+
+	require.NoError(t, r.publish())
+	assert.Len(t, s.received(), 4)
+	assert.Empty(t, r.pending)
+}
+
+func TestReporterDropsRejectedDocuments(t *testing.T) {
+	s := newESServer()
+	defer s.Close()
+	s.errors = true
+
+	r := newTestReporter(t, map[string]interface{}{
+		"hosts": []string{s.URL},
+	})
+
+	r.add(r.snapshot(time.Now()))
+	assert.Error(t, r.publish())
+	assert.Empty(t, r.pending)
+}
+
+func TestReporterFailover(t *testing.T) {
+	s := newESServer()
+	defer s.Close()
+
+	r := newTestReporter(t, map[string]interface{}{
+		"hosts":   []string{"http://127.0.0.1:1", s.URL},
+		"timeout": "1s",
+	})
+
+	r.add(r.snapshot(time.Now()))
+	assert.Error(t, r.publish())
+	assert.NoError(t, r.publish())
+	assert.Len(t, s.received(), 2)
+}
+
+func TestReporterStartStop(t *testing.T) {
+	s := newESServer()
+	defer s.Close()
+
+	r := newTestReporter(t, map[string]interface{}{
+		"hosts":  []string{s.URL},
+		"period": "10ms",
+	})
+
+	r.Start()
+	for i := 0; i < 100 && len(s.received()) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.Stop()
+
+	assert.NotEmpty(t, s.received())
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"hosts":       []string{"localhost:9200"},
+		"backoff.max": "1ms",
+	})
+	require.NoError(t, err)
+
+	_, err = New(testInfo, cfg)
+	assert.Error(t, err)
+}
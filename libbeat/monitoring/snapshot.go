@@ -79,3 +79,73 @@ func (vs *snapshotVisitor) OnInt(i int64) {
 func (vs *snapshotVisitor) OnFloat(f float64) {
 	vs.snapshot.Floats[vs.getName()] = f
 }
+
+type structSnapshotVisitor struct {
+	current map[string]interface{}
+	stack   []map[string]interface{}
+	key     string
+	hasKey  bool
+}
+
+// CollectStructSnapshot collects a snapshot of a metrics tree starting with
+// the given registry. Sub-registries are reported as nested maps.
+func CollectStructSnapshot(r *Registry, mode Mode, expvar bool) map[string]interface{} {
+	vs := &structSnapshotVisitor{current: map[string]interface{}{}}
+	r.Visit(mode, vs)
+	if expvar {
+		VisitExpvars(vs)
+	}
+	return vs.current
+}
+
+func (vs *structSnapshotVisitor) OnRegistryStart() {
+	vs.stack = append(vs.stack, vs.current)
+	if !vs.hasKey {
+		// the root registry is reported into the current map
+		return
+	}
+
+	vs.current = vs.path(vs.key)
+	vs.hasKey = false
+}
+
+func (vs *structSnapshotVisitor) OnRegistryFinished() {
+	last := len(vs.stack) - 1
+	vs.current = vs.stack[last]
+	vs.stack = vs.stack[:last]
+}
+
+func (vs *structSnapshotVisitor) OnKey(name string) {
+	vs.key = name
+	vs.hasKey = true
+}
+
+func (vs *structSnapshotVisitor) setValue(v interface{}) {
+	parent, key := vs.current, vs.key
+	if idx := strings.LastIndex(key, "."); idx >= 0 {
+		parent, key = vs.path(key[:idx]), key[idx+1:]
+	}
+	parent[key] = v
+	vs.hasKey = false
+}
+
+// path returns the map for the dotted key relative to the current map,
+// creating missing maps. Dotted names, as used by expvar metrics, are
+// reported as nested objects.
+func (vs *structSnapshotVisitor) path(key string) map[string]interface{} {
+	m := vs.current
+	for _, name := range strings.Split(key, ".") {
+		child, ok := m[name].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			m[name] = child
+		}
+		m = child
+	}
+	return m
+}
+
+func (vs *structSnapshotVisitor) OnString(s string) { vs.setValue(s) }
+func (vs *structSnapshotVisitor) OnBool(b bool)     { vs.setValue(b) }
+func (vs *structSnapshotVisitor) OnInt(i int64)     { vs.setValue(i) }
+func (vs *structSnapshotVisitor) OnFloat(f float64) { vs.setValue(f) }
@@ -0,0 +1,48 @@
+// +build !integration
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStructSnapshot(t *testing.T) {
+	r := NewRegistry()
+	NewInt(r, "count").Set(1)
+	NewString(r, "name").Set("test")
+
+	sub := r.NewRegistry("output")
+	NewInt(sub, "events.acked").Set(10)
+	NewFloat(sub, "rate").Set(1.5)
+
+	expected := map[string]interface{}{
+		"count": int64(1),
+		"name":  "test",
+		"output": map[string]interface{}{
+			"events": map[string]interface{}{
+				"acked": int64(10),
+			},
+			"rate": 1.5,
+		},
+	}
+	assert.Equal(t, expected, CollectStructSnapshot(r, Full, false))
+}
+
+func TestCollectStructSnapshotMode(t *testing.T) {
+	r := NewRegistry()
+	NewInt(r, "reported", Report).Set(1)
+	NewInt(r, "full").Set(2)
+
+	snapshot := CollectStructSnapshot(r, Reported, false)
+	assert.Equal(t, map[string]interface{}{"reported": int64(1)}, snapshot)
+}
+
+func TestCollectStructSnapshotExpvar(t *testing.T) {
+	getOrCreateInt("test.snapshot.events").Set(3)
+
+	snapshot := CollectStructSnapshot(NewRegistry(), Full, true)
+	test := snapshot["test"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"events": int64(3)}, test["snapshot"])
+}
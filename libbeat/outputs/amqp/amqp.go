@@ -0,0 +1,202 @@
+// Package amqp implements an output plugin publishing events to an AMQP 0-9-1
+// broker like RabbitMQ.
+package amqp
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/op"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+type amqpOut struct {
+	mode     mode.ConnectionMode
+	beatName string
+}
+
+var debugf = logp.MakeDebug("amqp")
+
+// Metrics that can retrieved through the expvar web interface.
+var (
+	ackedEvents    = expvar.NewInt("libbeat.amqp.published_and_acked_events")
+	eventsNotAcked = expvar.NewInt("libbeat.amqp.published_but_not_acked_events")
+	eventsDropped  = expvar.NewInt("libbeat.amqp.dropped_events")
+
+	statReadBytes   = expvar.NewInt("libbeat.amqp.publish.read_bytes")
+	statWriteBytes  = expvar.NewInt("libbeat.amqp.publish.write_bytes")
+	statReadErrors  = expvar.NewInt("libbeat.amqp.publish.read_errors")
+	statWriteErrors = expvar.NewInt("libbeat.amqp.publish.write_errors")
+)
+
+const (
+	defaultWaitRetry    = 1 * time.Second
+	defaultMaxWaitRetry = 60 * time.Second
+)
+
+func init() {
+	outputs.RegisterOutputPlugin("amqp", New)
+	outputs.RegisterOutputTester("amqp", testOutput)
+}
+
+// New instantiates a new output plugin instance publishing to AMQP brokers.
+func New(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
+	if !cfg.HasField("bulk_max_size") {
+		cfg.SetInt("bulk_max_size", -1, defaultBulkSize)
+	}
+
+	out := &amqpOut{beatName: beatName}
+	if err := out.init(cfg); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var tls *transport.TLSConfig
+	if err == nil {
+		tls, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		Proxy:   &config.Proxy,
+		TLS:     tls,
+	}
+
+	for _, host := range hosts {
+		d.Run("amqp: "+host, func(d testing.Driver) {
+			conn := transport.TestDial(d, transp, "tcp", host, config.Port)
+			if conn == nil {
+				return
+			}
+
+			if config.Timeout > 0 {
+				conn.SetDeadline(time.Now().Add(config.Timeout))
+			}
+			c, err := amqp.Open(conn, amqp.Config{
+				SASL:      []amqp.Authentication{&amqp.PlainAuth{Username: config.Username, Password: config.Password}},
+				Vhost:     config.VHost,
+				Heartbeat: config.Heartbeat,
+			})
+			d.Error("talk to server", err)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			c.Close()
+		})
+	}
+}
+
+func (out *amqpOut) init(cfg *common.Config) error {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return err
+	}
+
+	sendRetries := config.MaxRetries
+	maxAttempts := config.MaxRetries + 1
+	if sendRetries < 0 {
+		maxAttempts = 0
+	}
+
+	routingKey, err := outil.BuildSelectorFromConfig(cfg, outil.Settings{
+		Key:              "routing_key",
+		MultiKey:         "routing_keys",
+		EnableSingleOnly: true,
+		FailEmpty:        false,
+	})
+	if err != nil {
+		return err
+	}
+
+	tls, err := outputs.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return err
+	}
+
+	dialer, err := transport.MakeDialer(&transport.Config{
+		Timeout: config.Timeout,
+		Proxy:   &config.Proxy,
+		TLS:     tls,
+		Stats: &transport.IOStats{
+			Read:        statReadBytes,
+			Write:       statWriteBytes,
+			ReadErrors:  statReadErrors,
+			WriteErrors: statWriteErrors,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	clients, err := modeutil.MakeClients(cfg, func(host string) (mode.ProtocolClient, error) {
+		codec, err := outputs.CreateEncoder(config.Codec)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := newClient(host, dialer, &config, routingKey, codec)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logp.Info("Max Retries set to: %v", sendRetries)
+	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
+		Failover:     !config.LoadBalance,
+		MaxAttempts:  maxAttempts,
+		Timeout:      config.Timeout,
+		WaitRetry:    defaultWaitRetry,
+		MaxWaitRetry: defaultMaxWaitRetry,
+	})
+	if err != nil {
+		return err
+	}
+
+	out.mode = m
+	return nil
+}
+
+func (out *amqpOut) Close() error {
+	return out.mode.Close()
+}
+
+func (out *amqpOut) PublishEvent(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data outputs.Data,
+) error {
+	return out.mode.PublishEvent(signaler, opts, data)
+}
+
+func (out *amqpOut) BulkPublish(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data []outputs.Data,
+) error {
+	return out.mode.PublishEvents(signaler, opts, data)
+}
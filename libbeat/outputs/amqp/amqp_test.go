@@ -0,0 +1,183 @@
+// +build !integration
+
+package amqp
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs"
+	jsonCodec "github.com/elastic/beats/libbeat/outputs/codecs/json"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	beattest "github.com/elastic/beats/libbeat/testing"
+)
+
+func newTestClient(t *testing.T, addr string, modify func(*amqpConfig)) *client {
+	config := defaultConfig
+	config.Timeout = 2 * time.Second
+	config.Exchange = "beats"
+	if modify != nil {
+		modify(&config)
+	}
+
+	routingKey := outil.MakeSelector(outil.FmtSelectorExpr(
+		fmtstr.MustCompileEvent("%{[type]}"), "default"))
+
+	c, err := newClient(addr, transport.NetDialer(config.Timeout), &config, routingKey, jsonCodec.New(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(config.Timeout); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func testEvents(types ...string) []outputs.Data {
+	data := make([]outputs.Data, len(types))
+	for i, typ := range types {
+		data[i] = outputs.Data{Event: common.MapStr{"type": typ, "n": i}}
+	}
+	return data
+}
+
+func TestPublishWithConfirms(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := newTestClient(t, broker.Addr(), nil)
+	defer c.Close()
+
+	rest, err := c.PublishEvents(testEvents("log", "metric", "log"))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	messages := broker.Messages()
+	if assert.Len(t, messages, 3) {
+		assert.Equal(t, "beats", messages[0].exchange)
+		assert.Equal(t, "log", messages[0].routingKey)
+		assert.Equal(t, "metric", messages[1].routingKey)
+
+		var event map[string]interface{}
+		assert.NoError(t, json.Unmarshal(messages[2].body, &event))
+		assert.Equal(t, float64(2), event["n"])
+	}
+}
+
+func TestPublishNacked(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := newTestClient(t, broker.Addr(), nil)
+	defer c.Close()
+
+	data := testEvents("log", "nack", "log")
+	rest, err := c.PublishEvents(data)
+	assert.Equal(t, errNacked, err)
+	if assert.Len(t, rest, 1) {
+		assert.Equal(t, "nack", rest[0].Event["type"])
+	}
+}
+
+func TestPublishWithoutConfirms(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := newTestClient(t, broker.Addr(), func(config *amqpConfig) {
+		config.PublisherConfirms = false
+	})
+
+	rest, err := c.PublishEvents(testEvents("log", "nack"))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	// closing the connection flushes all pending frames
+	c.Close()
+	broker.Close()
+	assert.Len(t, broker.Messages(), 2)
+}
+
+func TestExchangeDeclare(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	c := newTestClient(t, broker.Addr(), func(config *amqpConfig) {
+		config.ExchangeDeclare.Enabled = true
+	})
+	c.Close()
+
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	assert.Equal(t, []string{"beats"}, broker.declared)
+}
+
+func TestPublishNotConnected(t *testing.T) {
+	c, err := newClient("localhost", transport.NetDialer(time.Second), &defaultConfig,
+		outil.MakeSelector(), jsonCodec.New(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := testEvents("log")
+	rest, err := c.PublishEvents(data)
+	assert.Equal(t, errNotConnected, err)
+	assert.Len(t, rest, 1)
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{map[string]interface{}{"hosts": []string{"localhost"}}, true},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "exchange_declare.enabled": true}, false},
+		{map[string]interface{}{
+			"hosts":                    []string{"localhost"},
+			"exchange":                 "beats",
+			"exchange_declare.enabled": true,
+			"exchange_declare.kind":    "unknown",
+		}, false},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "routing_key": "%{[type]}"}, true},
+	}
+
+	for i, test := range tests {
+		cfg, err := common.NewConfigFrom(test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := New("test", cfg, 0)
+		if test.valid {
+			if assert.NoError(t, err, "test %v", i) {
+				out.Close()
+			}
+		} else {
+			assert.Error(t, err, "test %v", i)
+		}
+	}
+}
+
+func TestOutputTester(t *testing.T) {
+	broker := newMockBroker(t)
+	defer broker.Close()
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"hosts":   []string{broker.Addr()},
+		"timeout": "2s",
+	})
+	assert.NoError(t, err)
+
+	buf := bytes.NewBuffer(nil)
+	d := beattest.NewConsoleDriver(buf)
+	testOutput("testbeat", cfg, d)
+
+	assert.False(t, d.Failed(), buf.String())
+	assert.Contains(t, buf.String(), "talk to server... OK")
+}
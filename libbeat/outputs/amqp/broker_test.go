@@ -0,0 +1,275 @@
+// +build !integration
+
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// mockBroker is a minimal AMQP 0-9-1 server stand-in. It accepts the
+// connection handshake, channel open, exchange declare and confirm select
+// methods and records all published messages. Messages published with the
+// routing key "nack" are negatively acknowledged.
+type mockBroker struct {
+	t        *testing.T
+	listener net.Listener
+
+	mutex    sync.Mutex
+	messages []mockMessage
+	declared []string
+	wg       sync.WaitGroup
+}
+
+type mockMessage struct {
+	exchange   string
+	routingKey string
+	body       []byte
+}
+
+type frame struct {
+	kind    byte
+	channel uint16
+	payload []byte
+}
+
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+)
+
+func newMockBroker(t *testing.T) *mockBroker {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &mockBroker{t: t, listener: l}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+func (b *mockBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+func (b *mockBroker) Close() {
+	b.listener.Close()
+	b.wg.Wait()
+}
+
+func (b *mockBroker) Messages() []mockMessage {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]mockMessage(nil), b.messages...)
+}
+
+func (b *mockBroker) run() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			defer conn.Close()
+			if err := b.serve(conn); err != nil && err != io.EOF {
+				b.t.Logf("mock broker connection closed: %v", err)
+			}
+		}()
+	}
+}
+
+func (b *mockBroker) serve(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if !bytes.Equal(header, []byte("AMQP\x00\x00\x09\x01")) {
+		return errors.New("invalid protocol header")
+	}
+
+	// connection.start
+	start := method(10, 10)
+	start.WriteByte(0) // version-major
+	start.WriteByte(9) // version-minor
+	writeLong(start, 0)
+	writeLongStr(start, "PLAIN")
+	writeLongStr(start, "en_US")
+	if err := writeFrame(conn, frameMethod, 0, start.Bytes()); err != nil {
+		return err
+	}
+
+	var deliveryTag uint64
+	var confirmMode bool
+	var publish *mockMessage
+	var bodySize uint64
+
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			return err
+		}
+
+		switch f.kind {
+		case frameHeartbeat:
+			continue
+
+		case frameHeader:
+			bodySize = binary.BigEndian.Uint64(f.payload[4:12])
+			if bodySize == 0 {
+				b.published(conn, publish, &deliveryTag, confirmMode)
+				publish = nil
+			}
+			continue
+
+		case frameBody:
+			publish.body = append(publish.body, f.payload...)
+			if uint64(len(publish.body)) >= bodySize {
+				b.published(conn, publish, &deliveryTag, confirmMode)
+				publish = nil
+			}
+			continue
+		}
+
+		class := binary.BigEndian.Uint16(f.payload[0:2])
+		id := binary.BigEndian.Uint16(f.payload[2:4])
+		args := f.payload[4:]
+
+		var reply *bytes.Buffer
+		switch {
+		case class == 10 && id == 11: // connection.start-ok
+			reply = method(10, 30) // connection.tune
+			writeShort(reply, 0)
+			writeLong(reply, 131072)
+			writeShort(reply, 0)
+		case class == 10 && id == 31: // connection.tune-ok
+		case class == 10 && id == 40: // connection.open
+			reply = method(10, 41)
+			reply.WriteByte(0)
+		case class == 10 && id == 50: // connection.close
+			writeFrame(conn, frameMethod, 0, method(10, 51).Bytes())
+			return nil
+		case class == 20 && id == 10: // channel.open
+			reply = method(20, 11)
+			writeLong(reply, 0)
+		case class == 20 && id == 40: // channel.close
+			reply = method(20, 41)
+		case class == 40 && id == 10: // exchange.declare
+			name, _ := readShortStr(args[2:])
+			b.mutex.Lock()
+			b.declared = append(b.declared, name)
+			b.mutex.Unlock()
+			reply = method(40, 11)
+		case class == 85 && id == 10: // confirm.select
+			confirmMode = true
+			reply = method(85, 11)
+		case class == 60 && id == 40: // basic.publish
+			exchange, rest := readShortStr(args[2:])
+			key, _ := readShortStr(rest)
+			publish = &mockMessage{exchange: exchange, routingKey: key}
+		default:
+			return errors.New("unexpected method")
+		}
+
+		if reply != nil {
+			if err := writeFrame(conn, frameMethod, f.channel, reply.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (b *mockBroker) published(conn net.Conn, msg *mockMessage, tag *uint64, confirm bool) {
+	b.mutex.Lock()
+	b.messages = append(b.messages, *msg)
+	b.mutex.Unlock()
+
+	if !confirm {
+		return
+	}
+
+	*tag++
+	var reply *bytes.Buffer
+	if msg.routingKey == "nack" {
+		reply = method(60, 120) // basic.nack
+	} else {
+		reply = method(60, 80) // basic.ack
+	}
+	binary.Write(reply, binary.BigEndian, *tag)
+	reply.WriteByte(0)
+	writeFrame(conn, frameMethod, 1, reply.Bytes())
+}
+
+func method(class, id uint16) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	writeShort(buf, class)
+	writeShort(buf, id)
+	return buf
+}
+
+func writeShort(buf *bytes.Buffer, v uint16) {
+	binary.Write(buf, binary.BigEndian, v)
+}
+
+func writeLong(buf *bytes.Buffer, v uint32) {
+	binary.Write(buf, binary.BigEndian, v)
+}
+
+func writeLongStr(buf *bytes.Buffer, s string) {
+	writeLong(buf, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+func readShortStr(b []byte) (string, []byte) {
+	n := int(b[0])
+	return string(b[1 : 1+n]), b[1+n:]
+}
+
+func writeFrame(w io.Writer, kind byte, channel uint16, payload []byte) error {
+	buf := &bytes.Buffer{}
+	buf.WriteByte(kind)
+	writeShort(buf, channel)
+	writeLong(buf, uint32(len(payload)))
+	buf.Write(payload)
+	buf.WriteByte(frameEnd)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readFrame(r io.Reader) (frame, error) {
+	var hdr [7]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return frame{}, err
+	}
+
+	size := binary.BigEndian.Uint32(hdr[3:7])
+	payload := make([]byte, size+1)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return frame{}, err
+	}
+	if payload[size] != frameEnd {
+		return frame{}, errors.New("invalid frame end")
+	}
+
+	return frame{
+		kind:    hdr[0],
+		channel: binary.BigEndian.Uint16(hdr[1:3]),
+		payload: payload[:size],
+	}, nil
+}
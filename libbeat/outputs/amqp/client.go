@@ -0,0 +1,254 @@
+package amqp
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+type client struct {
+	url    string
+	dialer transport.Dialer
+	config *amqpConfig
+
+	routingKey outil.Selector
+	codec      outputs.Codec
+
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	confirms chan amqp.Confirmation
+	closed   chan *amqp.Error
+}
+
+var (
+	errNotConnected   = errors.New("amqp client not connected")
+	errConfirmTimeout = errors.New("timeout waiting for publisher confirms")
+	errChannelClosed  = errors.New("amqp channel closed")
+	errNacked         = errors.New("events rejected by broker")
+)
+
+func newClient(
+	host string,
+	dialer transport.Dialer,
+	config *amqpConfig,
+	routingKey outil.Selector,
+	codec outputs.Codec,
+) (*client, error) {
+	hostname, port, err := splitHostPort(host, config.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := amqp.URI{
+		Scheme:   "amqp",
+		Host:     hostname,
+		Port:     port,
+		Username: config.Username,
+		Password: config.Password,
+		Vhost:    config.VHost,
+	}
+
+	return &client{
+		url:        uri.String(),
+		dialer:     dialer,
+		config:     config,
+		routingKey: routingKey,
+		codec:      codec,
+	}, nil
+}
+
+func (c *client) Connect(timeout time.Duration) error {
+	debugf("connect")
+	c.Close()
+
+	conn, err := amqp.DialConfig(c.url, amqp.Config{
+		Vhost:     c.config.VHost,
+		Heartbeat: c.config.Heartbeat,
+		Dial: func(network, addr string) (net.Conn, error) {
+			conn, err := c.dialer.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+
+			// deadline for the AMQP handshake. The deadline is cleared by the
+			// amqp library once the connection is established.
+			if timeout > 0 {
+				if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+					conn.Close()
+					return nil, err
+				}
+			}
+			return conn, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	if decl := c.config.ExchangeDeclare; decl.Enabled {
+		err = ch.ExchangeDeclare(c.config.Exchange, decl.Kind, decl.Durable, false, false, false, nil)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	if c.config.PublisherConfirms {
+		if err := ch.Confirm(false); err != nil {
+			conn.Close()
+			return err
+		}
+		c.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, c.config.BulkMaxSize))
+	}
+
+	c.closed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	c.conn = conn
+	c.channel = ch
+	return nil
+}
+
+func (c *client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+
+	debugf("close connection")
+	err := c.conn.Close()
+	c.conn = nil
+	c.channel = nil
+	c.confirms = nil
+	c.closed = nil
+	return err
+}
+
+func (c *client) PublishEvent(data outputs.Data) error {
+	_, err := c.PublishEvents([]outputs.Data{data})
+	return err
+}
+
+// PublishEvents publishes all events to the configured exchange. If publisher
+// confirms are enabled, PublishEvents waits for the broker to confirm all
+// messages and returns the events not acknowledged by the broker.
+func (c *client) PublishEvents(data []outputs.Data) ([]outputs.Data, error) {
+	if c.channel == nil {
+		return data, errNotConnected
+	}
+
+	// events successfully handed to the broker, awaiting confirmation
+	pending := data[:0]
+	for i, d := range data {
+		msg, key, ok := c.encode(d.Event)
+		if !ok {
+			eventsDropped.Add(1)
+			continue
+		}
+
+		err := c.channel.Publish(c.config.Exchange, key, false, false, msg)
+		if err != nil {
+			logp.Err("Failed to publish event to amqp exchange '%v': %v", c.config.Exchange, err)
+
+			// requeue the events not confirmed yet
+			failed := append(pending, data[i:]...)
+			return failed, err
+		}
+		pending = append(pending, d)
+	}
+
+	if c.confirms == nil {
+		ackedEvents.Add(int64(len(pending)))
+		return nil, nil
+	}
+	return c.awaitConfirms(pending)
+}
+
+// awaitConfirms waits for one confirmation per pending event. Confirmations
+// are delivered in publishing order.
+func (c *client) awaitConfirms(pending []outputs.Data) ([]outputs.Data, error) {
+	var failed []outputs.Data
+
+	timer := time.NewTimer(c.config.Timeout)
+	defer timer.Stop()
+
+	for i := range pending {
+		select {
+		case confirm, ok := <-c.confirms:
+			if !ok {
+				return append(failed, pending[i:]...), errChannelClosed
+			}
+			if confirm.Ack {
+				ackedEvents.Add(1)
+			} else {
+				eventsNotAcked.Add(1)
+				failed = append(failed, pending[i])
+			}
+
+		case err := <-c.closed:
+			if err != nil {
+				logp.Err("amqp channel closed: %v", err)
+			}
+			return append(failed, pending[i:]...), errChannelClosed
+
+		case <-timer.C:
+			return append(failed, pending[i:]...), errConfirmTimeout
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed, errNacked
+	}
+	return nil, nil
+}
+
+func (c *client) encode(event common.MapStr) (amqp.Publishing, string, bool) {
+	body, err := c.codec.Encode(event)
+	if err != nil {
+		return amqp.Publishing{}, "", false
+	}
+
+	key, err := c.routingKey.Select(event)
+	if err != nil {
+		logp.Err("Failed to select routing key: %v", err)
+		return amqp.Publishing{}, "", false
+	}
+
+	msg := amqp.Publishing{
+		ContentType: c.config.ContentType,
+		Body:        body,
+	}
+	if ts, ok := event["@timestamp"].(common.Time); ok {
+		msg.Timestamp = time.Time(ts)
+	}
+	if c.config.Persistent {
+		msg.DeliveryMode = amqp.Persistent
+	}
+	return msg, key, true
+}
+
+func splitHostPort(host string, defaultPort int) (string, int, error) {
+	h, p, err := net.SplitHostPort(host)
+	if err != nil {
+		// no port given
+		return host, defaultPort, nil
+	}
+
+	port, err := strconv.Atoi(p)
+	if err != nil {
+		return "", 0, err
+	}
+	return h, port, nil
+}
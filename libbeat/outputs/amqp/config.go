@@ -0,0 +1,84 @@
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+type amqpConfig struct {
+	Port              int                   `config:"port"`
+	Username          string                `config:"username"`
+	Password          string                `config:"password"`
+	VHost             string                `config:"vhost"`
+	Exchange          string                `config:"exchange"`
+	ExchangeDeclare   exchangeDeclareConfig `config:"exchange_declare"`
+	Persistent        bool                  `config:"persistent"`
+	PublisherConfirms bool                  `config:"publisher_confirms"`
+	ContentType       string                `config:"content_type"`
+	LoadBalance       bool                  `config:"loadbalance"`
+	BulkMaxSize       int                   `config:"bulk_max_size"`
+	Timeout           time.Duration         `config:"timeout"`
+	Heartbeat         time.Duration         `config:"heartbeat"`
+	MaxRetries        int                   `config:"max_retries"`
+	TLS               *outputs.TLSConfig    `config:"ssl"`
+	Proxy             transport.ProxyConfig `config:",inline"`
+	Codec             outputs.CodecConfig   `config:"codec"`
+}
+
+type exchangeDeclareConfig struct {
+	Enabled bool   `config:"enabled"`
+	Kind    string `config:"kind"`
+	Durable bool   `config:"durable"`
+}
+
+const (
+	defaultBulkSize = 2048
+)
+
+var (
+	defaultConfig = amqpConfig{
+		Port:              5672,
+		Username:          "guest",
+		Password:          "guest",
+		VHost:             "/",
+		Exchange:          "",
+		Persistent:        true,
+		PublisherConfirms: true,
+		ContentType:       "application/json",
+		LoadBalance:       true,
+		BulkMaxSize:       defaultBulkSize,
+		Timeout:           30 * time.Second,
+		Heartbeat:         10 * time.Second,
+		MaxRetries:        3,
+		TLS:               nil,
+		ExchangeDeclare: exchangeDeclareConfig{
+			Enabled: false,
+			Kind:    "topic",
+			Durable: true,
+		},
+	}
+)
+
+func (c *amqpConfig) Validate() error {
+	if c.ExchangeDeclare.Enabled {
+		if c.Exchange == "" {
+			return errors.New("exchange_declare requires exchange to be set")
+		}
+
+		switch c.ExchangeDeclare.Kind {
+		case "direct", "fanout", "topic", "headers":
+		default:
+			return fmt.Errorf("exchange kind %v not supported", c.ExchangeDeclare.Kind)
+		}
+	}
+
+	if c.BulkMaxSize <= 0 {
+		return errors.New("bulk_max_size must be greater than 0")
+	}
+
+	return nil
+}
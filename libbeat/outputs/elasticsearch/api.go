@@ -18,6 +18,7 @@ type QueryResult struct {
 	Created      bool            `json:"created"`
 	Acknowledged bool            `json:"acknowledged"`
 	Matches      []string        `json:"matches"`
+	Errors       bool            `json:"errors"`
 }
 
 type SearchResults struct {
@@ -24,11 +24,13 @@ type elasticsearchConfig struct {
 }
 
 type Template struct {
-	Enabled   bool             `config:"enabled"`
-	Name      string           `config:"name"`
-	Path      string           `config:"path"`
-	Overwrite bool             `config:"overwrite"`
-	Versions  TemplateVersions `config:"versions"`
+	Enabled     bool             `config:"enabled"`
+	Name        string           `config:"name"`
+	Path        string           `config:"path"`
+	Fields      string           `config:"fields"`
+	ExtraFields []string         `config:"extra_fields"`
+	Overwrite   bool             `config:"overwrite"`
+	Versions    TemplateVersions `config:"versions"`
 }
 
 type TemplateVersions struct {
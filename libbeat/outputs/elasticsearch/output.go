@@ -21,6 +21,9 @@ import (
 	"github.com/elastic/beats/libbeat/outputs/outil"
 	"github.com/elastic/beats/libbeat/outputs/transport"
 	"github.com/elastic/beats/libbeat/paths"
+	"github.com/elastic/beats/libbeat/template"
+	"github.com/elastic/beats/libbeat/testing"
+	"github.com/elastic/beats/libbeat/version"
 )
 
 type elasticsearchOutput struct {
@@ -31,14 +34,16 @@ type elasticsearchOutput struct {
 	mode mode.ConnectionMode
 	topology
 
-	template      map[string]interface{}
-	template2x    map[string]interface{}
-	template6x    map[string]interface{}
-	templateMutex sync.Mutex
+	template       map[string]interface{}
+	template2x     map[string]interface{}
+	template6x     map[string]interface{}
+	templateFields template.Fields
+	templateMutex  sync.Mutex
 }
 
 func init() {
 	outputs.RegisterOutputPlugin("elasticsearch", New)
+	outputs.RegisterOutputTester("elasticsearch", testOutput)
 }
 
 var (
@@ -165,6 +170,75 @@ func NewElasticsearchClients(cfg *common.Config) ([]Client, error) {
 	return clients, nil
 }
 
+// testOutput connects to every configured Elasticsearch host and reports the
+// version of the cluster.
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	var tlsConfig *transport.TLSConfig
+	if err == nil {
+		tlsConfig, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var proxyURL *url.URL
+	if err == nil && config.ProxyURL != "" {
+		proxyURL, err = parseProxyURL(config.ProxyURL)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	for _, host := range hosts {
+		d.Run("elasticsearch: "+host, func(d testing.Driver) {
+			esURL, err := getURL(config.Protocol, config.Path, host)
+			var u *url.URL
+			if err == nil {
+				u, err = url.Parse(esURL)
+			}
+			d.Fatal("parse url", err)
+
+			if proxyURL != nil {
+				d.Info("proxy", proxyURL.String())
+			} else {
+				transp := &transport.Config{Timeout: config.Timeout}
+				if u.Scheme == "https" {
+					transp.TLS = tlsConfig
+					if transp.TLS == nil {
+						transp.TLS = &transport.TLSConfig{}
+					}
+				}
+
+				conn := transport.TestDial(d, transp, "tcp", u.Host, 9200)
+				if conn == nil {
+					return
+				}
+				conn.Close()
+			}
+
+			client, err := NewClient(ClientSettings{
+				URL:        esURL,
+				Proxy:      proxyURL,
+				TLS:        tlsConfig,
+				Username:   config.Username,
+				Password:   config.Password,
+				Parameters: config.Params,
+				Headers:    config.Headers,
+				Timeout:    config.Timeout,
+			}, nil)
+			if err != nil {
+				d.Fatal("client", err)
+			}
+
+			version, err := client.Ping(config.Timeout)
+			d.Fatal("talk to server", err)
+			d.Info("version", version)
+		})
+	}
+}
+
 func (out *elasticsearchOutput) init(
 	cfg *common.Config,
 	topologyExpire int,
@@ -242,12 +316,30 @@ func (out *elasticsearchOutput) init(
 }
 
 // readTemplates reads the ES mapping template from the disk, if configured.
+// Unless a template file is configured, the template is generated on connect
+// from the fields.yml file for the version of the Elasticsearch cluster.
 func (out *elasticsearchOutput) readTemplate(config *Template) error {
 	if config.Enabled {
 		// Set the defaults that depend on the beat name
 		if config.Name == "" {
 			config.Name = out.beatName
 		}
+
+		if config.Path == "" {
+			fieldsPath := paths.Resolve(paths.Config, "fields.yml")
+			if config.Fields != "" {
+				fieldsPath = paths.Resolve(paths.Config, config.Fields)
+			}
+
+			// Fall back to the static template files if the default fields
+			// file is not available.
+			_, err := os.Stat(fieldsPath)
+			if config.Fields != "" || err == nil {
+				return out.readTemplateFields(config, fieldsPath)
+			}
+			logp.Info("Fields file %v not found, using static template files", fieldsPath)
+		}
+
 		if config.Path == "" {
 			config.Path = fmt.Sprintf("%s.template.json", out.beatName)
 		}
@@ -262,22 +354,22 @@ func (out *elasticsearchOutput) readTemplate(config *Template) error {
 		templatePath := paths.Resolve(paths.Config, config.Path)
 		logp.Info("Loading template enabled. Reading template file: %v", templatePath)
 
-		template, err := readTemplate(templatePath)
+		tmpl, err := readTemplate(templatePath)
 		if err != nil {
 			return fmt.Errorf("Error loading template %s: %v", templatePath, err)
 		}
-		out.template = template
+		out.template = tmpl
 
 		if config.Versions.Es2x.Enabled {
 			// Read the version of the template compatible with ES 2.x
 			templatePath := paths.Resolve(paths.Config, config.Versions.Es2x.Path)
 			logp.Info("Loading template enabled for Elasticsearch 2.x. Reading template file: %v", templatePath)
 
-			template, err := readTemplate(templatePath)
+			tmpl, err := readTemplate(templatePath)
 			if err != nil {
 				return fmt.Errorf("Error loading template %s: %v", templatePath, err)
 			}
-			out.template2x = template
+			out.template2x = tmpl
 		}
 
 		if config.Versions.Es6x.Enabled {
@@ -285,16 +377,48 @@ func (out *elasticsearchOutput) readTemplate(config *Template) error {
 			templatePath := paths.Resolve(paths.Config, config.Versions.Es6x.Path)
 			logp.Info("Loading template enabled for Elasticsearch 6.x. Reading template file: %v", templatePath)
 
-			template, err := readTemplate(templatePath)
+			tmpl, err := readTemplate(templatePath)
 			if err != nil {
 				return fmt.Errorf("Error loading template %s: %v", templatePath, err)
 			}
-			out.template6x = template
+			out.template6x = tmpl
 		}
 	}
 	return nil
 }
 
+// readTemplateFields reads the fields.yml file and the extra fields files the
+// template is generated from.
+func (out *elasticsearchOutput) readTemplateFields(config *Template, fieldsPath string) error {
+	files := []string{fieldsPath}
+	for _, extra := range config.ExtraFields {
+		files = append(files, paths.Resolve(paths.Config, extra))
+	}
+	logp.Info("Loading template enabled. Generating template from fields files: %v", files)
+
+	fields, err := template.ReadFields(files...)
+	if err != nil {
+		return fmt.Errorf("Error loading template fields: %v", err)
+	}
+
+	// Generate the template once to report invalid field definitions on
+	// startup.
+	if _, err := generateTemplate(config.Name, "5.0.0", fields); err != nil {
+		return fmt.Errorf("Error generating template: %v", err)
+	}
+
+	out.templateFields = fields
+	return nil
+}
+
+func generateTemplate(name, esVersion string, fields template.Fields) (map[string]interface{}, error) {
+	tmpl, err := template.New(name, version.GetDefaultVersion(), esVersion)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Generate(fields)
+}
+
 func readTemplate(filename string) (map[string]interface{}, error) {
 	f, err := os.Open(filename)
 	if err != nil {
@@ -302,14 +426,14 @@ func readTemplate(filename string) (map[string]interface{}, error) {
 	}
 	defer f.Close()
 
-	var template map[string]interface{}
+	var tmpl map[string]interface{}
 	dec := json.NewDecoder(f)
-	err = dec.Decode(&template)
+	err = dec.Decode(&tmpl)
 	if err != nil {
 		return nil, err
 	}
 
-	return template, nil
+	return tmpl, nil
 }
 
 // loadTemplate checks if the index mapping template should be loaded
@@ -329,16 +453,12 @@ func (out *elasticsearchOutput) loadTemplate(config Template, client *Client) er
 			logp.Info("Existing template will be overwritten, as overwrite is enabled.")
 		}
 
-		template := out.template
-		if config.Versions.Es2x.Enabled && strings.HasPrefix(client.Connection.version, "2.") {
-			logp.Info("Detected Elasticsearch 2.x. Automatically selecting the 2.x version of the template")
-			template = out.template2x
-		} else if config.Versions.Es6x.Enabled && strings.HasPrefix(client.Connection.version, "6.") {
-			logp.Info("Detected Elasticsearch 6.x. Automatically selecting the 6.x version of the template")
-			template = out.template6x
+		tmpl, err := out.selectTemplate(config, client.Connection.version)
+		if err != nil {
+			return fmt.Errorf("Could not generate template: %v", err)
 		}
 
-		err := client.LoadTemplate(config.Name, template)
+		err = client.LoadTemplate(config.Name, tmpl)
 		if err != nil {
 			return fmt.Errorf("Could not load template: %v", err)
 		}
@@ -349,6 +469,76 @@ func (out *elasticsearchOutput) loadTemplate(config Template, client *Client) er
 	return nil
 }
 
+// selectTemplate returns the template for the given Elasticsearch version.
+func (out *elasticsearchOutput) selectTemplate(config Template, esVersion string) (map[string]interface{}, error) {
+	if out.templateFields != nil {
+		logp.Info("Generating template for Elasticsearch version %v", esVersion)
+		return generateTemplate(config.Name, esVersion, out.templateFields)
+	}
+
+	if config.Versions.Es2x.Enabled && strings.HasPrefix(esVersion, "2.") {
+		logp.Info("Detected Elasticsearch 2.x. Automatically selecting the 2.x version of the template")
+		return out.template2x, nil
+	} else if config.Versions.Es6x.Enabled && strings.HasPrefix(esVersion, "6.") {
+		logp.Info("Detected Elasticsearch 6.x. Automatically selecting the 6.x version of the template")
+		return out.template6x, nil
+	}
+	return out.template, nil
+}
+
+// ExportTemplate returns the index template the output configured by cfg
+// loads into clusters of the Elasticsearch version esVersion. The template is
+// returned even if template loading is disabled in the configuration.
+func ExportTemplate(beatName string, cfg *common.Config, esVersion string) (map[string]interface{}, error) {
+	config := defaultConfig
+	if cfg != nil {
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+	}
+	config.Template.Enabled = true
+
+	out := &elasticsearchOutput{beatName: beatName}
+	if err := out.readTemplate(&config.Template); err != nil {
+		return nil, err
+	}
+	return out.selectTemplate(config.Template, esVersion)
+}
+
+// SetupTemplate loads the index template into every Elasticsearch host
+// configured in cfg, as done by the output on connect.
+func SetupTemplate(beatName string, cfg *common.Config) error {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return err
+	}
+	if !config.Template.Enabled {
+		logp.Info("Template loading is disabled")
+		return nil
+	}
+
+	out := &elasticsearchOutput{beatName: beatName}
+	if err := out.readTemplate(&config.Template); err != nil {
+		return err
+	}
+
+	clients, err := NewElasticsearchClients(cfg)
+	if err != nil {
+		return err
+	}
+
+	for i := range clients {
+		client := &clients[i]
+		if err := client.Connect(client.timeout); err != nil {
+			return fmt.Errorf("Error connecting to Elasticsearch %v: %v", client.Connection.URL, err)
+		}
+		if err := out.loadTemplate(config.Template, client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func makeClientFactory(
 	tls *transport.TLSConfig,
 	config *elasticsearchConfig,
@@ -378,7 +568,7 @@ func makeClientFactory(
 
 		// define a callback to be called on connection
 		var onConnected connectCallback
-		if out.template != nil {
+		if out.template != nil || out.templateFields != nil {
 			onConnected = func(client *Client) error {
 				return out.loadTemplate(config.Template, client)
 			}
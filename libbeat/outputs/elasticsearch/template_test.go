@@ -0,0 +1,95 @@
+// +build !integration
+
+package elasticsearch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestReadTemplateFromFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fieldsPath := filepath.Join(dir, "fields.yml")
+	extraPath := filepath.Join(dir, "extra.yml")
+	writeFile(t, fieldsPath, "fields:\n- key: beat\n  fields:\n    - name: beat.name\n")
+	writeFile(t, extraPath, "- key: custom\n  fields:\n    - name: custom.count\n      type: long\n")
+
+	out := &elasticsearchOutput{beatName: "testbeat"}
+	config := Template{
+		Enabled:     true,
+		Fields:      fieldsPath,
+		ExtraFields: []string{extraPath},
+	}
+	if err := out.readTemplate(&config); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "testbeat", config.Name)
+	assert.Len(t, out.templateFields, 2)
+
+	tmpl, err := out.selectTemplate(config, "2.4.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "testbeat-*", tmpl["template"])
+
+	tmpl, err = out.selectTemplate(config, "6.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mapping := tmpl["mappings"].(common.MapStr)["_default_"].(common.MapStr)
+	custom := mapping["properties"].(common.MapStr)["custom"].(common.MapStr)
+	assert.Equal(t, common.MapStr{"type": "long"}, custom["properties"].(common.MapStr)["count"])
+}
+
+func TestReadTemplateMissingFields(t *testing.T) {
+	out := &elasticsearchOutput{beatName: "testbeat"}
+	config := Template{
+		Enabled: true,
+		Fields:  "/does/not/exist/fields.yml",
+	}
+	assert.Error(t, out.readTemplate(&config))
+}
+
+func TestReadTemplateFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	templatePath := filepath.Join(dir, "testbeat.template.json")
+	writeFile(t, templatePath, `{"template": "testbeat-*"}`)
+
+	out := &elasticsearchOutput{beatName: "testbeat"}
+	config := Template{
+		Enabled: true,
+		Path:    templatePath,
+	}
+	if err := out.readTemplate(&config); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, out.templateFields)
+
+	tmpl, err := out.selectTemplate(config, "5.6.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "testbeat-*", tmpl["template"])
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,31 +2,61 @@ package fileout
 
 import (
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 )
 
 type config struct {
-	Path          string              `config:"path"`
-	Filename      string              `config:"filename"`
-	RotateEveryKb int                 `config:"rotate_every_kb" validate:"min=1"`
-	NumberOfFiles int                 `config:"number_of_files"`
-	Codec         outputs.CodecConfig `config:"codec"`
+	Path           *fmtstr.EventFormatString `config:"path"`
+	Filename       *fmtstr.EventFormatString `config:"filename"`
+	RotateEveryKb  int                       `config:"rotate_every_kb" validate:"min=1"`
+	RotateInterval rotateInterval            `config:"rotate_interval"`
+	NumberOfFiles  int                       `config:"number_of_files"`
+	Compress       bool                      `config:"compress"`
+	Permissions    uint32                    `config:"permissions"`
+	Codec          outputs.CodecConfig       `config:"codec"`
 }
 
+// rotateInterval configures time based rotation. Supported values are
+// `hourly` and `daily`.
+type rotateInterval time.Duration
+
 var (
 	defaultConfig = config{
 		NumberOfFiles: 7,
 		RotateEveryKb: 10 * 1024,
+		Permissions:   0600,
+	}
+
+	rotateIntervals = map[string]rotateInterval{
+		"":       0,
+		"hourly": rotateInterval(time.Hour),
+		"daily":  rotateInterval(24 * time.Hour),
 	}
 )
 
+func (r *rotateInterval) Unpack(s string) error {
+	interval, found := rotateIntervals[s]
+	if !found {
+		return fmt.Errorf("invalid rotate_interval '%v', supported values are 'hourly' and 'daily'", s)
+	}
+	*r = interval
+	return nil
+}
+
 func (c *config) Validate() error {
 	if c.NumberOfFiles < 2 || c.NumberOfFiles > logp.RotatorMaxFiles {
 		return fmt.Errorf("The number_of_files to keep should be between 2 and %v",
 			logp.RotatorMaxFiles)
 	}
 
+	if c.Permissions > uint32(os.ModePerm) {
+		return fmt.Errorf("The permissions mask %#o is invalid", c.Permissions)
+	}
+
 	return nil
 }
@@ -1,20 +1,54 @@
 package fileout
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
 	"github.com/elastic/beats/libbeat/common/op"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/libbeat/outputs"
 )
 
+// closeInactive is the duration after which files not written to are closed.
+// Closed files are reopened in append mode on the next write.
+const closeInactive = 5 * time.Minute
+
+// modTimeResolution is the coarsest resolution of file modification times
+// supported when checking if a file was written since the output started.
+const modTimeResolution = 2 * time.Second
+
+var errClosed = errors.New("file output closed")
+
 func init() {
 	outputs.RegisterOutputPlugin("file", New)
 }
 
 type fileOutput struct {
 	beatName string
-	rotator  logp.FileRotator
+	config   config
+	path     *fmtstr.EventFormatString
+	filename *fmtstr.EventFormatString
 	codec    outputs.Codec
+
+	// mutex is held while writing events, such that Close does not close
+	// files being written to.
+	mutex     sync.Mutex
+	rotators  map[string]*fileRotator
+	lastSweep time.Time
+	started   time.Time
+	closed    bool
+}
+
+type fileRotator struct {
+	logp.FileRotator
+	lastWrite time.Time
 }
 
 // New instantiates a new file output instance.
@@ -38,10 +72,18 @@ func New(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
 func (out *fileOutput) init(config config) error {
 	var err error
 
-	out.rotator.Path = config.Path
-	out.rotator.Name = config.Filename
-	if out.rotator.Name == "" {
-		out.rotator.Name = out.beatName
+	out.config = config
+	out.rotators = map[string]*fileRotator{}
+	out.started = time.Now()
+	out.lastSweep = out.started
+
+	out.path = config.Path
+	if out.path == nil {
+		out.path = fmtstr.MustCompileEvent("")
+	}
+	out.filename = config.Filename
+	if out.filename == nil {
+		out.filename = fmtstr.MustCompileEvent(out.beatName)
 	}
 
 	codec, err := outputs.CreateEncoder(config.Codec)
@@ -51,33 +93,43 @@ func (out *fileOutput) init(config config) error {
 
 	out.codec = codec
 
-	logp.Info("File output path set to: %v", out.rotator.Path)
-	logp.Info("File output base filename set to: %v", out.rotator.Name)
-
-	rotateeverybytes := uint64(config.RotateEveryKb) * 1024
-	logp.Info("Rotate every bytes set to: %v", rotateeverybytes)
-	out.rotator.RotateEveryBytes = &rotateeverybytes
-
-	keepfiles := config.NumberOfFiles
-	logp.Info("Number of files set to: %v", keepfiles)
-	out.rotator.KeepFiles = &keepfiles
-
-	err = out.rotator.CreateDirectory()
-	if err != nil {
-		return err
+	logp.Info("File output path set to: %v", out.path)
+	logp.Info("File output base filename set to: %v", out.filename)
+	logp.Info("Rotate every bytes set to: %v", uint64(config.RotateEveryKb)*1024)
+	logp.Info("Number of files set to: %v", config.NumberOfFiles)
+	if config.RotateInterval > 0 {
+		logp.Info("Rotate interval set to: %v", time.Duration(config.RotateInterval))
+	}
+	if config.Compress {
+		logp.Info("Rotated files will be compressed")
 	}
 
-	err = out.rotator.CheckIfConfigSane()
-	if err != nil {
+	// Paths not depending on the event are opened right away, so
+	// configuration errors are reported on startup.
+	if out.path.IsConst() && out.filename.IsConst() {
+		out.mutex.Lock()
+		defer out.mutex.Unlock()
+		_, err = out.getRotator(common.MapStr{})
 		return err
 	}
 
-	return nil
+	probe := out.newRotator("", "probe")
+	return probe.CheckIfConfigSane()
 }
 
 // Implement Outputer
 func (out *fileOutput) Close() error {
-	return nil
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+
+	out.closed = true
+	var firstErr error
+	for _, rotator := range out.rotators {
+		if err := rotator.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
 }
 
 func (out *fileOutput) PublishEvent(
@@ -88,13 +140,30 @@ func (out *fileOutput) PublishEvent(
 	var serializedEvent []byte
 	var err error
 
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+
+	// Events published after Close are failed, so the publisher can send
+	// them to the output replacing this one.
+	if out.closed {
+		op.SigFailed(sig, errClosed)
+		return errClosed
+	}
+
+	rotator, err := out.getRotator(data.Event)
+	if err != nil {
+		logp.Err("Failed to select output file: %s", err)
+		op.SigCompleted(sig)
+		return err
+	}
+
 	serializedEvent, err = out.codec.Encode(data.Event)
 	if err != nil {
 		op.SigCompleted(sig)
 		return err
 	}
 
-	err = out.rotator.WriteLine(serializedEvent)
+	err = rotator.WriteLine(serializedEvent)
 	if err != nil {
 		if opts.Guaranteed {
 			logp.Critical("Unable to write events to file: %s", err)
@@ -105,3 +174,86 @@ func (out *fileOutput) PublishEvent(
 	op.Sig(sig, err)
 	return err
 }
+
+// getRotator returns the rotator for the file the event is written to. The
+// rotator is created on first use. Must be called with out.mutex held.
+func (out *fileOutput) getRotator(event common.MapStr) (*fileRotator, error) {
+	path, err := out.path.Run(event)
+	if err != nil {
+		return nil, err
+	}
+	filename, err := out.filename.Run(event)
+	if err != nil {
+		return nil, err
+	}
+	if filename == "" || strings.ContainsAny(filename, `/\`) {
+		return nil, fmt.Errorf("invalid filename '%v'", filename)
+	}
+	path = filepath.Clean(path)
+
+	now := time.Now()
+	if now.Sub(out.lastSweep) > closeInactive {
+		out.closeInactive(now)
+	}
+
+	key := filepath.Join(path, filename)
+	rotator := out.rotators[key]
+	if rotator == nil {
+		rotator = out.newRotator(path, filename)
+		if err := rotator.CreateDirectory(); err != nil {
+			return nil, err
+		}
+		if err := rotator.CheckIfConfigSane(); err != nil {
+			return nil, err
+		}
+
+		// Files written since the output was started were closed when they
+		// became inactive. They are appended to instead of being rotated.
+		// File systems can store modification times with a coarse
+		// resolution, so files written shortly before the start count as
+		// written since.
+		if info, err := os.Stat(key); err == nil && info.ModTime().After(out.started.Add(-modTimeResolution)) {
+			rotator.Close()
+		}
+
+		logp.Debug("file", "Opening output file %v", key)
+		out.rotators[key] = rotator
+	}
+	rotator.lastWrite = now
+	return rotator, nil
+}
+
+func (out *fileOutput) newRotator(path, filename string) *fileRotator {
+	rotateEveryBytes := uint64(out.config.RotateEveryKb) * 1024
+	keepFiles := out.config.NumberOfFiles
+	permissions := out.config.Permissions
+
+	return &fileRotator{
+		FileRotator: logp.FileRotator{
+			Path:             path,
+			Name:             filename,
+			RotateEveryBytes: &rotateEveryBytes,
+			KeepFiles:        &keepFiles,
+			Permissions:      &permissions,
+			RotateInterval:   time.Duration(out.config.RotateInterval),
+			Compress:         out.config.Compress,
+		},
+	}
+}
+
+// closeInactive closes and removes the rotators of all files which have not
+// been written to since closeInactive. Must be called with out.mutex held.
+func (out *fileOutput) closeInactive(now time.Time) {
+	out.lastSweep = now
+	for key, rotator := range out.rotators {
+		if now.Sub(rotator.lastWrite) <= closeInactive {
+			continue
+		}
+
+		logp.Debug("file", "Closing inactive output file %v", key)
+		if err := rotator.Close(); err != nil {
+			logp.Err("Error closing file %v: %v", key, err)
+		}
+		delete(out.rotators, key)
+	}
+}
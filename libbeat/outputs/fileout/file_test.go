@@ -1,3 +1,220 @@
 // +build !integration
 
 package fileout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	_ "github.com/elastic/beats/libbeat/outputs/codecs/json"
+)
+
+func TestConfigRotateInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"hourly": time.Hour,
+		"daily":  24 * time.Hour,
+	}
+
+	for value, expected := range tests {
+		config, err := readConfig(map[string]interface{}{
+			"rotate_interval": value,
+		})
+		if assert.NoError(t, err, value) {
+			assert.Equal(t, expected, time.Duration(config.RotateInterval), value)
+		}
+	}
+
+	_, err := readConfig(map[string]interface{}{
+		"rotate_interval": "weekly",
+	})
+	assert.Error(t, err)
+}
+
+func TestConfigPermissions(t *testing.T) {
+	_, err := readConfig(map[string]interface{}{
+		"permissions": 01000,
+	})
+	assert.Error(t, err)
+}
+
+func TestPublishEventPerEventPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":        filepath.Join(dir, "%{[type]}"),
+		"filename":    "%{[type]}-%{+yyyy.MM.dd}",
+		"permissions": 0640,
+	})
+	defer out.Close()
+
+	ts := time.Date(2017, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, typ := range []string{"log", "metric", "log"} {
+		publish(t, out, common.MapStr{
+			"@timestamp": common.Time(ts),
+			"type":       typ,
+		})
+	}
+
+	logFile := filepath.Join(dir, "log", "log-2017.03.04")
+	content, err := ioutil.ReadFile(logFile)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "metric", "metric-2017.03.04"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	}
+
+	// events missing the fields used in the path are dropped
+	err = out.PublishEvent(nil, outputs.Options{}, outputs.Data{
+		Event: common.MapStr{"@timestamp": common.Time(ts)},
+	})
+	assert.Error(t, err)
+}
+
+func TestPublishEventRejectsFilenameWithSeparator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":     dir,
+		"filename": "%{[type]}",
+	})
+	defer out.Close()
+
+	err = out.PublishEvent(nil, outputs.Options{}, outputs.Data{
+		Event: common.MapStr{"type": "../escape"},
+	})
+	assert.Error(t, err)
+}
+
+func TestCloseInactive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":     dir,
+		"filename": "%{[type]}",
+	})
+	defer out.Close()
+
+	publish(t, out, common.MapStr{"type": "a"})
+	publish(t, out, common.MapStr{"type": "b"})
+
+	out.rotators[filepath.Join(dir, "a")].lastWrite = time.Now().Add(-2 * closeInactive)
+	out.closeInactive(time.Now())
+	assert.NotContains(t, out.rotators, filepath.Join(dir, "a"))
+	assert.Contains(t, out.rotators, filepath.Join(dir, "b"))
+
+	// writing to a closed file appends without rotating it
+	publish(t, out, common.MapStr{"type": "a"})
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "a"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	}
+	_, err = os.Stat(filepath.Join(dir, "a.1"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPublishEventAfterClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":     dir,
+		"filename": "%{[type]}",
+	})
+	publish(t, out, common.MapStr{"type": "a"})
+	assert.NoError(t, out.Close())
+
+	// events published after closing are failed without reopening files
+	err = out.PublishEvent(nil, outputs.Options{}, outputs.Data{
+		Event: common.MapStr{"type": "b"},
+	})
+	assert.Equal(t, errClosed, err)
+	_, err = os.Stat(filepath.Join(dir, "b"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotateFilesOfPreviousRuns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileout_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a")
+	if err := ioutil.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	out := newTestOutput(t, map[string]interface{}{
+		"path":     dir,
+		"filename": "%{[type]}",
+	})
+	defer out.Close()
+
+	publish(t, out, common.MapStr{"type": "a"})
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "a.1"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "old\n", string(content))
+	}
+}
+
+func readConfig(settings map[string]interface{}) (config, error) {
+	config := defaultConfig
+	cfg, err := common.NewConfigFrom(settings)
+	if err != nil {
+		return config, err
+	}
+	err = cfg.Unpack(&config)
+	return config, err
+}
+
+func newTestOutput(t *testing.T, settings map[string]interface{}) *fileOutput {
+	cfg, err := common.NewConfigFrom(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := New("testbeat", cfg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.(*fileOutput)
+}
+
+func publish(t *testing.T, out *fileOutput, event common.MapStr) {
+	err := out.PublishEvent(nil, outputs.Options{}, outputs.Data{Event: event})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,283 @@
+package httpout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+type client struct {
+	url         string
+	username    string
+	password    string
+	bearerToken string
+	headers     map[string]string
+
+	http *http.Client
+
+	codec            outputs.Codec
+	batchFormat      string
+	maxBytes         int
+	compressionLevel int
+
+	// buffers reused between requests
+	body    bytes.Buffer
+	payload bytes.Buffer
+	gzip    *gzip.Writer
+}
+
+type clientSettings struct {
+	URL                string
+	Proxy              *url.URL
+	TLS                *transport.TLSConfig
+	Username, Password string
+	BearerToken        string
+	Parameters         map[string]string
+	Headers            map[string]string
+	Timeout            time.Duration
+	CompressionLevel   int
+	BatchFormat        string
+	MaxBytes           int
+	Codec              outputs.Codec
+}
+
+var (
+	errNoEventsEncoded = errors.New("no event could be encoded")
+)
+
+func newClient(s clientSettings) (*client, error) {
+	proxy := http.ProxyFromEnvironment
+	if s.Proxy != nil {
+		proxy = http.ProxyURL(s.Proxy)
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse http output URL: %v", err)
+	}
+	if u.User != nil {
+		s.Username = u.User.Username()
+		s.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	if len(s.Parameters) > 0 {
+		values := u.Query()
+		for k, v := range s.Parameters {
+			values.Set(k, v)
+		}
+		u.RawQuery = values.Encode()
+	}
+	s.URL = u.String()
+
+	logp.Info("HTTP output url: %s", s.URL)
+
+	dialer := transport.NetDialer(s.Timeout)
+	tlsDialer, err := transport.TLSDialer(dialer, s.TLS, s.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	iostats := &transport.IOStats{
+		Read:        statReadBytes,
+		Write:       statWriteBytes,
+		ReadErrors:  statReadErrors,
+		WriteErrors: statWriteErrors,
+	}
+	dialer = transport.StatsDialer(dialer, iostats)
+	tlsDialer = transport.StatsDialer(tlsDialer, iostats)
+
+	c := &client{
+		url:         s.URL,
+		username:    s.Username,
+		password:    s.Password,
+		bearerToken: s.BearerToken,
+		headers:     s.Headers,
+		http: &http.Client{
+			Transport: &http.Transport{
+				Dial:    dialer.Dial,
+				DialTLS: tlsDialer.Dial,
+				Proxy:   proxy,
+			},
+			Timeout: s.Timeout,
+		},
+		codec:            s.Codec,
+		batchFormat:      s.BatchFormat,
+		maxBytes:         s.MaxBytes,
+		compressionLevel: s.CompressionLevel,
+	}
+
+	if c.compressionLevel > 0 {
+		c.gzip, err = gzip.NewWriterLevel(&c.payload, c.compressionLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Connect is a no-op. Connections are established on demand by the HTTP
+// transport when sending a batch.
+func (c *client) Connect(_ time.Duration) error {
+	return nil
+}
+
+func (c *client) Close() error {
+	if t, ok := c.http.Transport.(*http.Transport); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (c *client) PublishEvent(data outputs.Data) error {
+	_, err := c.PublishEvents([]outputs.Data{data})
+	return err
+}
+
+// PublishEvents encodes and POSTs a batch of events. If bulk_max_bytes is
+// configured, only the events fitting into one request are sent and the
+// remaining events are returned for publishing in a follow-up request.
+func (c *client) PublishEvents(data []outputs.Data) ([]outputs.Data, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	count, encoded := c.encodeBatch(data)
+	rest := data[count:]
+	if encoded == 0 {
+		eventsDropped.Add(int64(count))
+		return rest, nil
+	}
+
+	status, err := c.send()
+	if err != nil {
+		logp.Err("Failed to publish events to %v: %v", c.url, err)
+		eventsNotAcked.Add(int64(encoded))
+		return data, err
+	}
+
+	switch {
+	case status >= 200 && status < 300:
+		debugf("Published %v events to %v", encoded, c.url)
+		ackedEvents.Add(int64(encoded))
+	case status == http.StatusTooManyRequests || status >= 500:
+		eventsNotAcked.Add(int64(encoded))
+		return data, fmt.Errorf("%v responded with status %v", c.url, status)
+	default:
+		// Client errors can not be resolved by retrying the same request. Drop
+		// the batch to not block the pipeline.
+		logp.Err("Dropping %v events rejected by %v with status %v", encoded, c.url, status)
+		eventsDropped.Add(int64(encoded))
+	}
+
+	return rest, nil
+}
+
+// encodeBatch writes as many events as fit into bulk_max_bytes to the request
+// body. At least one event is always consumed. It returns the number of
+// events consumed from data and the number of events actually encoded.
+// Events failing to encode are dropped.
+func (c *client) encodeBatch(data []outputs.Data) (consumed, encoded int) {
+	c.body.Reset()
+	if c.batchFormat == formatArray {
+		c.body.WriteByte('[')
+	}
+
+	for _, d := range data {
+		serialized, err := c.codec.Encode(d.Event)
+		if err != nil {
+			consumed++
+			continue
+		}
+
+		// an additional byte is required for the separator and array end
+		size := c.body.Len() + len(serialized) + 1
+		if c.maxBytes > 0 && encoded > 0 && size > c.maxBytes {
+			break
+		}
+
+		if c.batchFormat == formatArray {
+			if encoded > 0 {
+				c.body.WriteByte(',')
+			}
+			c.body.Write(serialized)
+		} else {
+			c.body.Write(serialized)
+			c.body.WriteByte('\n')
+		}
+
+		consumed++
+		encoded++
+	}
+
+	if c.batchFormat == formatArray {
+		c.body.WriteByte(']')
+	}
+	return consumed, encoded
+}
+
+func (c *client) send() (int, error) {
+	var body io.Reader = &c.body
+	if c.gzip != nil {
+		c.payload.Reset()
+		c.gzip.Reset(&c.payload)
+		if _, err := c.gzip.Write(c.body.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := c.gzip.Close(); err != nil {
+			return 0, err
+		}
+		body = &c.payload
+	}
+
+	req, err := http.NewRequest("POST", c.url, body)
+	if err != nil {
+		return 0, err
+	}
+
+	if c.batchFormat == formatArray {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	} else {
+		req.Header.Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+	}
+	if c.gzip != nil {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	} else if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer closing(resp.Body)
+
+	// drain the body so the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func closing(c io.Closer) {
+	err := c.Close()
+	if err != nil {
+		logp.Warn("Close failed with: %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// +build !integration
+
+package httpout
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/outputs"
+	jsonCodec "github.com/elastic/beats/libbeat/outputs/codecs/json"
+	beattest "github.com/elastic/beats/libbeat/testing"
+)
+
+type request struct {
+	header http.Header
+	body   string
+}
+
+func newTestServer(t *testing.T, status int) (*httptest.Server, chan request) {
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		var err error
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gz, gzErr := gzip.NewReader(r.Body)
+			if gzErr != nil {
+				t.Fatal(gzErr)
+			}
+			body, err = ioutil.ReadAll(gz)
+		} else {
+			body, err = ioutil.ReadAll(r.Body)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		requests <- request{header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	return server, requests
+}
+
+func newTestClient(t *testing.T, s clientSettings) *client {
+	if s.Codec == nil {
+		s.Codec = jsonCodec.New(false)
+	}
+	if s.BatchFormat == "" {
+		s.BatchFormat = formatNDJSON
+	}
+	if s.Timeout == 0 {
+		s.Timeout = 5 * time.Second
+	}
+
+	c, err := newClient(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func testData(n int) []outputs.Data {
+	data := make([]outputs.Data, n)
+	for i := range data {
+		data[i] = outputs.Data{Event: common.MapStr{"message": "test", "n": i}}
+	}
+	return data
+}
+
+func TestPublishNDJSON(t *testing.T) {
+	server, requests := newTestServer(t, 200)
+	defer server.Close()
+
+	c := newTestClient(t, clientSettings{
+		URL:      server.URL + "/ingest",
+		Username: "user",
+		Password: "secret",
+		Headers:  map[string]string{"X-Custom": "value"},
+	})
+
+	rest, err := c.PublishEvents(testData(3))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	req := <-requests
+	assert.Equal(t, "value", req.header.Get("X-Custom"))
+	assert.Equal(t, "application/x-ndjson; charset=UTF-8", req.header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(req.header.Get("Authorization"), "Basic "))
+
+	lines := strings.Split(strings.TrimSpace(req.body), "\n")
+	if assert.Len(t, lines, 3) {
+		var event map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+		assert.Equal(t, float64(2), event["n"])
+	}
+}
+
+func TestPublishArrayGzip(t *testing.T) {
+	server, requests := newTestServer(t, 200)
+	defer server.Close()
+
+	c := newTestClient(t, clientSettings{
+		URL:              server.URL,
+		BearerToken:      "token",
+		BatchFormat:      formatArray,
+		CompressionLevel: 5,
+	})
+
+	rest, err := c.PublishEvents(testData(2))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	req := <-requests
+	assert.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	assert.Equal(t, "gzip", req.header.Get("Content-Encoding"))
+
+	var events []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(req.body), &events))
+	assert.Len(t, events, 2)
+}
+
+func TestPublishSplitByBytes(t *testing.T) {
+	server, requests := newTestServer(t, 200)
+	defer server.Close()
+
+	data := testData(3)
+	event, _ := jsonCodec.New(false).Encode(data[0].Event)
+
+	c := newTestClient(t, clientSettings{
+		URL:      server.URL,
+		MaxBytes: 2*len(event) + 2,
+	})
+
+	rest, err := c.PublishEvents(data)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 1)
+	assert.Len(t, strings.Split(strings.TrimSpace((<-requests).body), "\n"), 2)
+
+	rest, err = c.PublishEvents(rest)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+	assert.Len(t, strings.Split(strings.TrimSpace((<-requests).body), "\n"), 1)
+}
+
+func TestPublishRetryableStatus(t *testing.T) {
+	for _, status := range []int{429, 500, 503} {
+		server, requests := newTestServer(t, status)
+
+		c := newTestClient(t, clientSettings{URL: server.URL})
+		data := testData(2)
+		rest, err := c.PublishEvents(data)
+		assert.Error(t, err, "status %v", status)
+		assert.Len(t, rest, len(data), "status %v", status)
+		<-requests
+
+		server.Close()
+	}
+}
+
+func TestPublishDropOnClientError(t *testing.T) {
+	server, requests := newTestServer(t, 400)
+	defer server.Close()
+
+	c := newTestClient(t, clientSettings{URL: server.URL})
+	rest, err := c.PublishEvents(testData(2))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+	<-requests
+}
+
+func TestGetURL(t *testing.T) {
+	tests := []struct {
+		scheme, path, host string
+		expected           string
+	}{
+		{"", "", "localhost:8080", "http://localhost:8080"},
+		{"https", "/events", "example.com", "https://example.com/events"},
+		{"https", "/events", "http://example.com/hook", "http://example.com/hook"},
+	}
+
+	for _, test := range tests {
+		url, err := getURL(test.scheme, test.path, test.host)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, url)
+	}
+}
+
+func TestNewOutputConfig(t *testing.T) {
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"hosts":        []string{"localhost:8080", "localhost:8081"},
+		"batch_format": "xml",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = New("test", cfg, 0)
+	assert.Error(t, err)
+
+	cfg, _ = common.NewConfigFrom(map[string]interface{}{
+		"hosts": []string{"localhost:8080", "localhost:8081"},
+	})
+	out, err := New("test", cfg, 0)
+	if assert.NoError(t, err) {
+		out.Close()
+	}
+}
+
+func TestOutputTester(t *testing.T) {
+	server, requests := newTestServer(t, http.StatusOK)
+	defer server.Close()
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"hosts": []string{server.URL},
+	})
+	assert.NoError(t, err)
+
+	buf := bytes.NewBuffer(nil)
+	d := beattest.NewConsoleDriver(buf)
+	testOutput("testbeat", cfg, d)
+
+	assert.False(t, d.Failed(), buf.String())
+	assert.Contains(t, buf.String(), "dial up... OK")
+
+	// The endpoint does not receive any request
+	assert.Len(t, requests, 0)
+}
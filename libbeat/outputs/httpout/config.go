@@ -0,0 +1,100 @@
+package httpout
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/outputs"
+)
+
+type httpConfig struct {
+	Protocol         string              `config:"protocol"`
+	Path             string              `config:"path"`
+	Params           map[string]string   `config:"parameters"`
+	Headers          map[string]string   `config:"headers"`
+	Username         string              `config:"username"`
+	Password         string              `config:"password"`
+	BearerToken      string              `config:"bearer_token"`
+	ProxyURL         string              `config:"proxy_url"`
+	LoadBalance      bool                `config:"loadbalance"`
+	BatchFormat      string              `config:"batch_format"`
+	BulkMaxBytes     int                 `config:"bulk_max_bytes" validate:"min=0"`
+	CompressionLevel int                 `config:"compression_level" validate:"min=0, max=9"`
+	TLS              *outputs.TLSConfig  `config:"ssl"`
+	MaxRetries       int                 `config:"max_retries"`
+	Timeout          time.Duration       `config:"timeout"`
+	Backoff          backoffConfig       `config:"backoff"`
+	Codec            outputs.CodecConfig `config:"codec"`
+}
+
+type backoffConfig struct {
+	Init time.Duration `config:"init" validate:"nonzero"`
+	Max  time.Duration `config:"max" validate:"nonzero"`
+}
+
+const (
+	defaultBulkSize = 50
+
+	formatNDJSON = "ndjson"
+	formatArray  = "array"
+)
+
+var (
+	defaultConfig = httpConfig{
+		Protocol:         "",
+		Path:             "",
+		ProxyURL:         "",
+		Params:           nil,
+		Username:         "",
+		Password:         "",
+		BearerToken:      "",
+		BatchFormat:      formatNDJSON,
+		BulkMaxBytes:     0,
+		Timeout:          90 * time.Second,
+		MaxRetries:       3,
+		CompressionLevel: 0,
+		TLS:              nil,
+		LoadBalance:      true,
+		Backoff: backoffConfig{
+			Init: 1 * time.Second,
+			Max:  60 * time.Second,
+		},
+	}
+)
+
+func (c *httpConfig) Validate() error {
+	switch c.BatchFormat {
+	case formatNDJSON, formatArray:
+	default:
+		return fmt.Errorf("batch_format %v not supported", c.BatchFormat)
+	}
+
+	if c.BearerToken != "" && (c.Username != "" || c.Password != "") {
+		return fmt.Errorf("bearer_token can not be combined with username and password")
+	}
+
+	if c.Backoff.Max < c.Backoff.Init {
+		return fmt.Errorf("backoff.max must not be less than backoff.init")
+	}
+
+	if c.ProxyURL != "" {
+		if _, err := parseProxyURL(c.ProxyURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err == nil && strings.HasPrefix(u.Scheme, "http") {
+		return u, err
+	}
+
+	// Proxy was bogus. Try prepending "http://" to it and
+	// see if that parses correctly.
+	return url.Parse("http://" + raw)
+}
@@ -0,0 +1,227 @@
+// Package httpout implements an output plugin POSTing batches of events to
+// arbitrary HTTP endpoints (webhooks).
+package httpout
+
+import (
+	"expvar"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/op"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+type httpOutput struct {
+	beatName string
+	mode     mode.ConnectionMode
+}
+
+var debugf = logp.MakeDebug("http")
+
+// Metrics that can retrieved through the expvar web interface.
+var (
+	ackedEvents     = expvar.NewInt("libbeat.http.published_and_acked_events")
+	eventsNotAcked  = expvar.NewInt("libbeat.http.published_but_not_acked_events")
+	eventsDropped   = expvar.NewInt("libbeat.http.published_but_dropped_events")
+	statReadBytes   = expvar.NewInt("libbeat.http.publish.read_bytes")
+	statWriteBytes  = expvar.NewInt("libbeat.http.publish.write_bytes")
+	statReadErrors  = expvar.NewInt("libbeat.http.publish.read_errors")
+	statWriteErrors = expvar.NewInt("libbeat.http.publish.write_errors")
+)
+
+var hasScheme = regexp.MustCompile(`^([a-z][a-z0-9+\-.]*)://`)
+
+func init() {
+	outputs.RegisterOutputPlugin("http", New)
+	outputs.RegisterOutputTester("http", testOutput)
+}
+
+// New instantiates a new output plugin instance publishing to HTTP endpoints.
+func New(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
+	if !cfg.HasField("bulk_max_size") {
+		cfg.SetInt("bulk_max_size", -1, defaultBulkSize)
+	}
+
+	output := &httpOutput{beatName: beatName}
+	if err := output.init(cfg); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// testOutput tests the connections to the endpoints. No request is sent, as
+// the endpoints would process it like a batch of events.
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	var tlsConfig *transport.TLSConfig
+	if err == nil {
+		tlsConfig, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var proxyURL *url.URL
+	if err == nil && config.ProxyURL != "" {
+		proxyURL, err = parseProxyURL(config.ProxyURL)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	for _, host := range hosts {
+		d.Run("http: "+host, func(d testing.Driver) {
+			hostURL, err := getURL(config.Protocol, config.Path, host)
+			var u *url.URL
+			if err == nil {
+				u, err = url.Parse(hostURL)
+			}
+			d.Fatal("parse url", err)
+
+			transp := &transport.Config{Timeout: config.Timeout}
+			addr, port := u.Host, 80
+			if proxyURL != nil {
+				d.Info("proxy", proxyURL.String())
+				addr = proxyURL.Host
+			} else if u.Scheme == "https" {
+				port = 443
+				transp.TLS = tlsConfig
+				if transp.TLS == nil {
+					transp.TLS = &transport.TLSConfig{}
+				}
+			}
+
+			conn := transport.TestDial(d, transp, "tcp", addr, port)
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+}
+
+func (out *httpOutput) init(cfg *common.Config) error {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return err
+	}
+
+	tlsConfig, err := outputs.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return err
+	}
+
+	var proxyURL *url.URL
+	if config.ProxyURL != "" {
+		proxyURL, err = parseProxyURL(config.ProxyURL)
+		if err != nil {
+			return err
+		}
+
+		logp.Info("Using proxy URL: %s", proxyURL)
+	}
+
+	clients, err := modeutil.MakeClients(cfg, func(host string) (mode.ProtocolClient, error) {
+		hostURL, err := getURL(config.Protocol, config.Path, host)
+		if err != nil {
+			logp.Err("Invalid host param set: %s, Error: %v", host, err)
+			return nil, err
+		}
+
+		codec, err := outputs.CreateEncoder(config.Codec)
+		if err != nil {
+			return nil, err
+		}
+
+		return newClient(clientSettings{
+			URL:              hostURL,
+			Proxy:            proxyURL,
+			TLS:              tlsConfig,
+			Username:         config.Username,
+			Password:         config.Password,
+			BearerToken:      config.BearerToken,
+			Parameters:       config.Params,
+			Headers:          config.Headers,
+			Timeout:          config.Timeout,
+			CompressionLevel: config.CompressionLevel,
+			BatchFormat:      config.BatchFormat,
+			MaxBytes:         config.BulkMaxBytes,
+			Codec:            codec,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	maxRetries := config.MaxRetries
+	maxAttempts := maxRetries + 1 // maximum number of send attempts (-1 = infinite)
+	if maxRetries < 0 {
+		maxAttempts = 0
+	}
+
+	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
+		Failover:     !config.LoadBalance,
+		MaxAttempts:  maxAttempts,
+		Timeout:      config.Timeout,
+		WaitRetry:    config.Backoff.Init,
+		MaxWaitRetry: config.Backoff.Max,
+	})
+	if err != nil {
+		return err
+	}
+
+	out.mode = m
+	return nil
+}
+
+func (out *httpOutput) Close() error {
+	return out.mode.Close()
+}
+
+func (out *httpOutput) PublishEvent(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data outputs.Data,
+) error {
+	return out.mode.PublishEvent(signaler, opts, data)
+}
+
+func (out *httpOutput) BulkPublish(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data []outputs.Data,
+) error {
+	return out.mode.PublishEvents(signaler, opts, data)
+}
+
+// getURL creates the endpoint URL from a configured host, adding the default
+// scheme and path if missing.
+func getURL(defaultScheme, defaultPath, rawURL string) (string, error) {
+	if defaultScheme == "" {
+		defaultScheme = "http"
+	}
+
+	if !hasScheme.MatchString(rawURL) {
+		rawURL = fmt.Sprintf("%v://%v", defaultScheme, rawURL)
+	}
+
+	addr, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if addr.Host == "" {
+		return "", fmt.Errorf("missing host in URL '%v'", rawURL)
+	}
+
+	if addr.Path == "" {
+		addr.Path = defaultPath
+	}
+	return addr.String(), nil
+}
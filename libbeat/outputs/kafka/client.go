@@ -183,6 +183,7 @@ func (c *client) successWorker(ch <-chan *sarama.ProducerMessage) {
 
 	for libMsg := range ch {
 		msg := libMsg.Metadata.(*message)
+		statsTopics.published(libMsg.Topic, libMsg.Partition)
 		msg.ref.done()
 	}
 }
@@ -193,6 +194,7 @@ func (c *client) errorWorker(ch <-chan *sarama.ProducerError) {
 
 	for errMsg := range ch {
 		msg := errMsg.Msg.Metadata.(*message)
+		statsTopics.failed(errMsg.Msg.Topic, errMsg.Msg.Partition)
 		msg.ref.fail(msg, errMsg.Err)
 	}
 }
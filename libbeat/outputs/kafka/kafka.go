@@ -20,6 +20,8 @@ import (
 	"github.com/elastic/beats/libbeat/outputs/mode"
 	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
 	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
 )
 
 type kafka struct {
@@ -33,6 +35,8 @@ type kafka struct {
 }
 
 const (
+	defaultPort = 9092
+
 	defaultWaitRetry = 1 * time.Second
 
 	// NOTE: maxWaitRetry has no effect on mode, as logstash client currently does
@@ -52,6 +56,55 @@ func init() {
 	kafkaMetricsRegistryInstance = reg
 
 	outputs.RegisterOutputPlugin("kafka", New)
+	outputs.RegisterOutputTester("kafka", testOutput)
+}
+
+// testOutput connects to every configured Kafka broker and requests the
+// cluster metadata.
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var tls *transport.TLSConfig
+	if err == nil {
+		tls, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var kafkaConfig *sarama.Config
+	if err == nil {
+		kafkaConfig, err = newKafkaConfig(&config)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		TLS:     tls,
+	}
+
+	for _, host := range config.Hosts {
+		d.Run("kafka: "+host, func(d testing.Driver) {
+			conn := transport.TestDial(d, transp, "tcp", host, defaultPort)
+			if conn == nil {
+				return
+			}
+			conn.Close()
+
+			broker := sarama.NewBroker(host)
+			d.Run("talk to server", func(d testing.Driver) {
+				d.Fatal("open", broker.Open(kafkaConfig))
+				defer broker.Close()
+
+				resp, err := broker.GetMetadata(&sarama.MetadataRequest{})
+				d.Fatal("metadata", err)
+
+				var brokers []string
+				for _, b := range resp.Brokers {
+					brokers = append(brokers, b.Addr())
+				}
+				d.Info("brokers", strings.Join(brokers, ", "))
+			})
+		})
+	}
 }
 
 var kafkaMetricsOnce sync.Once
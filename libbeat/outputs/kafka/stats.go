@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+// topicStats collects the number of published and failed events per kafka
+// topic and partition. The counters are registered with the monitoring
+// registry as `<topic>.<partition>.published` and `<topic>.<partition>.failed`.
+type topicStats struct {
+	mutex      sync.Mutex
+	name       string
+	reg        *monitoring.Registry
+	partitions map[topicPartition]*partitionStats
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type partitionStats struct {
+	published *monitoring.Int
+	failed    *monitoring.Int
+}
+
+var statsTopics = newTopicStats("libbeat.output.kafka.topics")
+
+func newTopicStats(name string) *topicStats {
+	return &topicStats{
+		name:       name,
+		partitions: map[topicPartition]*partitionStats{},
+	}
+}
+
+func (s *topicStats) published(topic string, partition int32) {
+	s.get(topic, partition).published.Inc()
+}
+
+func (s *topicStats) failed(topic string, partition int32) {
+	s.get(topic, partition).failed.Inc()
+}
+
+func (s *topicStats) get(topic string, partition int32) *partitionStats {
+	key := topicPartition{topic, partition}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if st := s.partitions[key]; st != nil {
+		return st
+	}
+
+	if s.reg == nil {
+		s.reg = monitoring.Default.GetRegistry(s.name)
+		if s.reg == nil {
+			s.reg = monitoring.Default.NewRegistry(s.name)
+		}
+	}
+
+	// registry names are split on dots, but dots are valid in topic names
+	name := strings.Replace(topic, ".", "_", -1) + "." + strconv.Itoa(int(partition))
+	reg := s.reg.GetRegistry(name)
+	if reg == nil {
+		reg = s.reg.NewRegistry(name)
+	}
+
+	st := &partitionStats{
+		published: getInt(reg, "published"),
+		failed:    getInt(reg, "failed"),
+	}
+	s.partitions[key] = st
+	return st
+}
+
+// getInt returns the existing counter named name, as topics using dots and
+// underscores might share the same registry.
+func getInt(reg *monitoring.Registry, name string) *monitoring.Int {
+	if v, ok := reg.Get(name).(*monitoring.Int); ok {
+		return v
+	}
+	return monitoring.NewInt(reg, name)
+}
@@ -0,0 +1,36 @@
+// +build !integration
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/monitoring"
+)
+
+func TestTopicStats(t *testing.T) {
+	stats := newTopicStats("test.kafka.topics")
+	defer monitoring.Default.Remove("test.kafka.topics")
+
+	stats.published("logs", 0)
+	stats.published("logs", 0)
+	stats.published("logs", 1)
+	stats.failed("logs", 1)
+	stats.published("my.topic", 2)
+
+	get := func(name string) int64 {
+		v, ok := monitoring.Default.Get("test.kafka.topics." + name).(*monitoring.Int)
+		if !ok {
+			t.Fatalf("missing counter %v", name)
+		}
+		return v.Get()
+	}
+
+	assert.Equal(t, int64(2), get("logs.0.published"))
+	assert.Equal(t, int64(0), get("logs.0.failed"))
+	assert.Equal(t, int64(1), get("logs.1.published"))
+	assert.Equal(t, int64(1), get("logs.1.failed"))
+	assert.Equal(t, int64(1), get("my_topic.2.published"))
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/elastic/beats/libbeat/outputs/mode"
 	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
 	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
 )
 
 var debug = logp.MakeDebug("logstash")
@@ -45,6 +46,7 @@ func init() {
 	log.Logger = logstashLogger{}
 
 	outputs.RegisterOutputPlugin("logstash", new)
+	outputs.RegisterOutputTester("logstash", testOutput)
 }
 
 func new(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
@@ -60,6 +62,39 @@ func new(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
 	return output, nil
 }
 
+// testOutput checks a connection can be established to every configured
+// Logstash host.
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var tls *transport.TLSConfig
+	if err == nil {
+		tls, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		Proxy:   &config.Proxy,
+		TLS:     tls,
+	}
+
+	for _, host := range hosts {
+		d.Run("logstash: "+host, func(d testing.Driver) {
+			conn := transport.TestDial(d, transp, "tcp", host, config.Port)
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+}
+
 type logstash struct {
 	mode  mode.ConnectionMode
 	index string
@@ -92,7 +92,16 @@ func New(
 func (m *LB) Close() error {
 	m.ctx.Close()
 	m.wg.Wait()
-	return nil
+
+	// Events returned by workers for retrying are not picked up anymore
+	for {
+		select {
+		case msg := <-m.ctx.retries:
+			dropping(msg)
+		default:
+			return nil
+		}
+	}
 }
 
 func (m *LB) start(makeWorkers WorkerFactory) error {
@@ -2,6 +2,7 @@ package single
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
@@ -18,7 +19,12 @@ type Mode struct {
 	conn        mode.ProtocolClient
 	isConnected bool
 
-	closed bool // mode closed flag to break publisher loop
+	// sendMutex is held while connecting and sending, such that Close does
+	// not close the connection while a publish attempt is in progress.
+	sendMutex sync.Mutex
+
+	done      chan struct{} // closed by Close to break the publisher loop
+	closeOnce sync.Once
 
 	timeout time.Duration // connection timeout
 	backoff *common.Backoff
@@ -41,11 +47,13 @@ func New(
 	maxAttempts int,
 	waitRetry, timeout, maxWaitRetry time.Duration,
 ) (*Mode, error) {
+	done := make(chan struct{})
 	s := &Mode{
 		conn: client,
+		done: done,
 
 		timeout:     timeout,
-		backoff:     common.NewBackoff(nil, waitRetry, maxWaitRetry),
+		backoff:     common.NewBackoff(done, waitRetry, maxWaitRetry),
 		maxAttempts: maxAttempts,
 	}
 
@@ -62,12 +70,26 @@ func (s *Mode) connect() error {
 	return err
 }
 
-// Close closes the underlying connection.
+// Close stops publishing, waits for the publish attempt in progress to
+// finish and closes the underlying connection. Events not published are
+// signaled as failed.
 func (s *Mode) Close() error {
-	s.closed = true
+	s.closeOnce.Do(func() { close(s.done) })
+
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
 	return s.closeClient()
 }
 
+func (s *Mode) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
 func (s *Mode) closeClient() error {
 	err := s.conn.Close()
 	s.isConnected = false
@@ -131,28 +153,19 @@ func (s *Mode) publish(
 	var err error
 
 	guaranteed := opts.Guaranteed || s.maxAttempts == 0
-	for !s.closed && (guaranteed || fails < s.maxAttempts) {
-
-		ok := false
-		resetFail := false
-
-		if err := s.connect(); err != nil {
-			logp.Err("Connecting error publishing events (retrying): %s", err)
-			goto sendFail
+	for !s.isClosed() && (guaranteed || fails < s.maxAttempts) {
+		ok, resetFail, closed := s.trySend(send)
+		if closed {
+			break
 		}
 
-		ok, resetFail = send()
-		if !ok {
-			s.closeClient()
-			goto sendFail
+		if ok {
+			debugf("send completed")
+			s.backoff.Reset()
+			op.SigCompleted(signaler)
+			return nil
 		}
 
-		debugf("send completed")
-		s.backoff.Reset()
-		op.SigCompleted(signaler)
-		return nil
-
-	sendFail:
 		debugf("send fail")
 
 		fails++
@@ -175,3 +188,25 @@ func (s *Mode) publish(
 	op.SigFailed(signaler, err)
 	return nil
 }
+
+// trySend connects if required and runs one send attempt. closed is set if
+// the mode has been closed and no attempt has been made.
+func (s *Mode) trySend(send func() (bool, bool)) (ok, resetFail, closed bool) {
+	s.sendMutex.Lock()
+	defer s.sendMutex.Unlock()
+
+	if s.isClosed() {
+		return false, false, true
+	}
+
+	if err := s.connect(); err != nil {
+		logp.Err("Connecting error publishing events (retrying): %s", err)
+		return false, false, false
+	}
+
+	ok, resetFail = send()
+	if !ok {
+		s.closeClient()
+	}
+	return ok, resetFail, false
+}
@@ -5,6 +5,8 @@ import (
 	"expvar"
 	"time"
 
+	"github.com/garyburd/redigo/redis"
+
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/op"
 	"github.com/elastic/beats/libbeat/logp"
@@ -13,6 +15,7 @@ import (
 	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
 	"github.com/elastic/beats/libbeat/outputs/outil"
 	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
 )
 
 type redisOut struct {
@@ -38,6 +41,7 @@ const (
 
 func init() {
 	outputs.RegisterOutputPlugin("redis", new)
+	outputs.RegisterOutputTester("redis", testOutput)
 }
 
 func new(beatName string, cfg *common.Config, expireTopo int) (outputs.Outputer, error) {
@@ -48,6 +52,44 @@ func new(beatName string, cfg *common.Config, expireTopo int) (outputs.Outputer,
 	return r, nil
 }
 
+// testOutput connects to every configured Redis host and checks the server
+// answers to PING, authenticating first if a password is configured.
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var tls *transport.TLSConfig
+	if err == nil {
+		tls, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		Proxy:   &config.Proxy,
+		TLS:     tls,
+	}
+
+	for _, host := range hosts {
+		d.Run("redis: "+host, func(d testing.Driver) {
+			conn := transport.TestDial(d, transp, "tcp", host, config.Port)
+			if conn == nil {
+				return
+			}
+
+			c := redis.NewConn(conn, config.Timeout, config.Timeout)
+			defer c.Close()
+
+			d.Error("talk to server", initRedisConn(c, config.Password, config.Db))
+		})
+	}
+}
+
 func (r *redisOut) init(cfg *common.Config, expireTopo int) error {
 	config := defaultConfig
 	if err := cfg.Unpack(&config); err != nil {
@@ -0,0 +1,181 @@
+package syslog
+
+import (
+	"bytes"
+	"strconv"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+type client struct {
+	*transport.Client
+	datagram bool
+	format   string
+	framing  string
+	hostname string
+	timeout  time.Duration
+
+	facility outil.Selector
+	severity outil.Selector
+	appName  outil.Selector
+	codec    outputs.Codec
+
+	buf bytes.Buffer
+	msg bytes.Buffer
+}
+
+type selectors struct {
+	facility outil.Selector
+	severity outil.Selector
+	appName  outil.Selector
+}
+
+func newClient(
+	tc *transport.Client,
+	config *syslogConfig,
+	sel selectors,
+	hostname string,
+	codec outputs.Codec,
+) *client {
+	framing := config.Framing
+	if framing == "" {
+		framing = framingOctetCounting
+		if config.Format == formatRFC3164 {
+			framing = framingNonTransparent
+		}
+	}
+
+	return &client{
+		Client:   tc,
+		datagram: isDatagram(config.Network),
+		format:   config.Format,
+		framing:  framing,
+		hostname: hostname,
+		timeout:  config.Timeout,
+		facility: sel.facility,
+		severity: sel.severity,
+		appName:  sel.appName,
+		codec:    codec,
+	}
+}
+
+func (c *client) Connect(timeout time.Duration) error {
+	debugf("connect")
+	return c.Client.Connect()
+}
+
+func (c *client) Close() error {
+	debugf("close connection")
+	return c.Client.Close()
+}
+
+func (c *client) PublishEvent(data outputs.Data) error {
+	_, err := c.PublishEvents([]outputs.Data{data})
+	return err
+}
+
+// PublishEvents writes one syslog message per event. On write failure the
+// events not yet written are returned.
+func (c *client) PublishEvents(data []outputs.Data) ([]outputs.Data, error) {
+	for i, d := range data {
+		if !c.encode(d.Event) {
+			eventsDropped.Add(1)
+			continue
+		}
+
+		if c.timeout > 0 {
+			c.SetWriteDeadline(time.Now().Add(c.timeout))
+		}
+		if _, err := c.Write(c.buf.Bytes()); err != nil {
+			logp.Err("Failed to write syslog message: %v", err)
+			return data[i:], err
+		}
+		ackedEvents.Add(1)
+	}
+	return nil, nil
+}
+
+// encode writes the framed syslog message for event into the client buffer.
+// It returns false if the event could not be encoded.
+func (c *client) encode(event common.MapStr) bool {
+	body, err := c.codec.Encode(event)
+	if err != nil {
+		return false
+	}
+
+	h, err := c.makeHeader(event)
+	if err != nil {
+		logp.Err("Failed to create syslog header: %v", err)
+		return false
+	}
+
+	c.msg.Reset()
+	if c.format == formatRFC3164 {
+		writeRFC3164(&c.msg, &h, body)
+	} else {
+		writeRFC5424(&c.msg, &h, body)
+	}
+
+	c.buf.Reset()
+	switch {
+	case c.datagram:
+		c.buf.Write(c.msg.Bytes())
+	case c.framing == framingOctetCounting:
+		c.buf.WriteString(strconv.Itoa(c.msg.Len()))
+		c.buf.WriteByte(' ')
+		c.buf.Write(c.msg.Bytes())
+	default:
+		c.buf.Write(c.msg.Bytes())
+		c.buf.WriteByte('\n')
+	}
+	return true
+}
+
+func (c *client) makeHeader(event common.MapStr) (header, error) {
+	h := header{
+		timestamp: time.Now(),
+		hostname:  c.hostname,
+	}
+
+	if ts, ok := event["@timestamp"].(common.Time); ok {
+		h.timestamp = time.Time(ts)
+	}
+	if hostname, err := event.GetValue("beat.hostname"); err == nil {
+		if s, ok := hostname.(string); ok && s != "" {
+			h.hostname = s
+		}
+	}
+
+	var err error
+	h.facility, err = parseFacility(selectOr(c.facility, event, defaultFacility))
+	if err != nil {
+		return h, err
+	}
+	h.severity, err = parseSeverity(selectOr(c.severity, event, defaultSeverity))
+	if err != nil {
+		return h, err
+	}
+	h.appName = selectOr(c.appName, event, "")
+	return h, nil
+}
+
+func selectOr(sel outil.Selector, event common.MapStr, fallback string) string {
+	s, err := sel.Select(event)
+	if err != nil || s == "" {
+		return fallback
+	}
+	return s
+}
+
+func isDatagram(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6":
+		return true
+	}
+	return false
+}
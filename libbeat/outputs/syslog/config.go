@@ -0,0 +1,74 @@
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+)
+
+type syslogConfig struct {
+	Network     string                `config:"network"`
+	Port        int                   `config:"port"`
+	Format      string                `config:"format"`
+	Framing     string                `config:"framing"`
+	Hostname    string                `config:"hostname"`
+	LoadBalance bool                  `config:"loadbalance"`
+	Timeout     time.Duration         `config:"timeout"`
+	MaxRetries  int                   `config:"max_retries"`
+	TLS         *outputs.TLSConfig    `config:"ssl"`
+	Proxy       transport.ProxyConfig `config:",inline"`
+	Codec       outputs.CodecConfig   `config:"codec"`
+}
+
+const (
+	formatRFC5424 = "rfc5424"
+	formatRFC3164 = "rfc3164"
+
+	framingOctetCounting  = "octet_counting"
+	framingNonTransparent = "non_transparent"
+)
+
+var (
+	defaultConfig = syslogConfig{
+		Network:     "udp",
+		Port:        514,
+		Format:      formatRFC5424,
+		Framing:     "",
+		LoadBalance: true,
+		Timeout:     5 * time.Second,
+		MaxRetries:  3,
+		TLS:         nil,
+	}
+)
+
+func (c *syslogConfig) Validate() error {
+	switch c.Network {
+	case "udp", "udp4", "udp6":
+		if c.TLS.IsEnabled() {
+			return errors.New("SSL is not supported with network type udp")
+		}
+		if c.Proxy.URL != "" {
+			return errors.New("proxy_url is not supported with network type udp")
+		}
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return fmt.Errorf("network type %v not supported", c.Network)
+	}
+
+	switch c.Format {
+	case formatRFC5424, formatRFC3164:
+	default:
+		return fmt.Errorf("syslog format %v not supported", c.Format)
+	}
+
+	switch c.Framing {
+	case "", framingOctetCounting, framingNonTransparent:
+	default:
+		return fmt.Errorf("syslog framing %v not supported", c.Framing)
+	}
+
+	return nil
+}
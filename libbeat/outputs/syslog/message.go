@@ -0,0 +1,158 @@
+package syslog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var facilities = map[string]int{
+	"kern":         0,
+	"user":         1,
+	"mail":         2,
+	"daemon":       3,
+	"auth":         4,
+	"syslog":       5,
+	"lpr":          6,
+	"news":         7,
+	"uucp":         8,
+	"cron":         9,
+	"authpriv":     10,
+	"ftp":          11,
+	"ntp":          12,
+	"security":     13,
+	"console":      14,
+	"solaris-cron": 15,
+	"local0":       16,
+	"local1":       17,
+	"local2":       18,
+	"local3":       19,
+	"local4":       20,
+	"local5":       21,
+	"local6":       22,
+	"local7":       23,
+}
+
+var severities = map[string]int{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"error":   3,
+	"warning": 4,
+	"warn":    4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+const (
+	defaultFacility = "user"
+	defaultSeverity = "info"
+
+	nilValue = "-"
+
+	// maximum field lengths as defined by RFC5424
+	maxHostnameLen = 255
+	maxAppNameLen  = 48
+	maxProcIDLen   = 128
+	maxMsgIDLen    = 32
+
+	// maximum TAG length as defined by RFC3164
+	maxTagLen = 32
+)
+
+type header struct {
+	facility  int
+	severity  int
+	timestamp time.Time
+	hostname  string
+	appName   string
+	procID    string
+	msgID     string
+}
+
+// parseFacility accepts a facility name or its numeric code.
+func parseFacility(s string) (int, error) {
+	return parseCode(s, facilities, 23, "facility")
+}
+
+// parseSeverity accepts a severity name or its numeric code.
+func parseSeverity(s string) (int, error) {
+	return parseCode(s, severities, 7, "severity")
+}
+
+func parseCode(s string, names map[string]int, max int, kind string) (int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, exists := names[s]; exists {
+		return code, nil
+	}
+
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 0 || code > max {
+		return 0, fmt.Errorf("invalid syslog %v '%v'", kind, s)
+	}
+	return code, nil
+}
+
+func (h *header) priority() int {
+	return h.facility*8 + h.severity
+}
+
+// writeRFC5424 writes a RFC5424 formatted message into buf. The message
+// contains no structured data.
+func writeRFC5424(buf *bytes.Buffer, h *header, msg []byte) {
+	fmt.Fprintf(buf, "<%d>1 ", h.priority())
+	buf.WriteString(h.timestamp.Format("2006-01-02T15:04:05.000000Z07:00"))
+	buf.WriteByte(' ')
+	buf.WriteString(headerField(h.hostname, maxHostnameLen))
+	buf.WriteByte(' ')
+	buf.WriteString(headerField(h.appName, maxAppNameLen))
+	buf.WriteByte(' ')
+	buf.WriteString(headerField(h.procID, maxProcIDLen))
+	buf.WriteByte(' ')
+	buf.WriteString(headerField(h.msgID, maxMsgIDLen))
+	buf.WriteString(" - ")
+	buf.Write(msg)
+}
+
+// writeRFC3164 writes a BSD syslog formatted message into buf.
+func writeRFC3164(buf *bytes.Buffer, h *header, msg []byte) {
+	fmt.Fprintf(buf, "<%d>", h.priority())
+	buf.WriteString(h.timestamp.Format(time.Stamp))
+	buf.WriteByte(' ')
+	buf.WriteString(headerField(h.hostname, maxHostnameLen))
+	buf.WriteByte(' ')
+
+	tag := h.appName
+	if len(tag) > maxTagLen {
+		tag = tag[:maxTagLen]
+	}
+	buf.WriteString(strings.Map(printASCII, tag))
+	if h.procID != "" {
+		fmt.Fprintf(buf, "[%v]", h.procID)
+	}
+	buf.WriteString(": ")
+	buf.Write(msg)
+}
+
+// headerField normalizes a RFC5424 header field, replacing non printable
+// characters and truncating it to max bytes.
+func headerField(s string, max int) string {
+	if s == "" {
+		return nilValue
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return strings.Map(printASCII, s)
+}
+
+func printASCII(r rune) rune {
+	if r < 33 || r > 126 {
+		return '_'
+	}
+	return r
+}
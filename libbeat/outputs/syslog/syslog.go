@@ -0,0 +1,235 @@
+// Package syslog implements an output plugin forwarding events as RFC5424 or
+// RFC3164 syslog messages via UDP, TCP or TLS.
+package syslog
+
+import (
+	"expvar"
+	"os"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/op"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+	"github.com/elastic/beats/libbeat/outputs/mode/modeutil"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+type syslogOut struct {
+	mode     mode.ConnectionMode
+	beatName string
+}
+
+var debugf = logp.MakeDebug("syslog")
+
+// Metrics that can retrieved through the expvar web interface.
+var (
+	ackedEvents   = expvar.NewInt("libbeat.syslog.published_events")
+	eventsDropped = expvar.NewInt("libbeat.syslog.dropped_events")
+
+	statReadBytes   = expvar.NewInt("libbeat.syslog.publish.read_bytes")
+	statWriteBytes  = expvar.NewInt("libbeat.syslog.publish.write_bytes")
+	statReadErrors  = expvar.NewInt("libbeat.syslog.publish.read_errors")
+	statWriteErrors = expvar.NewInt("libbeat.syslog.publish.write_errors")
+)
+
+const (
+	defaultWaitRetry    = 1 * time.Second
+	defaultMaxWaitRetry = 60 * time.Second
+)
+
+func init() {
+	outputs.RegisterOutputPlugin("syslog", new)
+	outputs.RegisterOutputTester("syslog", testOutput)
+}
+
+func new(beatName string, cfg *common.Config, _ int) (outputs.Outputer, error) {
+	s := &syslogOut{beatName: beatName}
+	if err := s.init(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func testOutput(beatName string, cfg *common.Config, d testing.Driver) {
+	config := defaultConfig
+	err := cfg.Unpack(&config)
+	var tls *transport.TLSConfig
+	if err == nil {
+		tls, err = outputs.LoadTLSConfig(config.TLS)
+	}
+	var hosts []string
+	if err == nil {
+		hosts, err = modeutil.ReadHostList(cfg)
+	}
+	if err != nil {
+		d.Fatal("config", err)
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		TLS:     tls,
+	}
+	if !isDatagram(config.Network) {
+		transp.Proxy = &config.Proxy
+	}
+
+	for _, host := range hosts {
+		d.Run("syslog: "+host, func(d testing.Driver) {
+			conn := transport.TestDial(d, transp, config.Network, host, config.Port)
+			if conn == nil {
+				return
+			}
+			conn.Close()
+
+			// Sending datagrams succeeds without a server listening
+			if isDatagram(config.Network) {
+				d.Warn("delivery", "can not be tested with network type "+config.Network)
+			}
+		})
+	}
+}
+
+func (s *syslogOut) init(cfg *common.Config) error {
+	config := defaultConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return err
+	}
+
+	sendRetries := config.MaxRetries
+	maxAttempts := config.MaxRetries + 1
+	if sendRetries < 0 {
+		maxAttempts = 0
+	}
+
+	if !cfg.HasField("app_name") && !cfg.HasField("app_names") {
+		cfg.SetString("app_name", -1, s.beatName)
+	}
+
+	var sel selectors
+	var err error
+	for _, b := range []struct {
+		sel           *outil.Selector
+		key, multiKey string
+	}{
+		{&sel.facility, "facility", "facilities"},
+		{&sel.severity, "severity", "severities"},
+		{&sel.appName, "app_name", "app_names"},
+	} {
+		*b.sel, err = outil.BuildSelectorFromConfig(cfg, outil.Settings{
+			Key:              b.key,
+			MultiKey:         b.multiKey,
+			EnableSingleOnly: true,
+			FailEmpty:        false,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := checkConstSelectors(sel); err != nil {
+		return err
+	}
+
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname, err = os.Hostname()
+		if err != nil {
+			return err
+		}
+	}
+
+	tls, err := outputs.LoadTLSConfig(config.TLS)
+	if err != nil {
+		return err
+	}
+
+	// proxy support is only available for stream based connections
+	var proxy *transport.ProxyConfig
+	if !isDatagram(config.Network) {
+		proxy = &config.Proxy
+	}
+
+	transp := &transport.Config{
+		Timeout: config.Timeout,
+		Proxy:   proxy,
+		TLS:     tls,
+		Stats: &transport.IOStats{
+			Read:        statReadBytes,
+			Write:       statWriteBytes,
+			ReadErrors:  statReadErrors,
+			WriteErrors: statWriteErrors,
+		},
+	}
+
+	clients, err := modeutil.MakeClients(cfg, func(host string) (mode.ProtocolClient, error) {
+		t, err := transport.NewClient(transp, config.Network, host, config.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		codec, err := outputs.CreateEncoder(config.Codec)
+		if err != nil {
+			return nil, err
+		}
+
+		return newClient(t, &config, sel, hostname, codec), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logp.Info("Max Retries set to: %v", sendRetries)
+	m, err := modeutil.NewConnectionMode(clients, modeutil.Settings{
+		Failover:     !config.LoadBalance,
+		MaxAttempts:  maxAttempts,
+		Timeout:      config.Timeout,
+		WaitRetry:    defaultWaitRetry,
+		MaxWaitRetry: defaultMaxWaitRetry,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mode = m
+	return nil
+}
+
+// checkConstSelectors validates constant facility and severity settings on
+// startup, instead of failing on every event.
+func checkConstSelectors(sel selectors) error {
+	if v, _ := sel.facility.Select(common.MapStr{}); v != "" && sel.facility.IsConst() {
+		if _, err := parseFacility(v); err != nil {
+			return err
+		}
+	}
+	if v, _ := sel.severity.Select(common.MapStr{}); v != "" && sel.severity.IsConst() {
+		if _, err := parseSeverity(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogOut) Close() error {
+	return s.mode.Close()
+}
+
+func (s *syslogOut) PublishEvent(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data outputs.Data,
+) error {
+	return s.mode.PublishEvent(signaler, opts, data)
+}
+
+func (s *syslogOut) BulkPublish(
+	signaler op.Signaler,
+	opts outputs.Options,
+	data []outputs.Data,
+) error {
+	return s.mode.PublishEvents(signaler, opts, data)
+}
@@ -0,0 +1,271 @@
+// +build !integration
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/fmtstr"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/codecs/format"
+	_ "github.com/elastic/beats/libbeat/outputs/codecs/json"
+	"github.com/elastic/beats/libbeat/outputs/outil"
+	"github.com/elastic/beats/libbeat/outputs/transport"
+	beattest "github.com/elastic/beats/libbeat/testing"
+)
+
+var testTime = time.Date(2017, 10, 11, 22, 14, 15, 3000, time.UTC)
+
+func TestWriteRFC5424(t *testing.T) {
+	h := header{
+		facility:  4,
+		severity:  2,
+		timestamp: testTime,
+		hostname:  "my host",
+		appName:   "su",
+	}
+
+	var buf bytes.Buffer
+	writeRFC5424(&buf, &h, []byte("'su root' failed"))
+	assert.Equal(t, "<34>1 2017-10-11T22:14:15.000003Z my_host su - - - 'su root' failed", buf.String())
+}
+
+func TestWriteRFC3164(t *testing.T) {
+	h := header{
+		facility:  4,
+		severity:  2,
+		timestamp: testTime,
+		hostname:  "mymachine",
+		appName:   "su",
+		procID:    "123",
+	}
+
+	var buf bytes.Buffer
+	writeRFC3164(&buf, &h, []byte("'su root' failed"))
+	assert.Equal(t, "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed", buf.String())
+}
+
+func TestParseCodes(t *testing.T) {
+	code, err := parseFacility("local7")
+	assert.NoError(t, err)
+	assert.Equal(t, 23, code)
+
+	code, err = parseFacility("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, code)
+
+	_, err = parseFacility("24")
+	assert.Error(t, err)
+
+	code, err = parseSeverity("Warning")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, code)
+
+	_, err = parseSeverity("unknown")
+	assert.Error(t, err)
+}
+
+func testEvent(msg string) outputs.Data {
+	return outputs.Data{Event: common.MapStr{
+		"@timestamp": common.Time(testTime),
+		"message":    msg,
+		"beat":       common.MapStr{"hostname": "beathost"},
+		"level":      "err",
+	}}
+}
+
+func newTestClient(t *testing.T, network, addr string, config syslogConfig) *client {
+	tc, err := transport.NewClient(&transport.Config{Timeout: time.Second}, network, addr, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sel := selectors{
+		facility: outil.MakeSelector(outil.ConstSelectorExpr("local0")),
+		severity: outil.MakeSelector(outil.FmtSelectorExpr(fmtstr.MustCompileEvent("%{[level]}"), "")),
+		appName:  outil.MakeSelector(outil.ConstSelectorExpr("test")),
+	}
+	codec := format.New(fmtstr.MustCompileEvent("%{[message]}"))
+
+	config.Network = network
+	c := newClient(tc, &config, sel, "localhost", codec)
+	if err := c.Connect(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPublishUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := newTestClient(t, "udp", conn.LocalAddr().String(), syslogConfig{Format: formatRFC5424})
+	defer c.Close()
+
+	rest, err := c.PublishEvents([]outputs.Data{testEvent("hello"), testEvent("world")})
+	assert.NoError(t, err)
+	assert.Len(t, rest, 0)
+
+	buf := make([]byte, 1024)
+	for _, msg := range []string{"hello", "world"} {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "<131>1 2017-10-11T22:14:15.000003Z beathost test - - - "+msg, string(buf[:n]))
+	}
+}
+
+func TestPublishTCPFraming(t *testing.T) {
+	tests := []struct {
+		config   syslogConfig
+		expected string
+	}{
+		{
+			syslogConfig{Format: formatRFC5424},
+			"60 <131>1 2017-10-11T22:14:15.000003Z beathost test - - - hello",
+		},
+		{
+			syslogConfig{Format: formatRFC3164},
+			"<131>Oct 11 22:14:15 beathost test: hello\n",
+		},
+		{
+			syslogConfig{Format: formatRFC3164, Framing: framingOctetCounting},
+			"41 <131>Oct 11 22:14:15 beathost test: hello",
+		},
+	}
+
+	for _, test := range tests {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		received := make(chan string, 1)
+		go func() {
+			conn, err := l.Accept()
+			if err != nil {
+				received <- err.Error()
+				return
+			}
+			defer conn.Close()
+
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			buf := make([]byte, len(test.expected))
+			_, err = bufio.NewReader(conn).Read(buf)
+			if err != nil {
+				received <- err.Error()
+				return
+			}
+			received <- string(buf)
+		}()
+
+		c := newTestClient(t, "tcp", l.Addr().String(), test.config)
+		rest, err := c.PublishEvents([]outputs.Data{testEvent("hello")})
+		assert.NoError(t, err)
+		assert.Len(t, rest, 0)
+		assert.Equal(t, test.expected, <-received)
+
+		c.Close()
+		l.Close()
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{map[string]interface{}{"hosts": []string{"localhost"}}, true},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "network": "tcp", "framing": "non_transparent"}, true},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "network": "unix"}, false},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "format": "cef"}, false},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "ssl.enabled": true}, false},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "facility": "unknown"}, false},
+		{map[string]interface{}{"hosts": []string{"localhost"}, "severity": "%{[level]}"}, true},
+	}
+
+	for i, test := range tests {
+		cfg, err := common.NewConfigFrom(test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := new("test", cfg, 0)
+		if test.valid {
+			if assert.NoError(t, err, "test %v", i) {
+				out.Close()
+			}
+		} else {
+			assert.Error(t, err, "test %v", i)
+		}
+	}
+}
+
+func TestRFC3164TagTruncated(t *testing.T) {
+	h := header{timestamp: testTime, hostname: "h", appName: strings.Repeat("a", 40)}
+
+	var buf bytes.Buffer
+	writeRFC3164(&buf, &h, nil)
+	assert.Equal(t, "<0>Oct 11 22:14:15 h "+strings.Repeat("a", 32)+": ", buf.String())
+}
+
+func TestOutputTester(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	tests := []struct {
+		network string
+		listen  bool
+		failed  bool
+		warning string
+	}{
+		{network: "tcp", listen: true},
+		{network: "tcp", failed: true},
+		{network: "udp", warning: "delivery... WARN"},
+	}
+
+	for _, test := range tests {
+		if test.listen {
+			l, err = net.Listen("tcp", addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg, err := common.NewConfigFrom(map[string]interface{}{
+			"hosts":   []string{addr},
+			"network": test.network,
+		})
+		assert.NoError(t, err)
+
+		buf := bytes.NewBuffer(nil)
+		d := beattest.NewConsoleDriver(buf)
+		testOutput("testbeat", cfg, d)
+
+		assert.Equal(t, test.failed, d.Failed(), "%v: %v", test, buf.String())
+		if test.warning != "" {
+			assert.Contains(t, buf.String(), test.warning)
+		}
+
+		if test.listen {
+			l.Close()
+		}
+	}
+}
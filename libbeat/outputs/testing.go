@@ -0,0 +1,54 @@
+package outputs
+
+import (
+	"sort"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+// OutputTester tests the connections to all hosts configured for an output,
+// reporting the results to the driver d.
+type OutputTester func(beatName string, config *common.Config, d testing.Driver)
+
+var outputsTesters = make(map[string]OutputTester)
+
+// RegisterOutputTester registers the tester used by the `test output` command
+// for the output type name.
+func RegisterOutputTester(name string, tester OutputTester) {
+	outputsTesters[name] = tester
+}
+
+// FindOutputTester returns the tester registered for the output type name.
+func FindOutputTester(name string) OutputTester {
+	return outputsTesters[name]
+}
+
+// TestOutputs tests all enabled outputs in configs. Outputs not supporting
+// tests are reported with a warning.
+func TestOutputs(beatName string, configs map[string]*common.Config, d testing.Driver) {
+	var names []string
+	for name, config := range configs {
+		if config.Enabled() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		d.Warn("output", "no output configured")
+		return
+	}
+
+	for _, name := range names {
+		tester := FindOutputTester(name)
+		config := configs[name]
+		d.Run(name+" output", func(d testing.Driver) {
+			if tester == nil {
+				d.Warn("", "testing not supported")
+				return
+			}
+			tester(beatName, config, d)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/testing"
+)
+
+// TestMakeDialer creates a dialer like MakeDialer, reporting every step of
+// establishing a connection to the driver d: name resolution, connecting and
+// the TLS handshake.
+func TestMakeDialer(d testing.Driver, c *Config) (Dialer, error) {
+	var err error
+	dialer := TestNetDialer(d, c.Timeout)
+	dialer, err = ProxyDialer(c.Proxy, dialer)
+	if err != nil {
+		return nil, err
+	}
+	if c.Proxy != nil && c.Proxy.URL != "" {
+		d.Info("proxy", c.Proxy.URL)
+	}
+	if c.Stats != nil {
+		dialer = StatsDialer(dialer, c.Stats)
+	}
+
+	if c.TLS != nil {
+		return TestTLSDialer(d, dialer, c.TLS, c.Timeout)
+	}
+	return dialer, nil
+}
+
+// TestDial connects to host, reporting all steps of establishing the
+// connection to d. The connection returned is nil if connecting failed.
+func TestDial(d testing.Driver, c *Config, network, host string, defaultPort int) net.Conn {
+	var conn net.Conn
+	d.Run("connection", func(d testing.Driver) {
+		dialer, err := TestMakeDialer(d, c)
+		if err != nil {
+			d.Fatal("dialer", err)
+		}
+
+		// all failures are reported by the dialers
+		conn, _ = dialer.Dial(network, fullAddress(host, defaultPort))
+	})
+
+	if conn != nil && c.TLS == nil {
+		d.Warn("TLS", "secure connection disabled")
+	}
+	return conn
+}
+
+// TestNetDialer creates a dialer reporting the DNS lookup and the attempts to
+// connect to the resolved addresses to the driver d.
+func TestNetDialer(d testing.Driver, timeout time.Duration) Dialer {
+	return DialerFunc(func(network, address string) (net.Conn, error) {
+		switch network {
+		case "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6":
+		default:
+			d.Fatal("network type", fmt.Errorf("unsupported network type %v", network))
+		}
+
+		host, port, err := net.SplitHostPort(address)
+		d.Fatal("parse host", err)
+
+		addresses, err := net.LookupHost(host)
+		d.Fatal("dns lookup", err)
+		d.Info("addresses", strings.Join(addresses, ", "))
+
+		dialer := &net.Dialer{Timeout: timeout}
+		conn, err := dialWith(dialer, network, host, addresses, port)
+		d.Fatal("dial up", err)
+		return conn, nil
+	})
+}
+
+// TestTLSDialer creates a dialer reporting the TLS handshake and the
+// negotiated TLS version to the driver d.
+func TestTLSDialer(
+	d testing.Driver,
+	forward Dialer,
+	config *TLSConfig,
+	timeout time.Duration,
+) (Dialer, error) {
+	return DialerFunc(func(network, address string) (net.Conn, error) {
+		switch network {
+		case "tcp", "tcp4", "tcp6":
+		default:
+			d.Fatal("network type", fmt.Errorf("unsupported network type %v", network))
+		}
+
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			d.Fatal("parse host", err)
+		}
+
+		socket, err := forward.Dial(network, address)
+		if err != nil {
+			return nil, err
+		}
+
+		var conn net.Conn
+		d.Run("TLS", func(d testing.Driver) {
+			tlsConfig := config.BuildModuleConfig(host)
+			if tlsConfig.InsecureSkipVerify {
+				d.Warn("security", "server's certificate chain verification is disabled")
+			} else {
+				d.Info("security", "server's certificate chain verification is enabled")
+			}
+
+			tlsConn := tls.Client(socket, tlsConfig)
+			if timeout > 0 {
+				tlsConn.SetDeadline(time.Now().Add(timeout))
+			}
+
+			err = tlsConn.Handshake()
+			if err != nil {
+				socket.Close()
+			}
+			d.Fatal("handshake", err)
+
+			tlsConn.SetDeadline(time.Time{})
+			err = postVerifyTLSConnection(tlsConn, config)
+			if err != nil {
+				tlsConn.Close()
+			}
+			d.Fatal("verify connection", err)
+
+			d.Info("TLS version", TLSVersion(tlsConn.ConnectionState().Version).String())
+			conn = tlsConn
+		})
+
+		if conn == nil {
+			return nil, errors.New("TLS handshake failed")
+		}
+		return conn, nil
+	}), nil
+}
@@ -2,6 +2,7 @@ package publisher
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
@@ -12,9 +13,31 @@ import (
 
 type outputWorker struct {
 	messageWorker
-	out         outputs.BulkOutputer
+	name        string
 	config      outputConfig
 	maxBulkSize int
+
+	// mutex guards out, such that the output can be swapped by reloading.
+	// generation is incremented on every swap, so events failed by a replaced
+	// output can be detected and published again by the current output.
+	// inFlight tracks the batches being published by out, so reloading can
+	// wait for the worker to stop using a replaced output.
+	mutex      sync.Mutex
+	out        outputs.BulkOutputer
+	generation int
+	inFlight   *sync.WaitGroup
+}
+
+// resendSignal forwards the result of publishing events to the signaler of
+// the context. Events failed by an output which has been replaced in the
+// meantime, for example because the replaced output was closed, are queued
+// to the worker to be published again by its current output.
+type resendSignal struct {
+	worker     *outputWorker
+	generation int
+	ctx        Context
+	data       []outputs.Data
+	datum      outputs.Data
 }
 
 type outputConfig struct {
@@ -34,6 +57,7 @@ var (
 )
 
 func newOutputWorker(
+	name string,
 	cfg *common.Config,
 	out outputs.Outputer,
 	ws *workerSignal,
@@ -48,21 +72,48 @@ func newOutputWorker(
 	}
 
 	o := &outputWorker{
+		name:        name,
 		out:         outputs.CastBulkOutputer(out),
 		config:      config,
 		maxBulkSize: config.BulkMaxSize,
+		inFlight:    &sync.WaitGroup{},
 	}
 	o.messageWorker.init(ws, hwm, bulkHWM, o)
 	return o
 }
 
 func (o *outputWorker) onStop() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
 	err := o.out.Close()
 	if err != nil {
 		logp.Info("Failed to close outputer: %s", err)
 	}
 }
 
+// acquire returns the output events are published to and its generation.
+// inFlight.Done must be called once publishing to the output returned.
+func (o *outputWorker) acquire() (
+	out outputs.BulkOutputer,
+	generation int,
+	inFlight *sync.WaitGroup,
+) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.inFlight.Add(1)
+	return o.out, o.generation, o.inFlight
+}
+
+func (o *outputWorker) currentGeneration() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.generation
+}
+
+// onMessage publishes the message to the current output. The mutex is not
+// held while publishing, as publishing guaranteed events can block until the
+// output is closed.
 func (o *outputWorker) onMessage(m message) {
 	if m.datum.Event != nil {
 		o.onEvent(&m.context, m.datum)
@@ -73,8 +124,12 @@ func (o *outputWorker) onMessage(m message) {
 
 func (o *outputWorker) onEvent(ctx *Context, data outputs.Data) {
 	debug("output worker: publish single event")
+	out, generation, inFlight := o.acquire()
+	defer inFlight.Done()
+
 	opts := outputs.Options{Guaranteed: ctx.Guaranteed}
-	o.out.PublishEvent(ctx.Signal, opts, data)
+	sig := &resendSignal{worker: o, generation: generation, ctx: *ctx, datum: data}
+	out.PublishEvent(sig, opts, data)
 }
 
 func (o *outputWorker) onBulk(ctx *Context, data []outputs.Data) {
@@ -108,9 +163,55 @@ func (o *outputWorker) sendBulk(
 ) {
 	debug("output worker: publish %v events", len(data))
 
+	out, generation, inFlight := o.acquire()
+	defer inFlight.Done()
+
 	opts := outputs.Options{Guaranteed: ctx.Guaranteed}
-	err := o.out.BulkPublish(ctx.Signal, opts, data)
+	sig := &resendSignal{worker: o, generation: generation, ctx: *ctx, data: data}
+	err := out.BulkPublish(sig, opts, data)
 	if err != nil {
 		logp.Info("Error bulk publishing events: %s", err)
 	}
 }
+
+// swap replaces the output of the worker. Queued events are published by the
+// new output. The old output is returned and must be closed by the caller.
+// inFlight is done once the worker stopped publishing to the old output.
+// Events the old output fails to publish, including the events still pending
+// when it is closed, are published again by the new output.
+func (o *outputWorker) swap(out outputs.Outputer) (
+	old outputs.BulkOutputer,
+	inFlight *sync.WaitGroup,
+) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	old, inFlight = o.out, o.inFlight
+	o.out = outputs.CastBulkOutputer(out)
+	o.inFlight = &sync.WaitGroup{}
+	o.generation++
+	return old, inFlight
+}
+
+func (s *resendSignal) Completed() {
+	op.SigCompleted(s.ctx.Signal)
+}
+
+func (s *resendSignal) Canceled() {
+	if s.ctx.Signal != nil {
+		s.ctx.Signal.Canceled()
+	}
+}
+
+func (s *resendSignal) Failed() {
+	if s.worker.currentGeneration() == s.generation {
+		op.SigFailed(s.ctx.Signal, nil)
+		return
+	}
+
+	// The signal can be sent while the replaced output is closed or while it
+	// is publishing, so the events are queued to the worker instead of being
+	// published right away.
+	debug("output worker: publish events of replaced output again")
+	s.worker.retry(message{context: s.ctx, datum: s.datum, data: s.data})
+}
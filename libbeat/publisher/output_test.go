@@ -3,12 +3,19 @@
 package publisher
 
 import (
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/common/op"
 	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode"
+	"github.com/elastic/beats/libbeat/outputs/mode/lb"
+	"github.com/elastic/beats/libbeat/outputs/mode/modetest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Outputer that writes events to a channel.
@@ -38,6 +45,7 @@ func (t *testOutputer) PublishEvent(
 func TestOutputWorker(t *testing.T) {
 	outputer := &testOutputer{data: make(chan outputs.Data, 10)}
 	ow := newOutputWorker(
+		"test",
 		common.NewConfig(),
 		outputer,
 		newWorkerSignal(),
@@ -65,3 +73,76 @@ func TestOutputWorker(t *testing.T) {
 		}
 	}
 }
+
+// lbOutputer publishes batches with the load balancer.
+type lbOutputer struct {
+	*lb.LB
+}
+
+func (o lbOutputer) BulkPublish(sig op.Signaler, opts outputs.Options, data []outputs.Data) error {
+	return o.PublishEvents(sig, opts, data)
+}
+
+// Test the batches in flight in a load balanced output are published by the
+// new output once the worker swapped outputs.
+func TestOutputWorkerSwapInFlight(t *testing.T) {
+	var mutex sync.Mutex
+	var pending []func([]outputs.Data, error)
+	published := make(chan struct{}, 1)
+
+	// The client never acknowledges batches and fails them on close, like a
+	// connection being closed
+	client := modetest.NewMockClient(&modetest.MockClient{
+		CBAsyncPublish: func(cb func([]outputs.Data, error), data []outputs.Data) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			pending = append(pending, func(_ []outputs.Data, err error) { cb(data, err) })
+			published <- struct{}{}
+			return nil
+		},
+		CBClose: func() error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, cb := range pending {
+				cb(nil, errors.New("connection closed"))
+			}
+			pending = nil
+			return nil
+		},
+	})
+	old, err := lb.NewAsync(
+		[]mode.AsyncProtocolClient{client}, 0,
+		10*time.Millisecond, 100*time.Millisecond, 100*time.Millisecond)
+	require.NoError(t, err)
+
+	ow := newOutputWorker(
+		"test",
+		common.NewConfig(),
+		lbOutputer{old},
+		newWorkerSignal(),
+		1, 0)
+
+	sig := newTestSignaler()
+	m := testBulkMessage(sig, []outputs.Data{testEvent()})
+	m.context.Guaranteed = true
+	ow.onMessage(m)
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the batch to be in flight")
+	}
+
+	outputer := &testOutputer{data: make(chan outputs.Data, 10)}
+	replaced, inFlight := ow.swap(outputer)
+	assert.NoError(t, replaced.Close())
+	inFlight.Wait()
+
+	select {
+	case data := <-outputer.data:
+		assert.Equal(t, m.data[0], data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the batch to be published by the new output")
+	}
+	assert.True(t, sig.wait())
+}
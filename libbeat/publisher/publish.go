@@ -4,6 +4,7 @@ import (
 	"errors"
 	"flag"
 	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -16,12 +17,15 @@ import (
 	"github.com/elastic/beats/libbeat/processors"
 
 	// load supported output plugins
+	_ "github.com/elastic/beats/libbeat/outputs/amqp"
 	_ "github.com/elastic/beats/libbeat/outputs/console"
 	_ "github.com/elastic/beats/libbeat/outputs/elasticsearch"
 	_ "github.com/elastic/beats/libbeat/outputs/fileout"
+	_ "github.com/elastic/beats/libbeat/outputs/httpout"
 	_ "github.com/elastic/beats/libbeat/outputs/kafka"
 	_ "github.com/elastic/beats/libbeat/outputs/logstash"
 	_ "github.com/elastic/beats/libbeat/outputs/redis"
+	_ "github.com/elastic/beats/libbeat/outputs/syslog"
 
 	// load support output codec
 	_ "github.com/elastic/beats/libbeat/outputs/codecs/format"
@@ -56,6 +60,7 @@ type Publisher interface {
 }
 
 type BeatPublisher struct {
+	beatName       string
 	shipperName    string // Shipper name as set in the configuration file
 	hostname       string // Host name as returned by the operation system
 	name           string // The shipperName if configured, the hostname otherwise
@@ -67,6 +72,10 @@ type BeatPublisher struct {
 	TopologyOutput outputs.TopologyOutputer
 	geoLite        *libgeo.GeoIP
 	Processors     *processors.Processors
+	topologyExpire int
+
+	// reloadMutex serializes reloading the outputs.
+	reloadMutex sync.Mutex
 
 	globalEventMetadata common.EventMetadata // Fields and tags to add to each event.
 
@@ -210,7 +219,9 @@ func (publisher *BeatPublisher) init(
 	processors *processors.Processors,
 ) error {
 	var err error
+	publisher.beatName = beatName
 	publisher.Processors = processors
+	publisher.topologyExpire = shipper.TopologyExpire
 
 	publisher.disabled = *publishDisabled
 	if publisher.disabled {
@@ -244,6 +255,7 @@ func (publisher *BeatPublisher) init(
 
 			outputers = append(outputers,
 				newOutputWorker(
+					plugin.Name,
 					config,
 					output,
 					&publisher.wsOutput,
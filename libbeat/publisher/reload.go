@@ -0,0 +1,285 @@
+package publisher
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/cfgfile"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/paths"
+)
+
+// Metrics reporting the results of reloading the outputs.
+var (
+	outputReloads        = expvar.NewInt("libbeat.publisher.output.reloads")
+	outputReloadFailures = expvar.NewInt("libbeat.publisher.output.reload_failures")
+)
+
+// ReloadOutputs replaces the outputs of the publisher by outputs created from
+// configs. The old outputs are closed immediately, which stops them from
+// retrying and waits for the batch they are sending. ReloadOutputs returns
+// once the old outputs are not used anymore. All queued events and the events
+// the old outputs did not publish before being closed are published by the
+// new outputs.
+//
+// Only the settings of the configured outputs can be changed. Enabling or
+// disabling outputs requires a restart, as the publisher pipelines are
+// created per output.
+func (publisher *BeatPublisher) ReloadOutputs(configs map[string]*common.Config) error {
+	if err := publisher.reloadOutputs(configs); err != nil {
+		outputReloadFailures.Add(1)
+		return err
+	}
+
+	outputReloads.Add(1)
+	return nil
+}
+
+func (publisher *BeatPublisher) reloadOutputs(configs map[string]*common.Config) error {
+	publisher.reloadMutex.Lock()
+	defer publisher.reloadMutex.Unlock()
+
+	if publisher.disabled {
+		debug("publisher disabled, ignore reloading outputs")
+		return nil
+	}
+
+	if publisher.TopologyOutput != nil {
+		return errors.New("outputs storing the topology can not be reloaded")
+	}
+	for name, config := range configs {
+		if ok, _ := config.Bool("save_topology", 0); ok && config.Enabled() {
+			return fmt.Errorf("save_topology is not supported by reloading the %v output", name)
+		}
+	}
+
+	plugins, err := outputs.InitOutputs(publisher.beatName, configs, publisher.topologyExpire)
+	if err != nil {
+		return err
+	}
+
+	byName := map[string]outputs.Outputer{}
+	for _, plugin := range plugins {
+		byName[plugin.Name] = plugin.Output
+	}
+
+	if err := checkOutputNames(publisher.Output, byName); err != nil {
+		for _, plugin := range plugins {
+			plugin.Output.Close()
+		}
+		return err
+	}
+
+	for _, worker := range publisher.Output {
+		// The old output is closed before waiting for the worker, as
+		// guaranteed events are retried by the output until it is closed.
+		old, inFlight := worker.swap(byName[worker.name])
+		if err := old.Close(); err != nil {
+			logp.Err("Failed to close the replaced %v output: %v", worker.name, err)
+		}
+		inFlight.Wait()
+		logp.Info("Reloaded %v output", worker.name)
+	}
+	return nil
+}
+
+// checkOutputNames checks the reloaded outputs match the outputs the
+// publisher has been started with.
+func checkOutputNames(workers []*outputWorker, reloaded map[string]outputs.Outputer) error {
+	var current, names []string
+	for _, worker := range workers {
+		current = append(current, worker.name)
+	}
+	for name := range reloaded {
+		names = append(names, name)
+	}
+	sort.Strings(current)
+	sort.Strings(names)
+
+	if fmt.Sprint(current) != fmt.Sprint(names) {
+		return fmt.Errorf("enabled outputs changed from %v to %v, which requires a restart",
+			current, names)
+	}
+	return nil
+}
+
+// LoadOutputConfigs reads the settings of all outputs from the output section
+// of the config file.
+func LoadOutputConfigs(path string) (map[string]*common.Config, error) {
+	cfg, err := common.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := struct {
+		Output map[string]*common.Config `config:"output"`
+	}{}
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+	if len(config.Output) == 0 {
+		return nil, fmt.Errorf("no outputs configured in %v", path)
+	}
+	return config.Output, nil
+}
+
+// OutputReloader periodically checks the config file holding the output
+// settings for changes, reloading the outputs of the publisher on change.
+type OutputReloader struct {
+	publisher *BeatPublisher
+	path      string
+	period    time.Duration
+	onReload  func(map[string]*common.Config)
+
+	// content of the outputs config file last loaded, used to ignore
+	// modification time updates not changing the file
+	content []byte
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OutputReloadPath returns the path of the config file holding the output
+// settings, if reloading the outputs is enabled in cfg.
+func OutputReloadPath(cfg *common.Config) (string, bool, error) {
+	if cfg == nil {
+		return "", false, nil
+	}
+
+	config := cfgfile.DefaultReloadConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return "", false, err
+	}
+	if !config.Reload.Enabled {
+		return "", false, nil
+	}
+	if config.Path == "" {
+		return "", false, errors.New("path of the outputs config file is required")
+	}
+
+	path := config.Path
+	if !filepath.IsAbs(path) {
+		path = paths.Resolve(paths.Config, path)
+	}
+	return path, true, nil
+}
+
+// NewOutputReloader creates a reloader for the outputs config file configured
+// in cfg. onReload is called with the new output settings after every
+// successful reload.
+func NewOutputReloader(
+	publisher *BeatPublisher,
+	cfg *common.Config,
+	onReload func(map[string]*common.Config),
+) (*OutputReloader, error) {
+	config := cfgfile.DefaultReloadConfig
+	if err := cfg.Unpack(&config); err != nil {
+		return nil, err
+	}
+
+	path, _, err := OutputReloadPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OutputReloader{
+		publisher: publisher,
+		path:      path,
+		period:    config.Reload.Period,
+		onReload:  onReload,
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Start starts watching the outputs config file in the background. The
+// outputs are expected to have been created from the current content of the
+// file.
+func (r *OutputReloader) Start() {
+	logp.Info("Output reloader started watching %v every %v", r.path, r.period)
+
+	// The initial scan records the state of the file the outputs have been
+	// created from.
+	gw := cfgfile.NewGlobWatcher(r.path)
+	if _, _, err := gw.Scan(); err != nil {
+		logp.Err("Error scanning outputs config file: %v", err)
+	}
+	r.content, _ = ioutil.ReadFile(r.path)
+
+	r.wg.Add(1)
+	go r.run(gw)
+}
+
+// Stop stops watching the outputs config file.
+func (r *OutputReloader) Stop() {
+	close(r.done)
+	r.wg.Wait()
+	logp.Info("Output reloader stopped")
+}
+
+func (r *OutputReloader) run(gw *cfgfile.GlobWatcher) {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-time.After(r.period):
+		}
+
+		files, updated, err := gw.Scan()
+		if err != nil {
+			logp.Err("Error scanning outputs config file: %v", err)
+			continue
+		}
+		if !updated {
+			continue
+		}
+
+		if err := r.reload(files); err != nil {
+			logp.Err("Error reloading outputs: %v", err)
+		}
+	}
+}
+
+func (r *OutputReloader) reload(files []string) error {
+	if len(files) != 1 {
+		outputReloadFailures.Add(1)
+		return fmt.Errorf("expected a single outputs config file matching %v, found %v",
+			r.path, len(files))
+	}
+
+	content, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		outputReloadFailures.Add(1)
+		return err
+	}
+	if bytes.Equal(content, r.content) {
+		debug("outputs config file %v not changed", files[0])
+		return nil
+	}
+
+	configs, err := LoadOutputConfigs(files[0])
+	if err != nil {
+		outputReloadFailures.Add(1)
+		return err
+	}
+
+	if err := r.publisher.ReloadOutputs(configs); err != nil {
+		return err
+	}
+	r.content = content
+
+	if r.onReload != nil {
+		r.onReload(configs)
+	}
+	return nil
+}
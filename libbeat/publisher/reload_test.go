@@ -0,0 +1,356 @@
+// +build !integration
+
+package publisher
+
+import (
+	"errors"
+	"expvar"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/common/op"
+	"github.com/elastic/beats/libbeat/outputs"
+	"github.com/elastic/beats/libbeat/outputs/mode/single"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+// reloadOutputer is a bulk output identified by the id setting, recording
+// if it has been closed.
+type reloadOutputer struct {
+	id     int
+	mutex  sync.Mutex
+	closed bool
+}
+
+// singleOutputer publishes events using single.Mode, connecting with the
+// protocol client registered in singleClients for the id setting.
+type singleOutputer struct {
+	mode *single.Mode
+}
+
+// singleClient records the events it published and the ways it has been
+// misused. Its state is not synchronized, so the race detector reports
+// concurrent use. If blocking is set, the first publish attempt blocks until
+// release is closed and fails.
+type singleClient struct {
+	blocking bool
+	entered  chan struct{}
+	release  chan struct{}
+
+	connected bool
+	closed    bool
+	published int
+	misuse    []string
+}
+
+var singleClients map[int]*singleClient
+
+func init() {
+	outputs.RegisterOutputPlugin("reloadsingle", func(
+		_ string, cfg *common.Config, _ int,
+	) (outputs.Outputer, error) {
+		config := struct {
+			ID int `config:"id"`
+		}{}
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+		m, err := single.New(singleClients[config.ID], 0,
+			time.Millisecond, time.Second, 10*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return &singleOutputer{mode: m}, nil
+	})
+
+	outputs.RegisterOutputPlugin("reloadtest", func(
+		_ string, cfg *common.Config, _ int,
+	) (outputs.Outputer, error) {
+		config := struct {
+			ID int `config:"id"`
+		}{}
+		if err := cfg.Unpack(&config); err != nil {
+			return nil, err
+		}
+		return &reloadOutputer{id: config.ID}, nil
+	})
+}
+
+func (o *reloadOutputer) PublishEvent(sig op.Signaler, _ outputs.Options, _ outputs.Data) error {
+	op.SigCompleted(sig)
+	return nil
+}
+
+func (o *reloadOutputer) BulkPublish(sig op.Signaler, _ outputs.Options, _ []outputs.Data) error {
+	op.SigCompleted(sig)
+	return nil
+}
+
+func (o *reloadOutputer) Close() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.closed = true
+	return nil
+}
+
+func (o *reloadOutputer) isClosed() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.closed
+}
+
+func (o *singleOutputer) PublishEvent(sig op.Signaler, opts outputs.Options, data outputs.Data) error {
+	return o.mode.PublishEvent(sig, opts, data)
+}
+
+func (o *singleOutputer) BulkPublish(sig op.Signaler, opts outputs.Options, data []outputs.Data) error {
+	return o.mode.PublishEvents(sig, opts, data)
+}
+
+func (o *singleOutputer) Close() error {
+	return o.mode.Close()
+}
+
+func newSingleClient(blocking bool) *singleClient {
+	return &singleClient{
+		blocking: blocking,
+		entered:  make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+}
+
+func (c *singleClient) Connect(_ time.Duration) error {
+	if c.closed {
+		c.misuse = append(c.misuse, "connected after close")
+	}
+	c.connected = true
+	return nil
+}
+
+func (c *singleClient) Close() error {
+	c.connected = false
+	c.closed = true
+	return nil
+}
+
+func (c *singleClient) PublishEvents(data []outputs.Data) ([]outputs.Data, error) {
+	if !c.connected {
+		c.misuse = append(c.misuse, "published without connection")
+	}
+	if c.blocking {
+		c.blocking = false
+		close(c.entered)
+		<-c.release
+		if c.closed {
+			c.misuse = append(c.misuse, "closed while publishing")
+		}
+		return data, errors.New("connection lost")
+	}
+	c.published += len(data)
+	return nil, nil
+}
+
+func (c *singleClient) PublishEvent(data outputs.Data) error {
+	_, err := c.PublishEvents([]outputs.Data{data})
+	return err
+}
+
+func expvarValue(v *expvar.Int) int64 {
+	n, _ := strconv.ParseInt(v.String(), 10, 64)
+	return n
+}
+
+func outputConfigs(t *testing.T, settings map[string]interface{}) map[string]*common.Config {
+	configs := map[string]*common.Config{}
+	for name, s := range settings {
+		cfg, err := common.NewConfigFrom(s)
+		require.NoError(t, err)
+		configs[name] = cfg
+	}
+	return configs
+}
+
+func newReloadPublisher(t *testing.T) *BeatPublisher {
+	pub, err := New("testbeat", "1.0.0", outputConfigs(t, map[string]interface{}{
+		"reloadtest": map[string]interface{}{"id": 1},
+	}), ShipperConfig{}, nil)
+	require.NoError(t, err)
+	return pub
+}
+
+func currentOutput(pub *BeatPublisher) *reloadOutputer {
+	worker := pub.Output[0]
+	worker.mutex.Lock()
+	defer worker.mutex.Unlock()
+	return worker.out.(*reloadOutputer)
+}
+
+func TestReloadOutputs(t *testing.T) {
+	pub := newReloadPublisher(t)
+	defer pub.Stop()
+
+	old := currentOutput(pub)
+	reloads := expvarValue(outputReloads)
+
+	err := pub.ReloadOutputs(outputConfigs(t, map[string]interface{}{
+		"reloadtest": map[string]interface{}{"id": 2},
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, currentOutput(pub).id)
+	assert.True(t, old.isClosed())
+	assert.Equal(t, reloads+1, expvarValue(outputReloads))
+}
+
+func TestReloadOutputsChangedOutputs(t *testing.T) {
+	pub := newReloadPublisher(t)
+	defer pub.Stop()
+
+	failures := expvarValue(outputReloadFailures)
+
+	tests := []map[string]interface{}{
+		{"reloadtest": map[string]interface{}{"id": 2, "enabled": false}},
+		{
+			"reloadtest": map[string]interface{}{"id": 2},
+			"console":    map[string]interface{}{},
+		},
+	}
+	for _, settings := range tests {
+		err := pub.ReloadOutputs(outputConfigs(t, settings))
+		assert.Error(t, err, "%v", settings)
+		assert.Equal(t, 1, currentOutput(pub).id)
+	}
+	assert.Equal(t, failures+int64(len(tests)), expvarValue(outputReloadFailures))
+}
+
+func TestReloadOutputsWhilePublishing(t *testing.T) {
+	old, replacement := newSingleClient(true), newSingleClient(false)
+	singleClients = map[int]*singleClient{1: old, 2: replacement}
+
+	pub, err := New("testbeat", "1.0.0", outputConfigs(t, map[string]interface{}{
+		"reloadsingle": map[string]interface{}{"id": 1},
+	}), ShipperConfig{}, &processors.Processors{})
+	require.NoError(t, err)
+	defer pub.Stop()
+
+	client := pub.Connect()
+	defer client.Close()
+
+	events := []common.MapStr{testEvent().Event, testEvent().Event}
+	published := make(chan bool, 1)
+	go func() {
+		published <- client.PublishEvents(events, Guaranteed, Sync)
+	}()
+
+	select {
+	case <-old.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("events not published")
+	}
+
+	reloaded := make(chan error, 1)
+	go func() {
+		reloaded <- pub.ReloadOutputs(outputConfigs(t, map[string]interface{}{
+			"reloadsingle": map[string]interface{}{"id": 2},
+		}))
+	}()
+
+	// Reloading waits for the batch being sent by the old output.
+	select {
+	case <-reloaded:
+		t.Fatal("outputs reloaded while publishing")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(old.release)
+
+	select {
+	case err := <-reloaded:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("outputs not reloaded")
+	}
+	select {
+	case ok := <-published:
+		assert.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed events not published again")
+	}
+
+	assert.True(t, old.closed)
+	assert.Empty(t, old.misuse)
+	assert.Empty(t, replacement.misuse)
+	assert.Equal(t, len(events), replacement.published)
+}
+
+func TestOutputReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "output_reload")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "outputs.yml")
+	write := func(content string, modTime time.Time) {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write("output.reloadtest.id: 1\n", time.Now().Add(-time.Minute))
+
+	pub := newReloadPublisher(t)
+	defer pub.Stop()
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"path":           path,
+		"reload.enabled": true,
+		"reload.period":  "10ms",
+	})
+	require.NoError(t, err)
+
+	reloaded := make(chan map[string]*common.Config, 1)
+	reloader, err := NewOutputReloader(pub, cfg, func(configs map[string]*common.Config) {
+		reloaded <- configs
+	})
+	require.NoError(t, err)
+
+	reloader.Start()
+	defer reloader.Stop()
+
+	write("output.reloadtest.id: 3\n", time.Now().Add(time.Minute))
+	select {
+	case configs := <-reloaded:
+		assert.Contains(t, configs, "reloadtest")
+	case <-time.After(5 * time.Second):
+		t.Fatal("outputs not reloaded")
+	}
+	assert.Equal(t, 3, currentOutput(pub).id)
+}
+
+func TestOutputReloadPath(t *testing.T) {
+	path, enabled, err := OutputReloadPath(nil)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+
+	cfg, err := common.NewConfigFrom(map[string]interface{}{
+		"reload.enabled": true,
+	})
+	require.NoError(t, err)
+	_, _, err = OutputReloadPath(cfg)
+	assert.Error(t, err)
+
+	cfg, err = common.NewConfigFrom(map[string]interface{}{
+		"path":           "/etc/beat/outputs.yml",
+		"reload.enabled": true,
+	})
+	require.NoError(t, err)
+	path, enabled, err = OutputReloadPath(cfg)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, "/etc/beat/outputs.yml", path)
+}
@@ -22,6 +22,14 @@ type messageWorker struct {
 	bulkQueue chan message
 	ws        *workerSignal
 	handler   messageHandler
+
+	// retries holds the messages to be handled again, in the order they were
+	// retried. They are handled before the next queued message. retryAdded
+	// wakes up the worker if it is idle.
+	retryMutex sync.Mutex
+	retries    []message
+	retryAdded chan struct{}
+	stopped    bool
 }
 
 type workerSignal struct {
@@ -50,6 +58,7 @@ func newMessageWorker(ws *workerSignal, hwm, bulkHWM int, h messageHandler) *mes
 func (p *messageWorker) init(ws *workerSignal, hwm, bulkHWM int, h messageHandler) {
 	p.queue = make(chan message, hwm)
 	p.bulkQueue = make(chan message, bulkHWM)
+	p.retryAdded = make(chan struct{}, 1)
 	p.ws = ws
 	p.handler = h
 
@@ -60,9 +69,12 @@ func (p *messageWorker) init(ws *workerSignal, hwm, bulkHWM int, h messageHandle
 func (p *messageWorker) run() {
 	defer p.shutdown()
 	for {
+		p.handleRetries()
+
 		select {
 		case <-p.ws.done:
 			return
+		case <-p.retryAdded:
 		case m := <-p.queue:
 			p.onEvent(m)
 		case m := <-p.bulkQueue:
@@ -73,11 +85,56 @@ func (p *messageWorker) run() {
 
 func (p *messageWorker) shutdown() {
 	p.handler.onStop()
+
+	p.retryMutex.Lock()
+	p.stopped = true
+	retries := p.retries
+	p.retries = nil
+	p.retryMutex.Unlock()
+	for _, m := range retries {
+		op.SigFailed(m.context.Signal, nil)
+	}
+
 	stopQueue(p.queue)
 	stopQueue(p.bulkQueue)
 	p.ws.wg.Done()
 }
 
+// retry queues m to be handled again by the worker. retry does not block, so
+// it can be called while the worker is handling a message. Messages retried
+// after the worker stopped are signaled as failed.
+func (p *messageWorker) retry(m message) {
+	p.retryMutex.Lock()
+	if p.stopped {
+		p.retryMutex.Unlock()
+		op.SigFailed(m.context.Signal, nil)
+		return
+	}
+	p.retries = append(p.retries, m)
+	p.retryMutex.Unlock()
+
+	select {
+	case p.retryAdded <- struct{}{}:
+	default:
+	}
+}
+
+func (p *messageWorker) handleRetries() {
+	for {
+		p.retryMutex.Lock()
+		retries := p.retries
+		p.retries = nil
+		p.retryMutex.Unlock()
+
+		if len(retries) == 0 {
+			return
+		}
+		for _, m := range retries {
+			p.handler.onMessage(m)
+		}
+	}
+}
+
 func (p *messageWorker) onEvent(m message) {
 	messagesInWorkerQueues.Add(-1)
 	p.handler.onMessage(m)
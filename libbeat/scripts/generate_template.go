@@ -0,0 +1,91 @@
+// +build ignore
+
+// This script collects the fields.yml of a beat and generates the static
+// index templates for Elasticsearch 2.x, 5.x and 6.x from it.
+//
+// Example usage:
+//
+//   go run generate_template.go -es_beats ../.. -beat . -name filebeat
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/beats/libbeat/template"
+	"github.com/elastic/beats/libbeat/version"
+)
+
+var targets = []struct {
+	suffix    string
+	esVersion string
+}{
+	{".template-es2x.json", "2.0.0"},
+	{".template.json", "5.0.0"},
+	{".template-es6x.json", "6.0.0"},
+}
+
+func main() {
+	esBeatsPath := flag.String("es_beats", "..", "Path to the beats repository")
+	beatPath := flag.String("beat", ".", "Path to the beat folder")
+	beatName := flag.String("name", "", "Name of the beat")
+	flag.Parse()
+
+	if *beatName == "" {
+		fmt.Fprintln(os.Stderr, "-name is required")
+		os.Exit(1)
+	}
+
+	if err := generate(*esBeatsPath, *beatPath, *beatName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating templates: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate(esBeatsPath, beatPath, beatName string) error {
+	content, err := template.CollectFields(esBeatsPath, beatPath)
+	if err != nil {
+		return err
+	}
+
+	fieldsPath := filepath.Join(beatPath, "fields.yml")
+	if err := ioutil.WriteFile(fieldsPath, content, 0644); err != nil {
+		return err
+	}
+
+	fields, err := template.LoadFields(content)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		t, err := template.New(beatName, version.GetDefaultVersion(), target.esVersion)
+		if err != nil {
+			return err
+		}
+
+		tmpl, err := t.Generate(fields)
+		if err != nil {
+			return err
+		}
+
+		buf := bytes.NewBuffer(nil)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(tmpl); err != nil {
+			return err
+		}
+
+		path := filepath.Join(beatPath, beatName+target.suffix)
+		if err := ioutil.WriteFile(path, bytes.TrimSuffix(buf.Bytes(), []byte("\n")), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,6 +15,11 @@ import (
 	"github.com/elastic/beats/libbeat/outputs/transport"
 )
 
+const (
+	exportAPI       = "/api/kibana/dashboards/export"
+	savedObjectsAPI = "/api/saved_objects"
+)
+
 type Connection struct {
 	URL      string
 	Username string
@@ -201,8 +206,65 @@ func (client *Client) ImportJSON(url string, params url.Values, body io.Reader)
 	return nil
 }
 
+// ExportDashboard exports the dashboard with the given ID, together with all
+// the visualizations, searches and index patterns it refers to. The result
+// is in the format expected by the dashboards import API.
+func (client *Client) ExportDashboard(id string) (common.MapStr, error) {
+	params := url.Values{}
+	params.Add("dashboard", id)
+
+	_, response, err := client.Connection.Request("GET", exportAPI, params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error exporting dashboard %s: %v. Response: %s",
+			id, err, truncateString(response))
+	}
+
+	var result struct {
+		Version string          `json:"version"`
+		Objects []common.MapStr `json:"objects"`
+	}
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, fmt.Errorf("error parsing the exported dashboard %s: %v", id, err)
+	}
+
+	// Missing objects are reported in the list of objects
+	for _, obj := range result.Objects {
+		if msg, err := obj.GetValue("error.message"); err == nil {
+			return nil, fmt.Errorf("error exporting %v %v: %v", obj["type"], obj["id"], msg)
+		}
+	}
+
+	return common.MapStr{
+		"version": result.Version,
+		"objects": result.Objects,
+	}, nil
+}
+
+// DeleteObject deletes the saved object of the given type and ID.
+func (client *Client) DeleteObject(objType, id string) error {
+	for _, segment := range []string{objType, id} {
+		if segment == "" || strings.Contains(segment, "/") {
+			return fmt.Errorf("invalid saved object %s/%s", objType, id)
+		}
+	}
+
+	path := savedObjectsAPI + "/" + escapePath(objType) + "/" + escapePath(id)
+	_, response, err := client.Connection.Request("DELETE", path, nil, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting %s %s: %v. Response: %s",
+			objType, id, err, truncateString(response))
+	}
+	return nil
+}
+
 func (client *Client) Close() error { return nil }
 
+// escapePath escapes the characters of s which are not allowed in a URL path
+func escapePath(s string) string {
+	u := url.URL{Path: s}
+	return u.EscapedPath()
+}
+
 // truncateString returns a truncated string if the length is greater than 250
 // runes. If the string is truncated "... (truncated)" is appended. Newlines are
 // replaced by spaces in the returned string.
@@ -0,0 +1,122 @@
+package template
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// CollectFields concatenates the fields.yml files of libbeat, its processors
+// and the beat at beatPath into a single fields.yml. Beats having modules
+// define their common fields in `_meta/fields.common.yml` and the fields of
+// every module and metricset or fileset in `module/*/_meta/fields.yml` and
+// `module/*/*/_meta/fields.yml`. All other beats define their fields in
+// `_meta/fields.yml`.
+func CollectFields(esBeatsPath, beatPath string) ([]byte, error) {
+	libbeatPath := filepath.Join(esBeatsPath, "libbeat")
+
+	files := []string{filepath.Join(libbeatPath, "_meta", "fields.common.yml")}
+	processors, err := filepath.Glob(filepath.Join(libbeatPath, "processors", "*", "_meta", "fields.yml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(processors)
+	files = append(files, processors...)
+
+	buf := bytes.NewBuffer(nil)
+	for _, file := range files {
+		if err := appendFile(buf, file, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if isSamePath(beatPath, libbeatPath) {
+		return buf.Bytes(), nil
+	}
+
+	modulesPath := filepath.Join(beatPath, "module")
+	commonFields := filepath.Join(beatPath, "_meta", "fields.common.yml")
+	if !exists(modulesPath) || !exists(commonFields) {
+		err := appendFile(buf, filepath.Join(beatPath, "_meta", "fields.yml"), "")
+		return buf.Bytes(), err
+	}
+
+	if err := appendFile(buf, commonFields, ""); err != nil {
+		return nil, err
+	}
+	if err := collectModuleFields(buf, modulesPath); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// collectModuleFields appends the fields of all modules. The fields of each
+// metricset or fileset are nested into the group defined by its module.
+func collectModuleFields(buf *bytes.Buffer, modulesPath string) error {
+	modules, err := ioutil.ReadDir(modulesPath)
+	if err != nil {
+		return err
+	}
+
+	for _, module := range modules {
+		moduleFields := filepath.Join(modulesPath, module.Name(), "_meta", "fields.yml")
+		if !module.IsDir() || !exists(moduleFields) {
+			continue
+		}
+
+		if err := appendFile(buf, moduleFields, ""); err != nil {
+			return err
+		}
+
+		sets, err := ioutil.ReadDir(filepath.Join(modulesPath, module.Name()))
+		if err != nil {
+			return err
+		}
+		for _, set := range sets {
+			setFields := filepath.Join(modulesPath, module.Name(), set.Name(), "_meta", "fields.yml")
+			if !set.IsDir() || !exists(setFields) {
+				continue
+			}
+
+			if err := appendFile(buf, setFields, "        "); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendFile appends the content of file to buf, indenting all non empty
+// lines.
+func appendFile(buf *bytes.Buffer, file, indent string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) > 0 {
+			buf.WriteString(indent)
+		}
+		buf.Write(line)
+	}
+
+	// make sure the next file starts on a new line
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isSamePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
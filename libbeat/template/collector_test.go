@@ -0,0 +1,69 @@
+// +build !integration
+
+package template
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectFieldsModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "collector")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"libbeat/_meta/fields.common.yml": "defaults:\n  type: keyword\nfields:\n- key: beat\n  fields:\n    - name: beat.name\n",
+		"libbeat/processors/p/_meta/fields.yml": "- key: p\n  fields:\n    - name: p.id\n",
+		"mybeat/_meta/fields.common.yml": "- key: log\n  fields:\n    - name: message\n      type: text\n",
+		"mybeat/module/mod/_meta/fields.yml": "- key: mod\n  fields:\n    - name: mod\n      type: group\n      fields:\n",
+		"mybeat/module/mod/set/_meta/fields.yml": "- name: set\n  type: group\n  fields:\n    - name: count\n      type: long\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := CollectFields(dir, filepath.Join(dir, "mybeat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields, err := LoadFields(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assert.Len(t, fields, 4) {
+		assert.Equal(t, "beat", fields[0].Key)
+		assert.Equal(t, "p", fields[1].Key)
+		assert.Equal(t, "log", fields[2].Key)
+		assert.Equal(t, "mod", fields[3].Key)
+
+		set := fields[3].Fields[0].Fields[0]
+		assert.Equal(t, "set", set.Name)
+		assert.Equal(t, "count", set.Fields[0].Name)
+	}
+
+	// libbeat itself has no beat specific fields
+	content, err = CollectFields(dir, filepath.Join(dir, "libbeat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err = LoadFields(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, fields, 2)
+}
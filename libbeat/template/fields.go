@@ -0,0 +1,135 @@
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultType is the field type used if neither the field nor the defaults
+// section of the fields file specify a type.
+const defaultType = "keyword"
+
+// Field is a single field definition from a fields.yml file. Fields of type
+// group and nested contain their child fields in Fields. Top level sections
+// of a fields.yml file are parsed into a Field having Key set.
+type Field struct {
+	Key           string `yaml:"key"`
+	Title         string `yaml:"title"`
+	Name          string `yaml:"name"`
+	Type          string `yaml:"type"`
+	Description   string `yaml:"description"`
+	Format        string `yaml:"format"`
+	ScalingFactor int    `yaml:"scaling_factor"`
+	DictType      string `yaml:"dict-type"`
+	Fields        Fields `yaml:"fields"`
+}
+
+// Fields is a list of field definitions.
+type Fields []Field
+
+type fieldsFile struct {
+	Defaults Field  `yaml:"defaults"`
+	Fields   Fields `yaml:"fields"`
+}
+
+// ReadFields reads the field definitions from one or more fields.yml files.
+// The sections of all files are concatenated in order.
+func ReadFields(paths ...string) (Fields, error) {
+	var fields Fields
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := LoadFields(content)
+		if err != nil {
+			return nil, fmt.Errorf("error reading fields file %v: %v", path, err)
+		}
+		fields = append(fields, f...)
+	}
+	return fields, nil
+}
+
+// LoadFields parses the contents of a fields.yml file. The file either
+// contains an optional `defaults` section and the list of sections in
+// `fields`, like the global fields.yml, or only the list of sections, like the
+// `_meta/fields.yml` files of modules. The type given in the defaults is
+// applied to all fields without type.
+func LoadFields(content []byte) (Fields, error) {
+	var file fieldsFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		// not a map, try to read the list of sections
+		var sections Fields
+		if err := yaml.Unmarshal(content, &sections); err != nil {
+			return nil, err
+		}
+		file.Fields = sections
+	}
+
+	defaultFieldType := file.Defaults.Type
+	if defaultFieldType == "" {
+		defaultFieldType = defaultType
+	}
+
+	for i := range file.Fields {
+		file.Fields[i].Fields.setDefaultType(defaultFieldType)
+	}
+	return file.Fields, nil
+}
+
+func (f Fields) setDefaultType(typ string) {
+	for i := range f {
+		if f[i].Type == "" {
+			f[i].Type = typ
+		}
+		f[i].Fields.setDefaultType(typ)
+	}
+}
+
+// dedot replaces fields with dots in their name by groups. For example the
+// fields `beat.name` and `beat.hostname` are replaced by the group `beat`
+// containing the fields `name` and `hostname`. Groups with the same name are
+// merged.
+func (f Fields) dedot() Fields {
+	var fields Fields
+	groups := map[string]int{}
+
+	add := func(field Field) {
+		if field.Type != "group" {
+			fields = append(fields, field)
+			return
+		}
+
+		if idx, exists := groups[field.Name]; exists {
+			fields[idx].Fields = append(fields[idx].Fields, field.Fields...)
+			return
+		}
+		groups[field.Name] = len(fields)
+		fields = append(fields, field)
+	}
+
+	for _, field := range f {
+		if idx := strings.Index(field.Name, "."); idx >= 0 {
+			child := field
+			child.Name = field.Name[idx+1:]
+			add(Field{
+				Name:   field.Name[:idx],
+				Type:   "group",
+				Fields: Fields{child},
+			})
+			continue
+		}
+		add(field)
+	}
+
+	for i := range fields {
+		if fields[i].Type == "group" || fields[i].Type == "nested" {
+			fields[i].Fields = fields[i].Fields.dedot()
+		}
+	}
+	return fields
+}
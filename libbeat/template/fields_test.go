@@ -0,0 +1,87 @@
+// +build !integration
+
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFieldsGlobalFile(t *testing.T) {
+	content := []byte(`
+defaults:
+  type: long
+fields:
+- key: beat
+  title: Beat
+  fields:
+    - name: beat.name
+      type: keyword
+    - name: count
+- key: system
+  fields:
+    - name: system
+      type: group
+      fields:
+        - name: load
+`)
+
+	fields, err := LoadFields(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "beat", fields[0].Key)
+	assert.Equal(t, "keyword", fields[0].Fields[0].Type)
+	assert.Equal(t, "long", fields[0].Fields[1].Type)
+	assert.Equal(t, "long", fields[1].Fields[0].Fields[0].Type)
+}
+
+func TestLoadFieldsSectionList(t *testing.T) {
+	content := []byte(`
+- key: custom
+  fields:
+    - name: custom.id
+`)
+
+	fields, err := LoadFields(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, fields, 1)
+	assert.Equal(t, defaultType, fields[0].Fields[0].Type)
+}
+
+func TestLoadFieldsInvalid(t *testing.T) {
+	_, err := LoadFields([]byte("fields: [:"))
+	assert.Error(t, err)
+}
+
+func TestDedot(t *testing.T) {
+	fields := Fields{
+		{Name: "beat.name", Type: "keyword"},
+		{Name: "beat.version", Type: "keyword"},
+		{Name: "beat", Type: "group", Fields: Fields{
+			{Name: "hostname", Type: "keyword"},
+			{Name: "stats.count", Type: "long"},
+		}},
+		{Name: "message", Type: "text"},
+	}
+
+	expected := Fields{
+		{Name: "beat", Type: "group", Fields: Fields{
+			{Name: "name", Type: "keyword"},
+			{Name: "version", Type: "keyword"},
+			{Name: "hostname", Type: "keyword"},
+			{Name: "stats", Type: "group", Fields: Fields{
+				{Name: "count", Type: "long"},
+			}},
+		}},
+		{Name: "message", Type: "text"},
+	}
+
+	assert.Equal(t, expected, fields.dedot())
+}
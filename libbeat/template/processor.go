@@ -0,0 +1,177 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// processor converts field definitions into Elasticsearch mappings.
+type processor struct {
+	esVersion        common.Version
+	dynamicTemplates []common.MapStr
+}
+
+func (p *processor) process(fields Fields, path string, output common.MapStr) error {
+	for _, field := range fields {
+		if field.Name == "" {
+			continue
+		}
+
+		var mapping common.MapStr
+		switch field.Type {
+		case "text":
+			mapping = p.text()
+		case "keyword", "ip":
+			mapping = p.keyword(field)
+		case "geo_point", "date", "boolean",
+			"long", "integer", "short", "byte",
+			"double", "float", "half_float", "scaled_float":
+			mapping = p.other(field)
+		case "dict", "list":
+			p.dict(field, fullName(path, field.Name))
+		case "group", "nested":
+			properties := common.MapStr{}
+			if err := p.process(field.Fields, fullName(path, field.Name), properties); err != nil {
+				return err
+			}
+
+			if field.Type == "nested" {
+				mapping = common.MapStr{
+					"type":       "nested",
+					"properties": properties,
+				}
+			} else if len(properties) > 0 {
+				// only add groups having content
+				mapping = common.MapStr{
+					"properties": properties,
+				}
+			}
+		default:
+			return fmt.Errorf("unknown type %v of field %v", field.Type, fullName(path, field.Name))
+		}
+
+		if mapping != nil {
+			output[field.Name] = mapping
+		}
+	}
+	return nil
+}
+
+func (p *processor) stringsAsKeyword() common.MapStr {
+	mapping := common.MapStr{
+		"type":         "keyword",
+		"ignore_above": defaultIgnoreAbove,
+	}
+	if p.esVersion.IsMajor(2) {
+		mapping = common.MapStr{
+			"type":         "string",
+			"index":        "not_analyzed",
+			"ignore_above": defaultIgnoreAbove,
+		}
+	}
+
+	return common.MapStr{
+		"strings_as_keyword": common.MapStr{
+			"mapping":            mapping,
+			"match_mapping_type": "string",
+		},
+	}
+}
+
+func (p *processor) text() common.MapStr {
+	if p.esVersion.IsMajor(2) {
+		return common.MapStr{
+			"type":  "string",
+			"index": "analyzed",
+			"norms": common.MapStr{
+				"enabled": false,
+			},
+		}
+	}
+
+	return common.MapStr{
+		"type":  "text",
+		"norms": false,
+	}
+}
+
+func (p *processor) keyword(field Field) common.MapStr {
+	if p.esVersion.IsMajor(2) {
+		return common.MapStr{
+			"type":         "string",
+			"index":        "not_analyzed",
+			"ignore_above": defaultIgnoreAbove,
+		}
+	}
+
+	if field.Type == "ip" {
+		return common.MapStr{"type": "ip"}
+	}
+
+	return common.MapStr{
+		"type":         "keyword",
+		"ignore_above": defaultIgnoreAbove,
+	}
+}
+
+func (p *processor) other(field Field) common.MapStr {
+	typ := field.Type
+	switch typ {
+	case "integer":
+		// all integer fields are stored as long
+		typ = "long"
+	case "half_float", "scaled_float":
+		// ES 2.x supports neither half nor scaled floats
+		if p.esVersion.IsMajor(2) {
+			typ = "float"
+		}
+	}
+
+	mapping := common.MapStr{"type": typ}
+	if typ == "scaled_float" {
+		scalingFactor := field.ScalingFactor
+		if scalingFactor == 0 {
+			scalingFactor = 1000
+		}
+		mapping["scaling_factor"] = scalingFactor
+	}
+	return mapping
+}
+
+// dict adds a dynamic template for all members of a dict of type text or long.
+func (p *processor) dict(field Field, name string) {
+	var mapping common.MapStr
+	matchType := "string"
+
+	switch field.DictType {
+	case "text":
+		mapping = common.MapStr{"type": "text"}
+		if p.esVersion.IsMajor(2) {
+			mapping = common.MapStr{
+				"type":  "string",
+				"index": "analyzed",
+			}
+		}
+	case "long":
+		mapping = common.MapStr{"type": "long"}
+		matchType = "long"
+	default:
+		return
+	}
+
+	p.dynamicTemplates = append(p.dynamicTemplates, common.MapStr{
+		name: common.MapStr{
+			"mapping":            mapping,
+			"match_mapping_type": matchType,
+			"path_match":         name + ".*",
+		},
+	})
+}
+
+func fullName(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
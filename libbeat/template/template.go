@@ -0,0 +1,105 @@
+package template
+
+import (
+	"fmt"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// defaultIgnoreAbove is the maximum length of keyword fields to be indexed.
+const defaultIgnoreAbove = 1024
+
+// Template generates the Elasticsearch index template for a beat from its
+// field definitions. The generated template depends on the version of the
+// Elasticsearch cluster it is loaded into.
+type Template struct {
+	name        string
+	beatVersion common.Version
+	esVersion   common.Version
+}
+
+// New creates a new template generator for the template name, which is also
+// used as index prefix. beatVersion is stored in the template metadata and
+// esVersion selects the mapping syntax.
+func New(name, beatVersion, esVersion string) (*Template, error) {
+	bv, err := common.NewVersion(beatVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid beat version: %v", err)
+	}
+
+	ev, err := common.NewVersion(esVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Elasticsearch version: %v", err)
+	}
+
+	if ev.Major < 2 {
+		return nil, fmt.Errorf("Elasticsearch version %v is not supported", esVersion)
+	}
+
+	return &Template{
+		name:        name,
+		beatVersion: *bv,
+		esVersion:   *ev,
+	}, nil
+}
+
+// Generate creates the index template from the sections of a fields.yml file.
+func (t *Template) Generate(sections Fields) (common.MapStr, error) {
+	p := processor{esVersion: t.esVersion}
+
+	properties := common.MapStr{}
+	dynamicTemplates := []common.MapStr{p.stringsAsKeyword()}
+	for _, section := range sections {
+		if err := p.process(section.Fields.dedot(), "", properties); err != nil {
+			return nil, err
+		}
+	}
+	dynamicTemplates = append(dynamicTemplates, p.dynamicTemplates...)
+
+	mapping := common.MapStr{
+		"_meta": common.MapStr{
+			"version": t.beatVersion.String(),
+		},
+		"date_detection":    false,
+		"dynamic_templates": dynamicTemplates,
+		"properties":        properties,
+	}
+
+	settings := common.MapStr{
+		"index.refresh_interval": "5s",
+	}
+
+	if t.esVersion.IsMajor(2) {
+		mapping["_all"] = common.MapStr{
+			"norms": common.MapStr{
+				"enabled": false,
+			},
+		}
+	} else {
+		// Most fields are not used in a typical scenario, so increasing the
+		// limit on the number of fields shouldn't be that bad.
+		settings["index.mapping.total_fields.limit"] = 10000
+	}
+
+	template := common.MapStr{
+		"mappings": common.MapStr{
+			"_default_": mapping,
+		},
+		"order":    0,
+		"settings": settings,
+	}
+
+	pattern := t.name + "-*"
+	if t.esVersion.Major >= 6 {
+		template["index_patterns"] = []string{pattern}
+	} else {
+		template["template"] = pattern
+	}
+
+	return template, nil
+}
+
+// GetName returns the name of the template.
+func (t *Template) GetName() string {
+	return t.name
+}
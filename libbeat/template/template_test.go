@@ -0,0 +1,122 @@
+// +build !integration
+
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+var testFields = Fields{
+	{Key: "test", Fields: Fields{
+		{Name: "message", Type: "text"},
+		{Name: "client.ip", Type: "ip"},
+		{Name: "count", Type: "integer"},
+		{Name: "ratio", Type: "scaled_float"},
+		{Name: "labels", Type: "dict", DictType: "text"},
+		{Name: "empty", Type: "group"},
+		{Name: "spans", Type: "nested"},
+	}},
+}
+
+func TestTemplateES5(t *testing.T) {
+	tmpl, err := New("testbeat", "5.6.0", "5.4.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := tmpl.Generate(testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "testbeat-*", template["template"])
+	assert.Equal(t, 10000, template["settings"].(common.MapStr)["index.mapping.total_fields.limit"])
+
+	mapping := template["mappings"].(common.MapStr)["_default_"].(common.MapStr)
+	assert.Equal(t, common.MapStr{"version": "5.6.0"}, mapping["_meta"])
+	assert.Nil(t, mapping["_all"])
+
+	expected := common.MapStr{
+		"message": common.MapStr{"type": "text", "norms": false},
+		"client": common.MapStr{
+			"properties": common.MapStr{
+				"ip": common.MapStr{"type": "ip"},
+			},
+		},
+		"count": common.MapStr{"type": "long"},
+		"ratio": common.MapStr{"type": "scaled_float", "scaling_factor": 1000},
+		"spans": common.MapStr{"type": "nested", "properties": common.MapStr{}},
+	}
+	assert.Equal(t, expected, mapping["properties"])
+
+	dynamic := mapping["dynamic_templates"].([]common.MapStr)
+	assert.Len(t, dynamic, 2)
+	assert.Contains(t, dynamic[0], "strings_as_keyword")
+	assert.Equal(t, common.MapStr{
+		"labels": common.MapStr{
+			"mapping":            common.MapStr{"type": "text"},
+			"match_mapping_type": "string",
+			"path_match":         "labels.*",
+		},
+	}, dynamic[1])
+}
+
+func TestTemplateES2(t *testing.T) {
+	tmpl, err := New("testbeat", "5.6.0", "2.4.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := tmpl.Generate(testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, template["settings"].(common.MapStr)["index.mapping.total_fields.limit"])
+
+	mapping := template["mappings"].(common.MapStr)["_default_"].(common.MapStr)
+	assert.NotNil(t, mapping["_all"])
+
+	properties := mapping["properties"].(common.MapStr)
+	assert.Equal(t, "string", properties["message"].(common.MapStr)["type"])
+	assert.Equal(t, common.MapStr{
+		"type":         "string",
+		"index":        "not_analyzed",
+		"ignore_above": 1024,
+	}, properties["client"].(common.MapStr)["properties"].(common.MapStr)["ip"])
+	assert.Equal(t, common.MapStr{"type": "float"}, properties["ratio"])
+}
+
+func TestTemplateES6(t *testing.T) {
+	tmpl, err := New("testbeat", "5.6.0", "6.0.0-rc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template, err := tmpl.Generate(testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, template["template"])
+	assert.Equal(t, []string{"testbeat-*"}, template["index_patterns"])
+}
+
+func TestTemplateInvalid(t *testing.T) {
+	_, err := New("testbeat", "5.6.0", "1.7.5")
+	assert.Error(t, err)
+
+	_, err = New("testbeat", "5.6.0", "invalid")
+	assert.Error(t, err)
+
+	tmpl, err := New("testbeat", "5.6.0", "5.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tmpl.Generate(Fields{{Key: "test", Fields: Fields{{Name: "a", Type: "unknown"}}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package testing
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConsoleDriver prints the test results to a writer, indenting the output of
+// nested steps:
+//
+//   elasticsearch: http://localhost:9200...
+//     parse url... OK
+//     connection...
+//       dns lookup... OK
+type ConsoleDriver struct {
+	out    io.Writer
+	level  int
+	state  *consoleState
+	failed bool
+}
+
+type consoleState struct {
+	// true if the name of the step last started has been printed without
+	// newline
+	open   bool
+	failed bool
+}
+
+// fatalError is used to stop the current step on Fatal
+type fatalError struct{}
+
+// NewConsoleDriver creates a driver writing to out.
+func NewConsoleDriver(out io.Writer) *ConsoleDriver {
+	return &ConsoleDriver{
+		out:   out,
+		state: &consoleState{},
+	}
+}
+
+// Run executes a step.
+func (d *ConsoleDriver) Run(name string, f func(Driver)) {
+	d.closeLine()
+	fmt.Fprintf(d.out, "%s%s...", d.indent(), name)
+	d.state.open = true
+
+	child := &ConsoleDriver{
+		out:   d.out,
+		level: d.level + 1,
+		state: d.state,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(fatalError); !ok {
+				panic(r)
+			}
+		}
+		d.closeLine()
+	}()
+
+	f(child)
+}
+
+// Info prints information about the current step.
+func (d *ConsoleDriver) Info(field, value string) {
+	d.closeLine()
+	fmt.Fprintf(d.out, "%s%s: %s\n", d.indent(), field, value)
+}
+
+// Warn prints a warning.
+func (d *ConsoleDriver) Warn(field, reason string) {
+	d.report(field, "WARN "+reason)
+}
+
+// Error prints the result of a check.
+func (d *ConsoleDriver) Error(field string, err error) {
+	if err == nil {
+		d.report(field, "OK")
+		return
+	}
+
+	d.state.failed = true
+	d.report(field, "ERROR "+err.Error())
+}
+
+// Fatal prints the result of a check and stops the current step on error.
+func (d *ConsoleDriver) Fatal(field string, err error) {
+	d.Error(field, err)
+	if err != nil {
+		panic(fatalError{})
+	}
+}
+
+// Result prints the output of a step.
+func (d *ConsoleDriver) Result(data string) {
+	d.closeLine()
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		fmt.Fprintf(d.out, "%s%s\n", d.indent(), line)
+	}
+}
+
+// Failed returns true if any check reported an error.
+func (d *ConsoleDriver) Failed() bool {
+	return d.state.failed
+}
+
+func (d *ConsoleDriver) report(field, result string) {
+	// the result of a check for the step just started is printed on the
+	// same line, unless the step reported anything else before
+	if d.state.open && field == "" {
+		fmt.Fprintf(d.out, " %s\n", result)
+		d.state.open = false
+		return
+	}
+
+	d.closeLine()
+	fmt.Fprintf(d.out, "%s%s... %s\n", d.indent(), field, result)
+}
+
+func (d *ConsoleDriver) closeLine() {
+	if d.state.open {
+		fmt.Fprintln(d.out)
+		d.state.open = false
+	}
+}
+
+func (d *ConsoleDriver) indent() string {
+	return strings.Repeat("  ", d.level)
+}
@@ -0,0 +1,52 @@
+// +build !integration
+
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleDriver(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	d := NewConsoleDriver(buf)
+
+	d.Run("output: localhost", func(d Driver) {
+		d.Error("parse url", nil)
+		d.Run("connection", func(d Driver) {
+			d.Info("addresses", "127.0.0.1")
+			d.Fatal("dial up", errors.New("connection refused"))
+			d.Error("not reached", nil)
+		})
+		d.Warn("TLS", "secure connection disabled")
+	})
+	d.Run("other output", func(d Driver) {
+		d.Warn("", "testing not supported")
+	})
+
+	expected := `output: localhost...
+  parse url... OK
+  connection...
+    addresses: 127.0.0.1
+    dial up... ERROR connection refused
+  TLS... WARN secure connection disabled
+other output... WARN testing not supported
+`
+	assert.Equal(t, expected, buf.String())
+	assert.True(t, d.Failed())
+}
+
+func TestConsoleDriverResult(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	d := NewConsoleDriver(buf)
+
+	d.Run("step", func(d Driver) {
+		d.Result("line 1\nline 2\n")
+	})
+
+	assert.Equal(t, "step...\n  line 1\n  line 2\n", buf.String())
+	assert.False(t, d.Failed())
+}
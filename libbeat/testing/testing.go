@@ -0,0 +1,31 @@
+// Package testing provides the drivers used by the `test` commands to report
+// the results of diagnostic checks step by step.
+package testing
+
+// Driver reports the steps and results of a test run. Tests are organized
+// in a tree of named steps, each step created by calling Run.
+type Driver interface {
+	// Run executes f as a new named step. A call to Fatal within f stops
+	// the step, without stopping the calling step.
+	Run(name string, f func(Driver))
+
+	// Info reports some information about the current step.
+	Info(field, value string)
+
+	// Warn reports a warning for field.
+	Warn(field, reason string)
+
+	// Error reports the result of the check named field. The check passed if
+	// err is nil.
+	Error(field string, err error)
+
+	// Fatal reports the result of the check named field. If err is not nil,
+	// the current step is stopped.
+	Fatal(field string, err error)
+
+	// Result reports the output of the current step.
+	Result(data string)
+
+	// Failed returns true if any error has been reported.
+	Failed() bool
+}
@@ -0,0 +1,6 @@
+package version
+
+// GetDefaultVersion returns the current libbeat version.
+func GetDefaultVersion() string {
+	return defaultBeatVersion
+}
@@ -1,3 +1,3 @@
-package beat
+package version
 
 const defaultBeatVersion = "5.6.10"
@@ -0,0 +1,83 @@
+package hints
+
+import (
+	"strconv"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+func init() {
+	autodiscover.Registry.MustAddBuilder(autodiscover.HintsBuilder, NewMetricHints)
+}
+
+const (
+	metrics = "metrics"
+
+	enabled    = "enabled"
+	module     = "module"
+	metricsets = "metricsets"
+	hosts      = "hosts"
+	period     = "period"
+	timeout    = "timeout"
+
+	// defaultHost is used if no hosts hint is set, referring to the address
+	// of the container.
+	defaultHost = "${data.host}"
+)
+
+// metricHints creates the module configs for the containers with metrics
+// hints, e.g. co.elastic.metrics/module.
+type metricHints struct {
+	registry *mb.Register
+}
+
+// NewMetricHints creates the hints builder of metricbeat.
+func NewMetricHints(cfg *common.Config) (autodiscover.Builder, error) {
+	return &metricHints{registry: mb.Registry}, nil
+}
+
+// CreateConfig returns the module config for the metrics hints found in data.
+// No config is returned if the module hint is missing, or the metrics
+// collection is disabled by the enabled hint.
+func (m *metricHints) CreateConfig(data common.MapStr) []*common.Config {
+	name := autodiscover.GetHintString(data, metrics, module)
+	if name == "" {
+		return nil
+	}
+	if value := autodiscover.GetHintString(data, metrics, enabled); value != "" {
+		if on, err := strconv.ParseBool(value); err != nil || !on {
+			return nil
+		}
+	}
+
+	sets := autodiscover.GetHintList(data, metrics, metricsets)
+	if len(sets) == 0 {
+		sets = m.registry.MetricSets(name)
+	}
+
+	hostList := autodiscover.GetHintList(data, metrics, hosts)
+	if len(hostList) == 0 {
+		hostList = []string{defaultHost}
+	}
+
+	settings := common.MapStr{
+		module:     name,
+		metricsets: sets,
+		hosts:      hostList,
+	}
+	for _, setting := range []string{period, timeout} {
+		if value := autodiscover.GetHintString(data, metrics, setting); value != "" {
+			settings[setting] = value
+		}
+	}
+
+	config, err := common.NewConfigFrom(settings)
+	if err != nil {
+		logp.Err("Error creating config from hints: %v", err)
+		return nil
+	}
+	return []*common.Config{config}
+}
@@ -0,0 +1,88 @@
+// +build !integration
+
+package hints
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/metricbeat/mb"
+)
+
+type moduleConfig struct {
+	Module     string        `config:"module"`
+	MetricSets []string      `config:"metricsets"`
+	Hosts      []string      `config:"hosts"`
+	Period     time.Duration `config:"period"`
+}
+
+func newTestBuilder(t *testing.T) autodiscover.Builders {
+	registry := mb.NewRegister()
+	for _, name := range []string{"keyspace", "info"} {
+		err := registry.AddMetricSet("redis", name, func(mb.BaseMetricSet) (mb.MetricSet, error) {
+			return nil, nil
+		})
+		require.NoError(t, err)
+	}
+	return autodiscover.Builders{&metricHints{registry: registry}}
+}
+
+func testData(labels map[string]string) common.MapStr {
+	data := common.MapStr{
+		"container": common.MapStr{"id": "abc", "name": "cache"},
+		"host":      "172.17.0.2",
+	}
+	if hints := autodiscover.GenerateHints(labels, "co.elastic."); len(hints) > 0 {
+		data["hints"] = hints
+	}
+	return data
+}
+
+func TestMetricHints(t *testing.T) {
+	configs := newTestBuilder(t).GetConfigs(testData(map[string]string{
+		"co.elastic.metrics/module":     "redis",
+		"co.elastic.metrics/metricsets": "info",
+		"co.elastic.metrics/hosts":      "${data.host}:6379, ${data.container.name}:6380",
+		"co.elastic.metrics/period":     "1m",
+	}))
+	require.Len(t, configs, 1)
+
+	config := moduleConfig{}
+	require.NoError(t, configs[0].Unpack(&config))
+	assert.Equal(t, moduleConfig{
+		Module:     "redis",
+		MetricSets: []string{"info"},
+		Hosts:      []string{"172.17.0.2:6379", "cache:6380"},
+		Period:     time.Minute,
+	}, config)
+}
+
+func TestMetricHintsDefaults(t *testing.T) {
+	configs := newTestBuilder(t).GetConfigs(testData(map[string]string{
+		"co.elastic.metrics/module": "redis",
+	}))
+	require.Len(t, configs, 1)
+
+	config := moduleConfig{}
+	require.NoError(t, configs[0].Unpack(&config))
+	assert.Equal(t, []string{"info", "keyspace"}, config.MetricSets)
+	assert.Equal(t, []string{"172.17.0.2"}, config.Hosts)
+}
+
+func TestMetricHintsNoConfig(t *testing.T) {
+	builders := newTestBuilder(t)
+
+	tests := []map[string]string{
+		nil,
+		{"co.elastic.logs/multiline.pattern": "^\\s"},
+		{"co.elastic.metrics/module": "redis", "co.elastic.metrics/enabled": "false"},
+	}
+	for _, labels := range tests {
+		assert.Empty(t, builders.GetConfigs(testData(labels)), "%v", labels)
+	}
+}
@@ -1,10 +1,14 @@
 package beater
 
-import "github.com/elastic/beats/libbeat/common"
+import (
+	"github.com/elastic/beats/libbeat/autodiscover"
+	"github.com/elastic/beats/libbeat/common"
+)
 
 // Config is the root of the Metricbeat configuration hierarchy.
 type Config struct {
 	// Modules is a list of module specific configuration data.
-	Modules       []*common.Config `config:"modules"`
-	ReloadModules *common.Config   `config:"config.modules"`
+	Modules       []*common.Config     `config:"modules"`
+	ReloadModules *common.Config       `config:"config.modules"`
+	Autodiscover  *autodiscover.Config `config:"autodiscover"`
 }
@@ -3,15 +3,20 @@ package beater
 import (
 	"sync"
 
+	"github.com/elastic/beats/libbeat/autodiscover"
 	"github.com/elastic/beats/libbeat/beat"
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/publisher"
 	"github.com/elastic/beats/metricbeat/mb"
 	"github.com/elastic/beats/metricbeat/mb/module"
 
 	"github.com/elastic/beats/libbeat/cfgfile"
 	"github.com/pkg/errors"
+
+	// Register the hints builder of metricbeat.
+	_ "github.com/elastic/beats/metricbeat/autodiscover/builder/hints"
 )
 
 // Metricbeat implements the Beater interface for metricbeat.
@@ -36,8 +41,8 @@ func New(b *beat.Beat, rawConfig *common.Config) (beat.Beater, error) {
 
 	modules, err := module.NewWrappers(config.Modules, mb.Registry)
 	if err != nil {
-		// Empty config is fine if dynamic config is enabled
-		if !config.ReloadModules.Enabled() {
+		// Empty config is fine if dynamic config or autodiscover is enabled
+		if !config.ReloadModules.Enabled() && config.Autodiscover == nil {
 			return nil, err
 		} else if err != mb.ErrEmptyConfig && err != mb.ErrAllModulesDisabled {
 			return nil, err
@@ -61,6 +66,7 @@ func (bt *Metricbeat) Run(b *beat.Beat) error {
 
 	var wg sync.WaitGroup
 
+	module.RegisterState(monitoring.State, bt.modules)
 	for _, m := range bt.modules {
 		r := module.NewRunner(b.Publisher.Connect, m)
 		r.Start()
@@ -86,6 +92,24 @@ func (bt *Metricbeat) Run(b *beat.Beat) error {
 		}()
 	}
 
+	if bt.config.Autodiscover != nil {
+		logp.Warn("BETA: feature autodiscover is enabled.")
+		factory := module.NewFactory(b.Publisher)
+		adiscover, err := autodiscover.NewAutodiscover(factory, bt.config.Autodiscover)
+		if err != nil {
+			return err
+		}
+		if err := adiscover.Start(); err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-bt.done
+			adiscover.Stop()
+		}()
+	}
+
 	wg.Wait()
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/libbeat/processors"
 	"github.com/elastic/beats/metricbeat/mb"
 
@@ -174,6 +175,24 @@ func (mw *Wrapper) Hash() uint64 {
 	return mw.configHash
 }
 
+// RegisterState adds the number of modules and metricsets by module name to
+// the given state registry, replacing the previously registered state.
+func RegisterState(r *monitoring.Registry, modules []*Wrapper) {
+	r.Remove("module")
+	reg := r.NewRegistry("module")
+
+	for _, mw := range modules {
+		m := reg.GetRegistry(mw.Name())
+		if m == nil {
+			m = reg.NewRegistry(mw.Name())
+			monitoring.NewInt(m, "count")
+			monitoring.NewInt(m, "metricsets")
+		}
+		m.Get("count").(*monitoring.Int).Inc()
+		m.Get("metricsets").(*monitoring.Int).Add(int64(len(mw.metricSets)))
+	}
+}
+
 // metricSetWrapper methods
 
 // startFetching performs an immediate fetch for the MetricSet then it
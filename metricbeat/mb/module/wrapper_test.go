@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/monitoring"
 	"github.com/elastic/beats/metricbeat/mb"
 	"github.com/elastic/beats/metricbeat/mb/module"
 
@@ -93,3 +94,31 @@ func TestWrapper(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterState(t *testing.T) {
+	c := newConfig(t, map[string]interface{}{
+		"module":     moduleName,
+		"metricsets": []string{metricSetName},
+		"hosts":      []string{"alpha", "beta"},
+	})
+
+	m, err := module.NewWrapper(c, newTestRegistry(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg := monitoring.NewRegistry()
+	module.RegisterState(reg, []*module.Wrapper{m, m})
+	// Registering again replaces the previous state.
+	module.RegisterState(reg, []*module.Wrapper{m, m})
+
+	snapshot := monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+	assert.Equal(t, map[string]interface{}{
+		"module": map[string]interface{}{
+			moduleName: map[string]interface{}{
+				"count":      int64(2),
+				"metricsets": int64(4),
+			},
+		},
+	}, snapshot)
+}
@@ -144,6 +144,17 @@ func (r *Register) metricSetFactory(module, name string) (MetricSetFactory, Host
 	return info.factory, info.hostParser, nil
 }
 
+// MetricSets returns the sorted names of the MetricSets registered for the
+// module.
+func (r Register) MetricSets(module string) []string {
+	var names []string
+	for name := range r.metricSets[module] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // String return a string representation of the registered ModuleFactory's and
 // MetricSetFactory's.
 func (r Register) String() string {
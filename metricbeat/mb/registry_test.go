@@ -150,3 +150,16 @@ func TestMetricSetFactory(t *testing.T) {
 		assert.NotNil(t, hp) // Can't compare functions in Go so just check for non-nil.
 	})
 }
+
+func TestMetricSets(t *testing.T) {
+	registry := NewRegister()
+	for _, name := range []string{"b", "a"} {
+		err := registry.AddMetricSet(moduleName, name, fakeMetricSetFactory)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(t, []string{"a", "b"}, registry.MetricSets(moduleName))
+	assert.Empty(t, registry.MetricSets("unknown"))
+}